@@ -0,0 +1,241 @@
+// Package address parses, classifies and encodes bitcoin addresses.
+//
+// Legacy P2PKH and P2SH addresses are base58check encoded, native segwit
+// (P2WPKH, P2WSH) and taproot (P2TR) addresses are bech32/bech32m encoded.
+package address
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/smallnest/bitcoin/wallet/base58check"
+	"github.com/smallnest/bitcoin/wallet/bech32"
+)
+
+// Kind is the type of output script an address pays to.
+type Kind int
+
+const (
+	P2PKH Kind = iota + 1
+	P2SH
+	P2WPKH
+	P2WSH
+	P2TR
+	// WitnessUnknown is a segwit address with a witness version or
+	// program length that has no meaning yet.
+	WitnessUnknown
+)
+
+func (k Kind) String() string {
+	switch k {
+	case P2PKH:
+		return "p2pkh"
+	case P2SH:
+		return "p2sh"
+	case P2WPKH:
+		return "p2wpkh"
+	case P2WSH:
+		return "p2wsh"
+	case P2TR:
+		return "p2tr"
+	case WitnessUnknown:
+		return "witness_unknown"
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+// IsSegwit reports whether addresses of this kind are bech32 encoded.
+func (k Kind) IsSegwit() bool {
+	return k >= P2WPKH
+}
+
+// Address is a decoded bitcoin address.
+type Address struct {
+	Network Network
+	Kind    Kind
+	// Hash is the 20-byte hash of a P2PKH or P2SH address, or the witness
+	// program of a segwit address.
+	Hash []byte
+	// WitnessVersion is the witness version of a segwit address.
+	WitnessVersion byte
+}
+
+// New returns an address of the given kind. The hash length must match the
+// kind: 20 bytes for P2PKH, P2SH and P2WPKH, 32 bytes for P2WSH and P2TR.
+func New(net Network, kind Kind, hash []byte) (*Address, error) {
+	var version byte
+	switch kind {
+	case P2PKH, P2SH, P2WPKH:
+		if len(hash) != 20 {
+			return nil, fmt.Errorf("address: %s needs a 20-byte hash, got %d", kind, len(hash))
+		}
+	case P2WSH:
+		if len(hash) != 32 {
+			return nil, fmt.Errorf("address: %s needs a 32-byte hash, got %d", kind, len(hash))
+		}
+	case P2TR:
+		if len(hash) != 32 {
+			return nil, fmt.Errorf("address: %s needs a 32-byte key, got %d", kind, len(hash))
+		}
+		version = 1
+	default:
+		return nil, fmt.Errorf("address: cannot create %s address", kind)
+	}
+	return &Address{Network: net, Kind: kind, Hash: append([]byte(nil), hash...), WitnessVersion: version}, nil
+}
+
+// NewWitness returns a segwit address for the witness version and program.
+func NewWitness(net Network, version byte, program []byte) (*Address, error) {
+	// Let the bech32 package validate version and program length.
+	if _, err := bech32.EncodeSegwitAddress(net.HRP(), version, program); err != nil {
+		return nil, err
+	}
+	return &Address{
+		Network:        net,
+		Kind:           witnessKind(version, len(program)),
+		Hash:           append([]byte(nil), program...),
+		WitnessVersion: version,
+	}, nil
+}
+
+func witnessKind(version byte, length int) Kind {
+	switch {
+	case version == 0 && length == 20:
+		return P2WPKH
+	case version == 0 && length == 32:
+		return P2WSH
+	case version == 1 && length == 32:
+		return P2TR
+	}
+	return WitnessUnknown
+}
+
+// Parse decodes and classifies any bitcoin address.
+//
+// Testnet and signet addresses are encoded identically and are reported as
+// Testnet, so are base58 regtest addresses. Use ParseFor when the network
+// is known.
+func Parse(s string) (*Address, error) {
+	if hrp, ok := segwitHRP(s); ok {
+		_, version, program, err := bech32.DecodeSegwit(s)
+		if err != nil {
+			return nil, err
+		}
+		net := Testnet
+		switch hrp {
+		case bech32.MainnetHRP:
+			net = Mainnet
+		case bech32.RegtestHRP:
+			net = Regtest
+		}
+		return &Address{
+			Network:        net,
+			Kind:           witnessKind(version, len(program)),
+			Hash:           program,
+			WitnessVersion: version,
+		}, nil
+	}
+
+	version, hash, err := base58check.Decode(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(hash) != 20 {
+		return nil, fmt.Errorf("address: invalid hash length %d", len(hash))
+	}
+
+	a := &Address{Hash: hash}
+	switch version {
+	case Mainnet.PubKeyHashID():
+		a.Network, a.Kind = Mainnet, P2PKH
+	case Mainnet.ScriptHashID():
+		a.Network, a.Kind = Mainnet, P2SH
+	case Testnet.PubKeyHashID():
+		a.Network, a.Kind = Testnet, P2PKH
+	case Testnet.ScriptHashID():
+		a.Network, a.Kind = Testnet, P2SH
+	default:
+		return nil, fmt.Errorf("address: unknown version byte 0x%02x", version)
+	}
+	return a, nil
+}
+
+// ParseFor decodes an address and checks that it is valid on net.
+func ParseFor(s string, net Network) (*Address, error) {
+	a, err := Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	if !a.Network.sameEncoding(net, a.Kind.IsSegwit()) {
+		return nil, fmt.Errorf("address: %s is a %s address, not %s", s, a.Network, net)
+	}
+	a.Network = net
+	return a, nil
+}
+
+// segwitHRP returns the human readable part of s if it is the one of a
+// known network.
+func segwitHRP(s string) (string, bool) {
+	pos := strings.LastIndexByte(s, '1')
+	if pos < 0 {
+		return "", false
+	}
+	hrp := strings.ToLower(s[:pos])
+	switch hrp {
+	case bech32.MainnetHRP, bech32.TestnetHRP, bech32.RegtestHRP:
+		return hrp, true
+	}
+	return "", false
+}
+
+// String encodes the address.
+func (a *Address) String() string {
+	switch a.Kind {
+	case P2PKH:
+		return base58check.EncodeVersion([]byte{a.Network.PubKeyHashID()}, a.Hash)
+	case P2SH:
+		return base58check.EncodeVersion([]byte{a.Network.ScriptHashID()}, a.Hash)
+	}
+	s, err := bech32.EncodeSegwitAddress(a.Network.HRP(), a.WitnessVersion, a.Hash)
+	if err != nil {
+		return ""
+	}
+	return s
+}
+
+// Equal reports whether both addresses pay to the same script on the same
+// network.
+func (a *Address) Equal(b *Address) bool {
+	return a.Network == b.Network && a.Kind == b.Kind &&
+		a.WitnessVersion == b.WitnessVersion && bytes.Equal(a.Hash, b.Hash)
+}
+
+// ScriptPubKey returns the output script that pays to the address.
+func (a *Address) ScriptPubKey() []byte {
+	var script bytes.Buffer
+	switch a.Kind {
+	case P2PKH:
+		script.WriteByte(0x76)              //OP_DUP
+		script.WriteByte(0xa9)              //OP_HASH160
+		script.WriteByte(byte(len(a.Hash))) //PUSH
+		script.Write(a.Hash)
+		script.WriteByte(0x88) //OP_EQUALVERIFY
+		script.WriteByte(0xac) //OP_CHECKSIG
+	case P2SH:
+		script.WriteByte(0xa9)              //OP_HASH160
+		script.WriteByte(byte(len(a.Hash))) //PUSH
+		script.Write(a.Hash)
+		script.WriteByte(0x87) //OP_EQUAL
+	default:
+		//OP_0 for version 0, OP_1 to OP_16 (0x51-0x60) otherwise
+		if a.WitnessVersion == 0 {
+			script.WriteByte(0x00)
+		} else {
+			script.WriteByte(0x50 + a.WitnessVersion)
+		}
+		script.WriteByte(byte(len(a.Hash))) //PUSH
+		script.Write(a.Hash)
+	}
+	return script.Bytes()
+}
@@ -0,0 +1,238 @@
+package address
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func decodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+var parseVectors = []struct {
+	addr   string
+	net    Network
+	kind   Kind
+	script string
+	// validOn lists the networks ParseFor accepts the address for.
+	validOn []Network
+}{
+	{
+		"1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", Mainnet, P2PKH,
+		"76a91462e907b15cbf27d5425399ebf6f0fb50ebb88f1888ac",
+		[]Network{Mainnet},
+	},
+	{
+		"39bgKC7RFbpoCRbtD5KEdkYKtNyhpsNa3Z", Mainnet, P2SH,
+		"a91456be8ea93912f37685542a2a864a5600f88a675487",
+		[]Network{Mainnet},
+	},
+	{
+		"mipcBbFg9gMiCh81Kj8tqqdgoZub1ZJRfn", Testnet, P2PKH,
+		"76a914243f1394f44554f4ce3fd68649c19adc483ce92488ac",
+		[]Network{Testnet, Signet, Regtest},
+	},
+	{
+		"2MzQwSSnBHWHqSAqtTVQ6v47XtaisrJa1Vc", Testnet, P2SH,
+		"a9144e9f39ca4688ff102128ea4ccda34105324305b087",
+		[]Network{Testnet, Signet, Regtest},
+	},
+	{
+		"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", Mainnet, P2WPKH,
+		"0014751e76e8199196d454941c45d1b3a323f1433bd6",
+		[]Network{Mainnet},
+	},
+	{
+		"BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4", Mainnet, P2WPKH,
+		"0014751e76e8199196d454941c45d1b3a323f1433bd6",
+		[]Network{Mainnet},
+	},
+	{
+		"tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sl5k7", Testnet, P2WSH,
+		"00201863143c14c5166804bd19203356da136c985678cd4d27a1b8c6329604903262",
+		[]Network{Testnet, Signet},
+	},
+	{
+		"bcrt1qw508d6qejxtdg4y5r3zarvary0c5xw7kygt080", Regtest, P2WPKH,
+		"0014751e76e8199196d454941c45d1b3a323f1433bd6",
+		[]Network{Regtest},
+	},
+	{
+		"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0", Mainnet, P2TR,
+		"512079be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798",
+		[]Network{Mainnet},
+	},
+	{
+		"tb1pqqqqp399et2xygdj5xreqhjjvcmzhxw4aywxecjdzew6hylgvsesf3hn0c", Testnet, P2TR,
+		"5120000000c4a5cad46221b2a187905e5266362b99d5e91c6ce24d165dab93e86433",
+		[]Network{Testnet, Signet},
+	},
+	{
+		"bc1zw508d6qejxtdg4y5r3zarvaryvaxxpcs", Mainnet, WitnessUnknown,
+		"5210751e76e8199196d454941c45d1b3a323",
+		[]Network{Mainnet},
+	},
+}
+
+func TestParse(t *testing.T) {
+	for _, v := range parseVectors {
+		a, err := Parse(v.addr)
+		if err != nil {
+			t.Errorf("%s: %v", v.addr, err)
+			continue
+		}
+		if a.Network != v.net || a.Kind != v.kind {
+			t.Errorf("%s: %s %s, want %s %s", v.addr, a.Network, a.Kind, v.net, v.kind)
+		}
+		if got := hex.EncodeToString(a.ScriptPubKey()); got != v.script {
+			t.Errorf("%s: script %s, want %s", v.addr, got, v.script)
+		}
+		if got := a.String(); got != strings.ToLower(v.addr) && got != v.addr {
+			t.Errorf("%s: String gives %s", v.addr, got)
+		}
+	}
+}
+
+func TestParseFor(t *testing.T) {
+	for _, v := range parseVectors {
+		for _, net := range Networks {
+			valid := false
+			for _, n := range v.validOn {
+				valid = valid || n == net
+			}
+			a, err := ParseFor(v.addr, net)
+			if !valid {
+				if err == nil {
+					t.Errorf("%s: accepted on %s", v.addr, net)
+				}
+				continue
+			}
+			if err != nil {
+				t.Errorf("%s on %s: %v", v.addr, net, err)
+				continue
+			}
+			if a.Network != net {
+				t.Errorf("%s on %s: network %s", v.addr, net, a.Network)
+			}
+			// Encoding it again for the network gives the same address.
+			if got := a.String(); !strings.EqualFold(got, v.addr) {
+				t.Errorf("%s on %s: String gives %s", v.addr, net, got)
+			}
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, s := range []string{
+		"",
+		// Bad checksum.
+		"1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNb",
+		// A WIF private key, not an address.
+		"5HueCGU8rMjxEXxiPuD5BDku4MkFqeZyd4dZ1jvhTVqvbTLvyTJ",
+		// An xpub.
+		"xpub661MyMwAqRbcFtXgS5sYJABqqG9YLmC4Q1Rdap9gSE8NqtwybGhePY2gZ29ESFjqJoCu1Rupje8YtGqsefD265TMg7usUDFdp6W1EGMcet8",
+		// Witness version 1 with a bech32 checksum.
+		"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqh2y7hd",
+		// An unknown human readable part.
+		"tc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vq5zuyut",
+	} {
+		if a, err := Parse(s); err == nil {
+			t.Errorf("%q parsed as %s %s", s, a.Network, a.Kind)
+		}
+	}
+}
+
+func TestFromKey(t *testing.T) {
+	// The public keys of private key 1.
+	compressed := decodeHex(t, "0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798")
+	uncompressed := decodeHex(t, "0479be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798483ada7726a3c4655da4fbfc0e1108a8fd17b448a68554199c47d08ffb10d4b8")
+
+	if got := NewP2PKHFromKey(Mainnet, compressed).String(); got != "1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH" {
+		t.Errorf("P2PKH %s", got)
+	}
+	if got := NewP2PKHFromKey(Mainnet, uncompressed).String(); got != "1EHNa6Q4Jz2uvNExL497mE43ikXhwF6kZm" {
+		t.Errorf("uncompressed P2PKH %s", got)
+	}
+	if a, err := NewP2WPKHFromKey(Mainnet, compressed); err != nil || a.String() != "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4" {
+		t.Errorf("P2WPKH %v, %v", a, err)
+	}
+	if a, err := NewP2SHP2WPKHFromKey(Mainnet, compressed); err != nil || a.String() != "3JvL6Ymt8MVWiCNHC7oWU6nLeHNJKLZGLN" {
+		t.Errorf("P2SH-P2WPKH %v, %v", a, err)
+	}
+	if _, err := NewP2WPKHFromKey(Mainnet, uncompressed); err == nil {
+		t.Error("P2WPKH of an uncompressed key")
+	}
+	if _, err := NewP2SHP2WPKHFromKey(Mainnet, uncompressed); err == nil {
+		t.Error("P2SH-P2WPKH of an uncompressed key")
+	}
+}
+
+func TestFromScript(t *testing.T) {
+	// The 2-of-2 multisig script of BIP67 vector 1.
+	script := decodeHex(t, "522102fe6f0a5a297eb38c391581c4413e084773ea23954d93f7753db7dc0adc188b2f2102ff12471208c14bd580709cb2358d98975247d8765f92bc25eab3b2763ed605f852ae")
+	if got := NewP2SHFromScript(Mainnet, script).String(); got != "39bgKC7RFbpoCRbtD5KEdkYKtNyhpsNa3Z" {
+		t.Errorf("P2SH %s", got)
+	}
+	p2wsh := NewP2WSHFromScript(Testnet, script)
+	if p2wsh.Kind != P2WSH || len(p2wsh.Hash) != 32 {
+		t.Errorf("P2WSH %s %x", p2wsh.Kind, p2wsh.Hash)
+	}
+	// P2SH-P2WSH pays to the script OP_0 <sha256(script)>.
+	nested := NewP2SHP2WSHFromScript(Mainnet, script)
+	if !nested.Equal(NewP2SHFromScript(Mainnet, NewP2WSHFromScript(Mainnet, script).ScriptPubKey())) {
+		t.Errorf("P2SH-P2WSH %s", nested)
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, v := range []struct {
+		kind Kind
+		n    int
+		ok   bool
+	}{
+		{P2PKH, 20, true},
+		{P2SH, 20, true},
+		{P2WPKH, 20, true},
+		{P2WSH, 32, true},
+		{P2TR, 32, true},
+		{P2PKH, 32, false},
+		{P2WSH, 20, false},
+		{P2TR, 33, false},
+		{WitnessUnknown, 20, false},
+	} {
+		a, err := New(Signet, v.kind, make([]byte, v.n))
+		if (err == nil) != v.ok {
+			t.Errorf("%s with %d bytes: %v", v.kind, v.n, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		b, err := ParseFor(a.String(), Signet)
+		if err != nil || !a.Equal(b) {
+			t.Errorf("%s: %s parses as %v, %v", v.kind, a, b, err)
+		}
+	}
+}
+
+func TestParseNetwork(t *testing.T) {
+	for _, n := range Networks {
+		got, err := ParseNetwork(strings.ToUpper(n.String()))
+		if err != nil || got != n {
+			t.Errorf("%s: %s, %v", n, got, err)
+		}
+		var u Network
+		if text, _ := n.MarshalText(); u.UnmarshalText(text) != nil || u != n {
+			t.Errorf("%s: text round trip gives %s", n, u)
+		}
+	}
+	if _, err := ParseNetwork("testnet4"); err == nil {
+		t.Error("ParseNetwork accepted testnet4")
+	}
+}
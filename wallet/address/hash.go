@@ -0,0 +1,17 @@
+package address
+
+import (
+	"crypto/sha256"
+
+	"golang.org/x/crypto/ripemd160"
+)
+
+// Hash160 returns RIPEMD160(SHA256(b)), the hash used in P2PKH, P2SH and
+// P2WPKH addresses.
+func Hash160(b []byte) []byte {
+	shaHash := sha256.Sum256(b)
+
+	ripeHash := ripemd160.New()
+	ripeHash.Write(shaHash[:])
+	return ripeHash.Sum(nil)
+}
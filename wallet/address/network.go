@@ -0,0 +1,115 @@
+package address
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/smallnest/bitcoin/wallet/bech32"
+)
+
+// Network identifies the bitcoin network an address belongs to.
+type Network int
+
+const (
+	Mainnet Network = iota
+	Testnet
+	Signet
+	Regtest
+)
+
+// Networks lists every supported network, mainnet first.
+var Networks = []Network{Mainnet, Testnet, Signet, Regtest}
+
+func (n Network) String() string {
+	switch n {
+	case Mainnet:
+		return "mainnet"
+	case Testnet:
+		return "testnet"
+	case Signet:
+		return "signet"
+	case Regtest:
+		return "regtest"
+	}
+	return fmt.Sprintf("Network(%d)", int(n))
+}
+
+// ParseNetwork returns the network with the given name.
+func ParseNetwork(name string) (Network, error) {
+	for _, n := range Networks {
+		if strings.EqualFold(name, n.String()) {
+			return n, nil
+		}
+	}
+	return 0, fmt.Errorf("address: unknown network %q", name)
+}
+
+// PubKeyHashID is the base58check version byte of P2PKH addresses.
+// Testnet, signet and regtest all share 0x6f.
+func (n Network) PubKeyHashID() byte {
+	if n == Mainnet {
+		return 0x00
+	}
+	return 0x6f
+}
+
+// ScriptHashID is the base58check version byte of P2SH addresses.
+// Testnet, signet and regtest all share 0xc4.
+func (n Network) ScriptHashID() byte {
+	if n == Mainnet {
+		return 0x05
+	}
+	return 0xc4
+}
+
+// PrivateKeyID is the base58check version byte of WIF private keys.
+// Testnet, signet and regtest all share 0xef.
+func (n Network) PrivateKeyID() byte {
+	if n == Mainnet {
+		return 0x80
+	}
+	return 0xef
+}
+
+// HRP is the human readable part of segwit addresses.
+func (n Network) HRP() string {
+	switch n {
+	case Mainnet:
+		return bech32.MainnetHRP
+	case Signet:
+		return bech32.SignetHRP
+	case Regtest:
+		return bech32.RegtestHRP
+	}
+	return bech32.TestnetHRP
+}
+
+// sameEncoding reports whether addresses of n and m are indistinguishable.
+// Testnet and signet share every prefix, regtest differs only by its HRP.
+func (n Network) sameEncoding(m Network, segwit bool) bool {
+	if n == m {
+		return true
+	}
+	if n == Mainnet || m == Mainnet {
+		return false
+	}
+	if segwit {
+		return n != Regtest && m != Regtest
+	}
+	return true
+}
+
+// MarshalText encodes the network as its name, e.g. in JSON.
+func (n Network) MarshalText() ([]byte, error) {
+	return []byte(n.String()), nil
+}
+
+// UnmarshalText decodes a network name.
+func (n *Network) UnmarshalText(text []byte) error {
+	net, err := ParseNetwork(string(text))
+	if err != nil {
+		return err
+	}
+	*n = net
+	return nil
+}
@@ -0,0 +1,33 @@
+package address
+
+import "errors"
+
+// ErrUncompressedKey is returned when a segwit address is requested for an
+// uncompressed public key, which segwit does not allow.
+var ErrUncompressedKey = errors.New("address: segwit needs a compressed public key")
+
+// NewP2PKHFromKey returns the legacy address of a compressed or
+// uncompressed public key.
+func NewP2PKHFromKey(net Network, pubKey []byte) *Address {
+	return &Address{Network: net, Kind: P2PKH, Hash: Hash160(pubKey)}
+}
+
+// NewP2WPKHFromKey returns the native segwit address of a compressed
+// public key.
+func NewP2WPKHFromKey(net Network, pubKey []byte) (*Address, error) {
+	if len(pubKey) != 33 {
+		return nil, ErrUncompressedKey
+	}
+	return &Address{Network: net, Kind: P2WPKH, Hash: Hash160(pubKey)}, nil
+}
+
+// NewP2SHP2WPKHFromKey returns the nested segwit address of a compressed
+// public key: a P2SH address whose redeem script is the P2WPKH output
+// script.
+func NewP2SHP2WPKHFromKey(net Network, pubKey []byte) (*Address, error) {
+	w, err := NewP2WPKHFromKey(net, pubKey)
+	if err != nil {
+		return nil, err
+	}
+	return &Address{Network: net, Kind: P2SH, Hash: Hash160(w.ScriptPubKey())}, nil
+}
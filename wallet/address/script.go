@@ -0,0 +1,21 @@
+package address
+
+import "crypto/sha256"
+
+// NewP2SHFromScript returns the legacy P2SH address of a redeem script.
+func NewP2SHFromScript(net Network, redeemScript []byte) *Address {
+	return &Address{Network: net, Kind: P2SH, Hash: Hash160(redeemScript)}
+}
+
+// NewP2WSHFromScript returns the native segwit address of a witness
+// script, its SHA256 hash.
+func NewP2WSHFromScript(net Network, witnessScript []byte) *Address {
+	hash := sha256.Sum256(witnessScript)
+	return &Address{Network: net, Kind: P2WSH, Hash: hash[:]}
+}
+
+// NewP2SHP2WSHFromScript returns the nested segwit address of a witness
+// script: a P2SH address whose redeem script is the P2WSH output script.
+func NewP2SHP2WSHFromScript(net Network, witnessScript []byte) *Address {
+	return NewP2SHFromScript(net, NewP2WSHFromScript(net, witnessScript).ScriptPubKey())
+}
@@ -3,6 +3,9 @@
 // As opposed to base64 and friends, base58 is typically used to
 // convert integers. You can use big.Int.SetBytes to convert arbitrary
 // bytes to an integer first, and big.Int.Bytes the other way around.
+//
+// Encode and Decode work directly on byte slices without math/big and
+// keep leading zero bytes as leading '1' characters, as bitcoin does.
 package base58
 
 import (
@@ -12,6 +15,10 @@ import (
 
 const alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
 
+// Alphabet is the bitcoin base58 alphabet. 0, O, I and l are left out
+// because they are easily mistaken for each other.
+const Alphabet = alphabet
+
 var decodeMap [256]byte
 
 func init() {
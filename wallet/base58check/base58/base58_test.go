@@ -0,0 +1,133 @@
+package base58
+
+import (
+	"bytes"
+	"math/big"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// encodeBig is Encode done with math/big: a leading '1' for every leading
+// zero byte, then the number.
+func encodeBig(src []byte) string {
+	zeros := 0
+	for zeros < len(src) && src[zeros] == 0 {
+		zeros++
+	}
+	return strings.Repeat("1", zeros) + string(EncodeBig(nil, new(big.Int).SetBytes(src)))
+}
+
+// decodeBig is Decode done with math/big.
+func decodeBig(src string) ([]byte, error) {
+	zeros := 0
+	for zeros < len(src) && src[zeros] == '1' {
+		zeros++
+	}
+	n, err := DecodeToBig([]byte(src))
+	if err != nil {
+		return nil, err
+	}
+	return append(make([]byte, zeros), n.Bytes()...), nil
+}
+
+func TestBytesAgreeWithBig(t *testing.T) {
+	inputs := [][]byte{
+		{},
+		{0},
+		{0, 0, 0},
+		{1},
+		{0, 0, 1},
+		{0xff},
+		{0, 0xff, 0xff},
+		bytes.Repeat([]byte{0xff}, 64),
+	}
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 500; i++ {
+		src := make([]byte, r.Intn(80))
+		r.Read(src)
+		// Zero a random number of leading bytes.
+		zeros := r.Intn(5)
+		for j := 0; j < len(src) && j < zeros; j++ {
+			src[j] = 0
+		}
+		inputs = append(inputs, src)
+	}
+
+	for _, src := range inputs {
+		want := encodeBig(src)
+		got := string(Encode(nil, src))
+		if got != want {
+			t.Fatalf("Encode(%x) = %q, math/big gives %q", src, got, want)
+		}
+
+		decoded, err := Decode([]byte(got))
+		if err != nil {
+			t.Fatalf("Decode(%q): %v", got, err)
+		}
+		if !bytes.Equal(decoded, src) {
+			t.Fatalf("Decode(%q) = %x, want %x", got, decoded, src)
+		}
+		decodedBig, err := decodeBig(got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(decoded, decodedBig) {
+			t.Fatalf("Decode(%q) = %x, math/big gives %x", got, decoded, decodedBig)
+		}
+	}
+}
+
+func TestEncodeAppends(t *testing.T) {
+	if got := string(Encode([]byte("x:"), []byte{0, 0, 0x39})); got != "x:11z" {
+		t.Errorf("Encode appends %q, want %q", got, "x:11z")
+	}
+}
+
+func TestDecodeCorrupt(t *testing.T) {
+	for _, s := range []string{"0", "11O", "abcl", "I"} {
+		_, err := Decode([]byte(s))
+		want := CorruptInputError(strings.IndexAny(s, "0OIl"))
+		if err != want {
+			t.Errorf("Decode(%q) = %v, want %v", s, err, want)
+		}
+		if _, err := DecodeToBig([]byte(s)); err != want {
+			t.Errorf("DecodeToBig(%q) = %v, want %v", s, err, want)
+		}
+	}
+}
+
+// benchInput is the size of an extended key with its checksum, with a
+// leading zero byte like an address.
+var benchInput = append([]byte{0}, bytes.Repeat([]byte{0xa5, 0x3c, 0x96}, 27)...)
+
+func BenchmarkEncode(b *testing.B) {
+	b.SetBytes(int64(len(benchInput)))
+	for i := 0; i < b.N; i++ {
+		Encode(nil, benchInput)
+	}
+}
+
+func BenchmarkDecode(b *testing.B) {
+	encoded := Encode(nil, benchInput)
+	b.SetBytes(int64(len(encoded)))
+	for i := 0; i < b.N; i++ {
+		Decode(encoded)
+	}
+}
+
+func BenchmarkEncodeBig(b *testing.B) {
+	n := new(big.Int).SetBytes(benchInput)
+	b.SetBytes(int64(len(benchInput)))
+	for i := 0; i < b.N; i++ {
+		EncodeBig(nil, n)
+	}
+}
+
+func BenchmarkDecodeToBig(b *testing.B) {
+	encoded := Encode(nil, benchInput)
+	b.SetBytes(int64(len(encoded)))
+	for i := 0; i < b.N; i++ {
+		DecodeToBig(encoded)
+	}
+}
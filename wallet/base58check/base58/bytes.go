@@ -0,0 +1,79 @@
+package base58
+
+// Encode encodes src, appending to dst. Be sure to use the returned
+// new value of dst. Every leading zero byte of src is encoded as a
+// leading '1'.
+func Encode(dst []byte, src []byte) []byte {
+	zeros := 0
+	for zeros < len(src) && src[zeros] == 0 {
+		zeros++
+	}
+
+	// log(256) / log(58), rounded up.
+	size := (len(src)-zeros)*138/100 + 1
+	buf := make([]byte, size)
+
+	// high is the index of the most significant digit written so far,
+	// everything before it is still zero.
+	high := size - 1
+	for _, b := range src[zeros:] {
+		carry := uint32(b)
+		j := size - 1
+		for ; j > high || carry != 0; j-- {
+			carry += uint32(buf[j]) << 8
+			buf[j] = byte(carry % 58)
+			carry /= 58
+		}
+		high = j
+	}
+
+	i := 0
+	for i < size && buf[i] == 0 {
+		i++
+	}
+
+	for n := 0; n < zeros; n++ {
+		dst = append(dst, alphabet[0])
+	}
+	for ; i < size; i++ {
+		dst = append(dst, alphabet[buf[i]])
+	}
+	return dst
+}
+
+// Decode decodes src into a new byte slice. Every leading '1' of src is
+// decoded as a leading zero byte. Returns an error on corrupt input.
+func Decode(src []byte) ([]byte, error) {
+	zeros := 0
+	for zeros < len(src) && src[zeros] == alphabet[0] {
+		zeros++
+	}
+
+	// log(58) / log(256), rounded up.
+	size := (len(src)-zeros)*733/1000 + 1
+	buf := make([]byte, size)
+
+	high := size - 1
+	for i := zeros; i < len(src); i++ {
+		carry := uint32(decodeMap[src[i]])
+		if carry == 0xFF {
+			return nil, CorruptInputError(i)
+		}
+		j := size - 1
+		for ; j > high || carry != 0; j-- {
+			carry += uint32(buf[j]) * 58
+			buf[j] = byte(carry)
+			carry >>= 8
+		}
+		high = j
+	}
+
+	i := 0
+	for i < size && buf[i] == 0 {
+		i++
+	}
+
+	out := make([]byte, zeros+size-i)
+	copy(out[zeros:], buf[i:])
+	return out, nil
+}
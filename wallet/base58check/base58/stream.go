@@ -0,0 +1,81 @@
+package base58
+
+import (
+	"bytes"
+	"errors"
+	"io"
+)
+
+// An Encoder base58 encodes everything written to it. Base58 treats the
+// whole input as one number, so nothing is written to the underlying
+// writer until Close is called.
+type Encoder struct {
+	w      io.Writer
+	buf    bytes.Buffer
+	closed bool
+}
+
+var errClosed = errors.New("base58: write to closed Encoder")
+
+// NewEncoder returns an Encoder writing to w. The caller must Close the
+// Encoder to flush the encoded data.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// Write buffers p to be encoded on Close.
+func (e *Encoder) Write(p []byte) (int, error) {
+	if e.closed {
+		return 0, errClosed
+	}
+	return e.buf.Write(p)
+}
+
+// Close encodes the buffered data and writes it to the underlying writer.
+// It does not close the underlying writer.
+func (e *Encoder) Close() error {
+	if e.closed {
+		return errClosed
+	}
+	e.closed = true
+	_, err := e.w.Write(Encode(nil, e.buf.Bytes()))
+	return err
+}
+
+// A Decoder reads base58 text from an underlying reader and returns the
+// decoded bytes. The whole input is consumed on the first Read.
+type Decoder struct {
+	r   io.Reader
+	out []byte
+	err error
+}
+
+// NewDecoder returns a Decoder reading from r. Leading and trailing
+// white space in the input is ignored.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: r}
+}
+
+// Read reads decoded bytes into p.
+func (d *Decoder) Read(p []byte) (int, error) {
+	if d.r != nil {
+		src, err := io.ReadAll(d.r)
+		d.r = nil
+		if err == nil {
+			d.out, err = Decode(bytes.TrimSpace(src))
+		}
+		if err != nil {
+			d.err = err
+		}
+	}
+
+	if len(d.out) == 0 {
+		if d.err != nil {
+			return 0, d.err
+		}
+		return 0, io.EOF
+	}
+	n := copy(p, d.out)
+	d.out = d.out[n:]
+	return n, nil
+}
@@ -0,0 +1,110 @@
+package base58
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// errWriter fails every write.
+type errWriter struct{}
+
+func (errWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestStreamRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	inputs := [][]byte{{}, {0}, {0, 0, 0}, {0, 0, 1, 2, 3}}
+	for i := 0; i < 50; i++ {
+		src := make([]byte, 1+r.Intn(100))
+		r.Read(src)
+		src[0] = 0
+		inputs = append(inputs, src)
+	}
+
+	for _, src := range inputs {
+		// Write a byte at a time.
+		var encoded bytes.Buffer
+		enc := NewEncoder(&encoded)
+		for i := range src {
+			if n, err := enc.Write(src[i : i+1]); n != 1 || err != nil {
+				t.Fatalf("Write: %d, %v", n, err)
+			}
+		}
+		if encoded.Len() != 0 {
+			t.Fatalf("%x: %q written before Close", src, encoded.String())
+		}
+		if err := enc.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := encoded.String(), string(Encode(nil, src)); got != want {
+			t.Fatalf("%x: encoded %q, want %q", src, got, want)
+		}
+
+		// Read a byte at a time from a reader returning a byte at a time.
+		dec := NewDecoder(iotest.OneByteReader(strings.NewReader(" " + encoded.String() + "\n")))
+		var decoded []byte
+		p := make([]byte, 1)
+		for {
+			n, err := dec.Read(p)
+			decoded = append(decoded, p[:n]...)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		if !bytes.Equal(decoded, src) {
+			t.Fatalf("decoded %x, want %x", decoded, src)
+		}
+
+		if err := iotest.TestReader(NewDecoder(strings.NewReader(encoded.String())), src); err != nil {
+			t.Fatalf("%x: %v", src, err)
+		}
+	}
+}
+
+func TestEncoderClose(t *testing.T) {
+	enc := NewEncoder(io.Discard)
+	if err := enc.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := enc.Write([]byte{1}); err == nil {
+		t.Error("Write after Close succeeded")
+	}
+	if err := enc.Close(); err == nil {
+		t.Error("second Close succeeded")
+	}
+
+	enc = NewEncoder(errWriter{})
+	enc.Write([]byte{1, 2, 3})
+	if err := enc.Close(); err == nil {
+		t.Error("Close did not return the write error")
+	}
+}
+
+func TestDecoderErrors(t *testing.T) {
+	dec := NewDecoder(strings.NewReader("11O"))
+	p := make([]byte, 8)
+	for i := 0; i < 2; i++ {
+		if _, err := dec.Read(p); err != CorruptInputError(2) {
+			t.Errorf("read %d: %v, want CorruptInputError(2)", i, err)
+		}
+	}
+
+	readErr := errors.New("read failed")
+	if _, err := NewDecoder(iotest.ErrReader(readErr)).Read(p); err != readErr {
+		t.Errorf("got %v, want the read error", err)
+	}
+	// Data read before the error is dropped, base58 needs the whole input.
+	r := io.MultiReader(strings.NewReader("2NEpo7TZRRrLZSi2U"), iotest.ErrReader(readErr))
+	if n, err := NewDecoder(r).Read(p); n != 0 || err != readErr {
+		t.Errorf("got %d, %v, want the read error", n, err)
+	}
+}
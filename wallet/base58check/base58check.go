@@ -1,92 +1,118 @@
 package base58check
 
 import (
-	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
-	"log"
-	"math/big"
-	"strings"
+	"errors"
+	"fmt"
 
 	"github.com/smallnest/bitcoin/wallet/base58check/base58"
 )
 
-func Encode(prefix string, byteData []byte) string {
+// ChecksumError is returned by Decode when the last four bytes of the
+// decoded value are not the double SHA-256 checksum of the rest.
+type ChecksumError struct {
+	Expected [4]byte // checksum computed from the version and payload
+	Actual   [4]byte // checksum carried by the encoded string
+}
+
+func (e ChecksumError) Error() string {
+	return fmt.Sprintf("base58check: checksum mismatch, expected %x got %x", e.Expected, e.Actual)
+}
+
+// LengthError is returned by Decode when the decoded value is too short to
+// hold the version prefix and a checksum. The value is the decoded length.
+type LengthError int
+
+func (e LengthError) Error() string {
+	return fmt.Sprintf("base58check: decoded length %d is too short", int(e))
+}
+
+// Bad characters are reported as base58.CorruptInputError carrying the
+// offset of the first byte outside the base58 alphabet.
+
+// Encode prepends the hex encoded version prefix to byteData, appends the
+// 4-byte checksum and returns the base58 representation.
+// The prefix may be any number of bytes, e.g. "00" for a P2PKH address or
+// "0488B21E" for a BIP32 extended public key.
+func Encode(prefix string, byteData []byte) (string, error) {
 	prefixBytes, err := hex.DecodeString(prefix)
 	if err != nil {
-		log.Fatal(err)
+		return "", fmt.Errorf("base58check: invalid prefix %q: %v", prefix, err)
+	}
+	if len(prefixBytes) == 0 {
+		return "", errors.New("base58check: empty prefix")
 	}
 
-	length := len(byteData) + 1
-	encoded := make([]byte, length)
-	encoded[0] = prefixBytes[0]
-	copy(encoded[1:], byteData)
-
-	//Perform SHA-256 twice
-	shaHash := sha256.New()
-	shaHash.Write(encoded)
-	var hash = shaHash.Sum(nil)
+	return EncodeVersion(prefixBytes, byteData), nil
+}
 
-	shaHash2 := sha256.New()
-	shaHash2.Write(hash)
-	hash2 := shaHash2.Sum(nil)
+// EncodeVersion is like Encode but takes the raw version prefix bytes.
+func EncodeVersion(version []byte, byteData []byte) string {
+	encoded := make([]byte, len(version)+len(byteData))
+	copy(encoded, version)
+	copy(encoded[len(version):], byteData)
 
 	//First 4 bytes if this double-sha'd byte array is the checksum
-	checksum := hash2[0:4]
+	sum := checksum(encoded)
 
 	//Append this checksum to the input bytes
-	encodedChecksum := append(encoded, checksum...)
-
-	//base58 alone is not enough. We need to first count each of the zero bytes
-	//which are at the beginning of the encodedCheckSum
-	zeroBytes := 0
-	for i := 0; i < len(encodedChecksum); i++ {
-		if encodedChecksum[i] == 0 {
-			zeroBytes++
-		} else {
-			break
-		}
-	}
-
-	//Convert this checksum'd version to a big Int
-	bigIntEncodedChecksum := big.NewInt(0)
-	bigIntEncodedChecksum.SetBytes(encodedChecksum)
+	encodedChecksum := append(encoded, sum[:]...)
 
-	//Encode the big int checksum'd version into a Base58Checked string
-	base58EncodedChecksum := string(base58.EncodeBig(nil, bigIntEncodedChecksum))
+	//base58 removes 0's (0x00), so bitcoin demands we add leading 0s back
+	//on as 1s. base58.Encode takes care of that.
+	return string(base58.Encode(nil, encodedChecksum))
+}
 
-	//Now for each zero byte we counted above we need to prepend a 1 to our
-	//base58 encoded string. The rational behind this is that base58 removes 0's (0x00).
-	//So bitcoin demands we add leading 0s back on as 1s.
-	var buffer bytes.Buffer
-	for i := 0; i < zeroBytes; i++ {
-		buffer.WriteString("1")
+// Decode decodes a base58check string with a single version byte and
+// verifies its checksum. It returns the version byte and the payload that
+// follows it.
+func Decode(value string) (version byte, payload []byte, err error) {
+	v, payload, err := DecodeVersion(value, 1)
+	if err != nil {
+		return 0, nil, err
 	}
-
-	leadingZeros := strings.Repeat("1", zeroBytes)
-	return leadingZeros + base58EncodedChecksum
+	return v[0], payload, nil
 }
 
-func Decode(value string) []byte {
-	zeroBytes := 0
-	for i := 0; i < len(value); i++ {
-		if value[i] == 49 {
-			zeroBytes++
-		} else {
-			break
-		}
+// DecodeVersion decodes a base58check string whose version prefix is
+// versionLen bytes long and verifies its checksum.
+// It returns the version prefix and the payload that follows it.
+func DecodeVersion(value string, versionLen int) (version []byte, payload []byte, err error) {
+	if versionLen < 1 {
+		return nil, nil, fmt.Errorf("base58check: invalid version length %d", versionLen)
 	}
+	return decode([]byte(value), versionLen)
+}
 
-	publicKeyInt, err := base58.DecodeToBig([]byte(value))
+func decode(value []byte, versionLen int) (version []byte, payload []byte, err error) {
+	//Leading 1s are decoded back into leading 0 bytes.
+	decoded, err := base58.Decode(value)
 	if err != nil {
-		log.Fatal(err)
+		return nil, nil, err
 	}
 
-	encodedChecksum := publicKeyInt.Bytes()
-	encoded := encodedChecksum[0 : len(encodedChecksum)-4]
+	//At least the version prefix and four checksum bytes
+	if len(decoded) < versionLen+4 {
+		return nil, nil, LengthError(len(decoded))
+	}
+
+	encoded := decoded[:len(decoded)-4]
+	var actual [4]byte
+	copy(actual[:], decoded[len(decoded)-4:])
+	if expected := checksum(encoded); expected != actual {
+		return nil, nil, ChecksumError{Expected: expected, Actual: actual}
+	}
+
+	return encoded[:versionLen], encoded[versionLen:], nil
+}
 
-	var buffer = bytes.NewBuffer(bytes.Repeat([]byte{0}, zeroBytes))
-	buffer.Write(encoded)
+// checksum returns the first four bytes of sha256(sha256(data)).
+func checksum(data []byte) [4]byte {
+	hash := sha256.Sum256(data)
+	hash2 := sha256.Sum256(hash[:])
 
-	return buffer.Bytes()[1:len(buffer.Bytes())]
+	var sum [4]byte
+	copy(sum[:], hash2[:4])
+	return sum
 }
@@ -0,0 +1,136 @@
+package base58check
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/smallnest/bitcoin/wallet/base58check/base58"
+)
+
+var versionVectors = []struct {
+	name         string
+	encoded      string
+	version      string
+	payload      string
+	payloadBytes int
+}{
+	{
+		"P2PKH address",
+		"1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa",
+		"00", "62e907b15cbf27d5425399ebf6f0fb50ebb88f18", 20,
+	},
+	{
+		"testnet P2PKH address",
+		"mipcBbFg9gMiCh81Kj8tqqdgoZub1ZJRfn",
+		"6f", "243f1394f44554f4ce3fd68649c19adc483ce924", 20,
+	},
+	{
+		"WIF",
+		"5HueCGU8rMjxEXxiPuD5BDku4MkFqeZyd4dZ1jvhTVqvbTLvyTJ",
+		"80", "0c28fca386c7a227600b2fe50b7cae11ec86d3bf1fbe471be89827e19d72aa1d", 32,
+	},
+	{
+		"compressed WIF",
+		"KwdMAjGmerYanjeui5SHS7JkmpZvVipYvB2LJGU1ZxJwYvP98617",
+		"80", "0c28fca386c7a227600b2fe50b7cae11ec86d3bf1fbe471be89827e19d72aa1d01", 33,
+	},
+	{
+		"testnet WIF",
+		"cMahea7zqjxrtgAbB7LSGbcQUr1uX1ojuat9jZodMN87JcbXMTcA",
+		"ef", "000000000000000000000000000000000000000000000000000000000000000101", 33,
+	},
+	{
+		"BIP38",
+		"6PRVWUbkzzsbcVac2qwfssoUJAN1Xhrg6bNk8J7Nzm5H7kxEbn2Nh2ZoGg",
+		"0142", "c0e957a24ad357fafb81c71f8375a9a4d0ac02bad5f6c87c4b459fabe34c0c314b33708ec3", 37,
+	},
+	{
+		"xpub",
+		"xpub661MyMwAqRbcFtXgS5sYJABqqG9YLmC4Q1Rdap9gSE8NqtwybGhePY2gZ29ESFjqJoCu1Rupje8YtGqsefD265TMg7usUDFdp6W1EGMcet8",
+		"0488b21e", "", 74,
+	},
+	{
+		"xprv",
+		"xprv9s21ZrQH143K3QTDL4LXw2F7HEK3wJUD2nW2nRk4stbPy6cq3jPPqjiChkVvvNKmPGJxWUtg6LnF5kejMRNNU3TGtRBeJgk33yuGBxrMPHi",
+		"0488ade4", "", 74,
+	},
+}
+
+func TestDecodeVersion(t *testing.T) {
+	for _, v := range versionVectors {
+		version, payload, err := DecodeVersion(v.encoded, len(v.version)/2)
+		if err != nil {
+			t.Errorf("%s: %v", v.name, err)
+			continue
+		}
+		if got := hex.EncodeToString(version); got != v.version {
+			t.Errorf("%s: version %s, want %s", v.name, got, v.version)
+		}
+		if len(payload) != v.payloadBytes {
+			t.Errorf("%s: payload of %d bytes, want %d", v.name, len(payload), v.payloadBytes)
+		}
+		if v.payload != "" && hex.EncodeToString(payload) != v.payload {
+			t.Errorf("%s: payload %x, want %s", v.name, payload, v.payload)
+		}
+
+		if got := EncodeVersion(version, payload); got != v.encoded {
+			t.Errorf("%s: EncodeVersion gives %s", v.name, got)
+		}
+		if got, err := Encode(v.version, payload); err != nil || got != v.encoded {
+			t.Errorf("%s: Encode gives %s, %v", v.name, got, err)
+		}
+	}
+}
+
+func TestDecode(t *testing.T) {
+	version, payload, err := Decode("1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != 0x00 || hex.EncodeToString(payload) != "62e907b15cbf27d5425399ebf6f0fb50ebb88f18" {
+		t.Errorf("Decode gives %02x %x", version, payload)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	// The last character of the WIF changed from J to K.
+	_, _, err := Decode("5HueCGU8rMjxEXxiPuD5BDku4MkFqeZyd4dZ1jvhTVqvbTLvyTK")
+	var checksumErr ChecksumError
+	if !errors.As(err, &checksumErr) {
+		t.Errorf("bad checksum: got %v, want a ChecksumError", err)
+	} else if checksumErr.Expected == checksumErr.Actual {
+		t.Errorf("bad checksum: %v", checksumErr)
+	}
+
+	for _, s := range []string{"", "1", "1111", "2NEpo7"} {
+		_, _, err := Decode(s)
+		var lengthErr LengthError
+		if !errors.As(err, &lengthErr) {
+			t.Errorf("Decode(%q) = %v, want a LengthError", s, err)
+		}
+	}
+
+	// A 4-byte version needs at least 8 bytes.
+	_, _, err = DecodeVersion("1A1zP1eP", 4)
+	var lengthErr LengthError
+	if !errors.As(err, &lengthErr) {
+		t.Errorf("short extended key: got %v, want a LengthError", err)
+	}
+
+	_, _, err = Decode("1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfN0")
+	var corrupt base58.CorruptInputError
+	if !errors.As(err, &corrupt) || corrupt != 33 {
+		t.Errorf("bad character: got %v, want CorruptInputError(33)", err)
+	}
+
+	if _, _, err := DecodeVersion("1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", 0); err == nil {
+		t.Error("DecodeVersion accepted a version length of 0")
+	}
+	if _, err := Encode("0g", nil); err == nil {
+		t.Error("Encode accepted a prefix that is not hex")
+	}
+	if _, err := Encode("", nil); err == nil {
+		t.Error("Encode accepted an empty prefix")
+	}
+}
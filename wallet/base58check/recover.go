@@ -0,0 +1,174 @@
+package base58check
+
+import (
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/smallnest/bitcoin/wallet/base58check/base58"
+)
+
+const alphabet = base58.Alphabet
+
+// lookAlikes maps characters that are not part of the base58 alphabet to
+// the character they are usually misread for.
+var lookAlikes = map[byte]byte{
+	'0': 'o',
+	'O': 'o',
+	'I': '1',
+	'l': '1',
+}
+
+// Candidate is a string recovered from a mistyped base58check value.
+type Candidate struct {
+	Value string
+	// Edits is the number of substitutions and transpositions applied on
+	// top of look-alike replacements.
+	Edits int
+}
+
+// RecoverOptions tunes Recover.
+type RecoverOptions struct {
+	// MaxEdits is the number of substitutions and adjacent transpositions
+	// to try, 1 or 2. Defaults to 1.
+	MaxEdits int
+	// Workers is the number of goroutines searching in parallel.
+	// Defaults to runtime.NumCPU().
+	Workers int
+	// Filter, if set, rejects candidates whose checksum validates but whose
+	// content is not plausible, e.g. a wrong version byte or length.
+	Filter func(version byte, payload []byte) bool
+}
+
+// Recover searches the strings close to value for ones whose checksum
+// validates. Characters outside the base58 alphabet are first replaced with
+// their look-alikes (0 and O with o, I and l with 1), then up to MaxEdits
+// single character substitutions and adjacent transpositions are tried.
+//
+// If value is valid after the look-alike replacements it is returned as
+// the only candidate.
+// Candidates are sorted by number of edits.
+func Recover(value string, opts RecoverOptions) []Candidate {
+	if opts.MaxEdits < 1 {
+		opts.MaxEdits = 1
+	}
+	if opts.Workers < 1 {
+		opts.Workers = runtime.NumCPU()
+	}
+
+	r := &recoverer{filter: opts.Filter, found: make(map[string]int)}
+	base := replaceLookAlikes(value)
+	if r.check(base) {
+		r.add(string(base), 0)
+		return r.candidates()
+	}
+
+	// Each job is the position of the first edit. Early positions also try
+	// second edits at every later position and carry far more work, so
+	// they are handed out first and the workers pull the shorter jobs as
+	// they finish.
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			buf := make([]byte, len(base))
+			for pos := range jobs {
+				copy(buf, base)
+				r.editAt(buf, pos, 1, opts.MaxEdits)
+			}
+		}()
+	}
+	for pos := range base {
+		jobs <- pos
+	}
+	close(jobs)
+	wg.Wait()
+
+	return r.candidates()
+}
+
+// replaceLookAlikes replaces the invalid characters of value with their
+// look-alikes. Invalid characters without look-alikes are kept and left to
+// the edit search.
+func replaceLookAlikes(value string) []byte {
+	buf := []byte(value)
+	for i, c := range buf {
+		if alike, ok := lookAlikes[c]; ok {
+			buf[i] = alike
+		}
+	}
+	return buf
+}
+
+type recoverer struct {
+	filter func(version byte, payload []byte) bool
+
+	mu    sync.Mutex
+	found map[string]int
+}
+
+func (r *recoverer) check(buf []byte) bool {
+	version, payload, err := decode(buf, 1)
+	if err != nil {
+		return false
+	}
+	return r.filter == nil || r.filter(version[0], payload)
+}
+
+func (r *recoverer) add(value string, edits int) {
+	r.mu.Lock()
+	if e, ok := r.found[value]; !ok || edits < e {
+		r.found[value] = edits
+	}
+	r.mu.Unlock()
+}
+
+// editAt applies one edit at pos, checks the result and recurses into
+// further edits at later positions.
+func (r *recoverer) editAt(buf []byte, pos int, edits, maxEdits int) {
+	orig := buf[pos]
+	for k := 0; k < len(alphabet); k++ {
+		if alphabet[k] == orig {
+			continue
+		}
+		buf[pos] = alphabet[k]
+		r.visit(buf, pos+1, edits, maxEdits)
+	}
+	buf[pos] = orig
+
+	if pos+1 < len(buf) && buf[pos] != buf[pos+1] {
+		buf[pos], buf[pos+1] = buf[pos+1], buf[pos]
+		r.visit(buf, pos+2, edits, maxEdits)
+		buf[pos], buf[pos+1] = buf[pos+1], buf[pos]
+	}
+}
+
+func (r *recoverer) visit(buf []byte, next int, edits, maxEdits int) {
+	if r.check(buf) {
+		r.add(string(buf), edits)
+	}
+	if edits < maxEdits {
+		for p := next; p < len(buf); p++ {
+			r.editAt(buf, p, edits+1, maxEdits)
+		}
+	}
+}
+
+func (r *recoverer) candidates() []Candidate {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	candidates := make([]Candidate, 0, len(r.found))
+	for v, e := range r.found {
+		candidates = append(candidates, Candidate{Value: v, Edits: e})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Edits != candidates[j].Edits {
+			return candidates[i].Edits < candidates[j].Edits
+		}
+		return candidates[i].Value < candidates[j].Value
+	})
+	return candidates
+}
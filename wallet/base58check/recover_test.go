@@ -0,0 +1,87 @@
+package base58check
+
+import "testing"
+
+// plausible accepts P2PKH/P2SH addresses and WIF private keys, like the
+// filter of key recover.
+func plausible(version byte, payload []byte) bool {
+	switch version {
+	case 0x00, 0x05, 0x6f, 0xc4:
+		return len(payload) == 20
+	case 0x80, 0xef:
+		return len(payload) == 32 || (len(payload) == 33 && payload[32] == 0x01)
+	}
+	return false
+}
+
+func TestRecover(t *testing.T) {
+	const (
+		addr    = "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa"
+		testnet = "mipcBbFg9gMiCh81Kj8tqqdgoZub1ZJRfn"
+		wif     = "5HueCGU8rMjxEXxiPuD5BDku4MkFqeZyd4dZ1jvhTVqvbTLvyTJ"
+		cwif    = "KwdMAjGmerYanjeui5SHS7JkmpZvVipYvB2LJGU1ZxJwYvP98617"
+	)
+	for _, v := range []struct {
+		name  string
+		typo  string
+		want  string
+		edits int
+	}{
+		{"valid address", addr, addr, 0},
+		{"address substitution", "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNb", addr, 1},
+		{"address transposition", "1A1zP1eP5QGefi2DMPTfTL5SLmv7DvifNa", addr, 1},
+		{"address look-alike l", "1AlzP1eP5QGefi2DMPTfTL5SLmv7DivfNa", addr, 0},
+		{"address look-alike I", "IA1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", addr, 0},
+		{"address look-alike 0", "mipcBbFg9gMiCh81Kj8tqqdg0Zub1ZJRfn", testnet, 0},
+		{"address look-alike O", "mipcBbFg9gMiCh81Kj8tqqdgOZub1ZJRfn", testnet, 0},
+		{"look-alike and substitution", "mipcBbFg9gMiCh81Kj8tqqdg0Zub1ZJRfm", testnet, 1},
+		{"WIF substitution", "5HueCGU8rMjxEXxiPuD5BDku4MkFqeZyd4dZ1jvhTVqvbTLvyTK", wif, 1},
+		{"WIF transposition", "5HueCGU8rMjxEXxiPuD5BDku4MkFqeZyd4dZ1jvhTVqvbTLvTyJ", wif, 1},
+		{"WIF look-alike", "5HueCGU8rMjxEXxiPuD5BDku4MkFqeZyd4dZljvhTVqvbTLvyTJ", wif, 0},
+		{"compressed WIF substitution", "KwdMAjGmerYanjeui5SHS7JkmpZvVipYvB2LJGU1ZxJwYvP9861Z", cwif, 1},
+		{"compressed WIF transposition", "KwdMAjGmerYanjeui5SHS7JkmpZvVipYvB2LJGU1ZxJwYvP98671", cwif, 1},
+	} {
+		candidates := Recover(v.typo, RecoverOptions{Filter: plausible, Workers: 4})
+		found := false
+		for _, c := range candidates {
+			if c.Value == v.want {
+				found = true
+				if c.Edits != v.edits {
+					t.Errorf("%s: %d edits, want %d", v.name, c.Edits, v.edits)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("%s: %s not among %v", v.name, v.want, candidates)
+		}
+		if v.edits == 0 && len(candidates) != 1 {
+			t.Errorf("%s: %d candidates, want only the valid value", v.name, len(candidates))
+		}
+	}
+}
+
+func TestRecoverNothing(t *testing.T) {
+	// Two substitutions are out of reach of a single edit.
+	if c := Recover("1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfMb", RecoverOptions{Filter: plausible}); len(c) != 0 {
+		t.Errorf("got %v", c)
+	}
+	// The filter rejects everything.
+	reject := func(byte, []byte) bool { return false }
+	if c := Recover("1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", RecoverOptions{Filter: reject}); len(c) != 0 {
+		t.Errorf("got %v", c)
+	}
+}
+
+func TestRecoverTwoEdits(t *testing.T) {
+	if testing.Short() {
+		t.Skip("searches about two million strings")
+	}
+	const addr = "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa"
+	candidates := Recover("1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfMb", RecoverOptions{MaxEdits: 2, Filter: plausible})
+	for _, c := range candidates {
+		if c.Value == addr && c.Edits == 2 {
+			return
+		}
+	}
+	t.Errorf("%s not among %v", addr, candidates)
+}
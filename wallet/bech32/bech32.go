@@ -0,0 +1,244 @@
+// Package bech32 implements the Bech32 (BIP173) and Bech32m (BIP350)
+// encodings used by native SegWit and Taproot addresses.
+package bech32
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+var charsetRev [128]int8
+
+func init() {
+	for i := range charsetRev {
+		charsetRev[i] = -1
+	}
+	for i := 0; i < len(charset); i++ {
+		charsetRev[charset[i]] = int8(i)
+	}
+}
+
+// Encoding selects the checksum constant, Bech32 for witness version 0 and
+// Bech32m for witness version 1 and above.
+type Encoding int
+
+const (
+	Bech32 Encoding = iota + 1
+	Bech32m
+)
+
+const (
+	bech32Const  = 1
+	bech32mConst = 0x2bc830a3
+
+	// maxLength is the length limit of a bech32 string defined by BIP173.
+	maxLength = 90
+)
+
+func (e Encoding) String() string {
+	switch e {
+	case Bech32:
+		return "bech32"
+	case Bech32m:
+		return "bech32m"
+	}
+	return "unknown"
+}
+
+// InvalidCharacterError is returned when the string contains a character
+// that is not allowed at that position. The value is the offset in the input.
+type InvalidCharacterError int
+
+func (e InvalidCharacterError) Error() string {
+	return fmt.Sprintf("bech32: invalid character at position %d", int(e))
+}
+
+// MixedCaseError is returned when the string mixes upper and lower case
+// characters. The value is the offset of the first character whose case
+// differs from the ones before it.
+type MixedCaseError int
+
+func (e MixedCaseError) Error() string {
+	return fmt.Sprintf("bech32: mixed case at position %d", int(e))
+}
+
+// LengthError is returned when the string is shorter than a separator plus
+// checksum or longer than 90 characters. The value is the input length.
+type LengthError int
+
+func (e LengthError) Error() string {
+	return fmt.Sprintf("bech32: invalid length %d", int(e))
+}
+
+var (
+	// ErrSeparator is returned when the '1' separating the human readable
+	// part from the data part is missing or misplaced.
+	ErrSeparator = errors.New("bech32: missing or misplaced separator")
+	// ErrChecksum is returned when the checksum matches neither Bech32
+	// nor Bech32m.
+	ErrChecksum = errors.New("bech32: invalid checksum")
+)
+
+func polymod(values []byte) uint32 {
+	gen := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := uint32(1)
+	for _, v := range values {
+		b := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (b>>uint(i))&1 == 1 {
+				chk ^= gen[i]
+			}
+		}
+	}
+	return chk
+}
+
+func hrpExpand(hrp string) []byte {
+	ret := make([]byte, 0, len(hrp)*2+1)
+	for i := 0; i < len(hrp); i++ {
+		ret = append(ret, hrp[i]>>5)
+	}
+	ret = append(ret, 0)
+	for i := 0; i < len(hrp); i++ {
+		ret = append(ret, hrp[i]&31)
+	}
+	return ret
+}
+
+func checksumConst(enc Encoding) uint32 {
+	if enc == Bech32m {
+		return bech32mConst
+	}
+	return bech32Const
+}
+
+func createChecksum(hrp string, data []byte, enc Encoding) []byte {
+	values := append(hrpExpand(hrp), data...)
+	values = append(values, 0, 0, 0, 0, 0, 0)
+	mod := polymod(values) ^ checksumConst(enc)
+	ret := make([]byte, 6)
+	for i := 0; i < 6; i++ {
+		ret[i] = byte(mod>>uint(5*(5-i))) & 31
+	}
+	return ret
+}
+
+// Encode encodes the 5-bit data values with the human readable part hrp.
+// The result is always lower case.
+func Encode(hrp string, data []byte, enc Encoding) (string, error) {
+	if enc != Bech32 && enc != Bech32m {
+		return "", fmt.Errorf("bech32: unknown encoding %d", int(enc))
+	}
+	if len(hrp) < 1 {
+		return "", ErrSeparator
+	}
+	if len(hrp)+len(data)+7 > maxLength {
+		return "", LengthError(len(hrp) + len(data) + 7)
+	}
+	for i := 0; i < len(hrp); i++ {
+		if hrp[i] < 33 || hrp[i] > 126 {
+			return "", InvalidCharacterError(i)
+		}
+	}
+	hrp = strings.ToLower(hrp)
+
+	var sb strings.Builder
+	sb.Grow(len(hrp) + 1 + len(data) + 6)
+	sb.WriteString(hrp)
+	sb.WriteByte('1')
+	for i, v := range data {
+		if v >= 32 {
+			return "", InvalidCharacterError(len(hrp) + 1 + i)
+		}
+		sb.WriteByte(charset[v])
+	}
+	for _, v := range createChecksum(hrp, data, enc) {
+		sb.WriteByte(charset[v])
+	}
+	return sb.String(), nil
+}
+
+// Decode decodes a Bech32 or Bech32m string and reports which checksum it
+// carries. The returned hrp is lower case and data holds 5-bit values
+// without the checksum.
+func Decode(s string) (hrp string, data []byte, enc Encoding, err error) {
+	if len(s) < 8 || len(s) > maxLength {
+		return "", nil, 0, LengthError(len(s))
+	}
+
+	hasLower, hasUpper := false, false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c < 33 || c > 126 {
+			return "", nil, 0, InvalidCharacterError(i)
+		}
+		if c >= 'a' && c <= 'z' {
+			hasLower = true
+		} else if c >= 'A' && c <= 'Z' {
+			hasUpper = true
+		}
+		if hasLower && hasUpper {
+			return "", nil, 0, MixedCaseError(i)
+		}
+	}
+	s = strings.ToLower(s)
+
+	pos := strings.LastIndexByte(s, '1')
+	if pos < 1 || pos+7 > len(s) {
+		return "", nil, 0, ErrSeparator
+	}
+
+	hrp = s[:pos]
+	data = make([]byte, 0, len(s)-pos-1)
+	for i := pos + 1; i < len(s); i++ {
+		d := charsetRev[s[i]]
+		if d == -1 {
+			return "", nil, 0, InvalidCharacterError(i)
+		}
+		data = append(data, byte(d))
+	}
+
+	switch polymod(append(hrpExpand(hrp), data...)) {
+	case bech32Const:
+		enc = Bech32
+	case bech32mConst:
+		enc = Bech32m
+	default:
+		return "", nil, 0, ErrChecksum
+	}
+
+	return hrp, data[:len(data)-6], enc, nil
+}
+
+// ConvertBits regroups data from fromBits-bit values into toBits-bit values.
+// With pad set, the trailing bits are zero padded into a final group;
+// without it, leftover bits must be zero and fewer than fromBits.
+func ConvertBits(data []byte, fromBits, toBits uint, pad bool) ([]byte, error) {
+	acc := uint32(0)
+	bits := uint(0)
+	maxv := uint32(1)<<toBits - 1
+	ret := make([]byte, 0, len(data)*int(fromBits)/int(toBits)+1)
+	for i, value := range data {
+		if uint32(value)>>fromBits != 0 {
+			return nil, fmt.Errorf("bech32: value %d at index %d exceeds %d bits", value, i, fromBits)
+		}
+		acc = acc<<fromBits | uint32(value)
+		bits += fromBits
+		for bits >= toBits {
+			bits -= toBits
+			ret = append(ret, byte(acc>>bits&maxv))
+		}
+	}
+	if pad {
+		if bits > 0 {
+			ret = append(ret, byte(acc<<(toBits-bits)&maxv))
+		}
+	} else if bits >= fromBits || acc<<(toBits-bits)&maxv != 0 {
+		return nil, errors.New("bech32: invalid padding")
+	}
+	return ret, nil
+}
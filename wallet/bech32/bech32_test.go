@@ -0,0 +1,231 @@
+package bech32
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// validStrings are the valid test vectors of BIP173 and BIP350.
+var validStrings = []struct {
+	s   string
+	enc Encoding
+}{
+	{"A12UEL5L", Bech32},
+	{"a12uel5l", Bech32},
+	{"an83characterlonghumanreadablepartthatcontainsthenumber1andtheexcludedcharactersbio1tt5tgs", Bech32},
+	{"abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxw", Bech32},
+	{"11qqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqc8247j", Bech32},
+	{"split1checkupstagehandshakeupstreamerranterredcaperred2y9e3w", Bech32},
+	{"?1ezyfcl", Bech32},
+	{"A1LQFN3A", Bech32m},
+	{"a1lqfn3a", Bech32m},
+	{"an83characterlonghumanreadablepartthatcontainsthetheexcludedcharactersbioandnumber11sg7hg6", Bech32m},
+	{"abcdef1l7aum6echk45nj3s0wdvt2fg8x9yrzpqzd3ryx", Bech32m},
+	{"11llllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllludsr8", Bech32m},
+	{"split1checkupstagehandshakeupstreamerranterredcaperredlc445v", Bech32m},
+	{"?1v759aa", Bech32m},
+}
+
+func TestDecodeValid(t *testing.T) {
+	for _, v := range validStrings {
+		hrp, data, enc, err := Decode(v.s)
+		if err != nil {
+			t.Errorf("%s: %v", v.s, err)
+			continue
+		}
+		if enc != v.enc {
+			t.Errorf("%s: %s, want %s", v.s, enc, v.enc)
+		}
+		got, err := Encode(hrp, data, enc)
+		if err != nil || got != strings.ToLower(v.s) {
+			t.Errorf("%s: Encode gives %s, %v", v.s, got, err)
+		}
+
+		// Flipping a character of the data part breaks the checksum.
+		pos := strings.LastIndexByte(v.s, '1') + 1
+		c := byte('q')
+		if strings.ToLower(v.s)[pos] == 'q' {
+			c = 'p'
+		}
+		if _, _, _, err := Decode(strings.ToLower(v.s[:pos]) + string(c) + strings.ToLower(v.s[pos+1:])); err != ErrChecksum {
+			t.Errorf("%s with a changed character: %v, want ErrChecksum", v.s, err)
+		}
+	}
+}
+
+// invalidStrings are the invalid test vectors of BIP173 and BIP350, with
+// the error they must give.
+var invalidStrings = []struct {
+	s   string
+	err error
+}{
+	{"\x201nwldj5", InvalidCharacterError(0)},
+	{"\x7f1axkwrx", InvalidCharacterError(0)},
+	{"\x801eym55h", InvalidCharacterError(0)},
+	{"an84characterslonghumanreadablepartthatcontainsthenumber1andtheexcludedcharactersbio1569pvx", LengthError(91)},
+	{"pzry9x0s0muk", ErrSeparator},
+	{"1pzry9x0s0muk", ErrSeparator},
+	{"x1b4n0q5v", InvalidCharacterError(2)},
+	{"li1dgmt3", ErrSeparator},
+	{"de1lg7wt\xff", InvalidCharacterError(8)},
+	{"A1G7SGD8", ErrChecksum},
+	{"10a06t8", LengthError(7)},
+	{"1qzzfhee", ErrSeparator},
+	{"\x201xj0phk", InvalidCharacterError(0)},
+	{"\x7f1g6xzxy", InvalidCharacterError(0)},
+	{"\x801vctc34", InvalidCharacterError(0)},
+	{"an84characterslonghumanreadablepartthatcontainsthetheexcludedcharactersbioandnumber11d6pts4", LengthError(91)},
+	{"qyrz8wqd2c9m", ErrSeparator},
+	{"1qyrz8wqd2c9m", ErrSeparator},
+	{"y1b0jsk6g", InvalidCharacterError(2)},
+	{"lt1igcx5c0", InvalidCharacterError(3)},
+	{"in1muywd", ErrSeparator},
+	{"mm1crxm3i", InvalidCharacterError(8)},
+	{"au1s5cgom", InvalidCharacterError(7)},
+	{"M1VUXWEZ", ErrChecksum},
+	{"16plkw9", LengthError(7)},
+	{"1p2gdwpf", ErrSeparator},
+	{"a12UEL5L", MixedCaseError(3)},
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	for _, v := range invalidStrings {
+		if _, _, _, err := Decode(v.s); err != v.err {
+			t.Errorf("%q: %v, want %v", v.s, err, v.err)
+		}
+	}
+}
+
+func TestEncodeInvalid(t *testing.T) {
+	if _, err := Encode("a", []byte{32}, Bech32); err == nil {
+		t.Error("Encode accepted a value of 6 bits")
+	}
+	if _, err := Encode("", nil, Bech32); err == nil {
+		t.Error("Encode accepted an empty hrp")
+	}
+	if _, err := Encode(strings.Repeat("a", 84), nil, Bech32m); err == nil {
+		t.Error("Encode accepted 91 characters")
+	}
+	if _, err := Encode("a", nil, 0); err == nil {
+		t.Error("Encode accepted an unknown encoding")
+	}
+}
+
+// validAddresses are the valid segwit addresses of BIP350 with their
+// output scripts.
+var validAddresses = []struct {
+	addr   string
+	script string
+}{
+	{"BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4", "0014751e76e8199196d454941c45d1b3a323f1433bd6"},
+	{"tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sl5k7", "00201863143c14c5166804bd19203356da136c985678cd4d27a1b8c6329604903262"},
+	{"bc1pw508d6qejxtdg4y5r3zarvary0c5xw7kw508d6qejxtdg4y5r3zarvary0c5xw7kt5nd6y", "5128751e76e8199196d454941c45d1b3a323f1433bd6751e76e8199196d454941c45d1b3a323f1433bd6"},
+	{"BC1SW50QGDZ25J", "6002751e"},
+	{"bc1zw508d6qejxtdg4y5r3zarvaryvaxxpcs", "5210751e76e8199196d454941c45d1b3a323"},
+	{"tb1qqqqqp399et2xygdj5xreqhjjvcmzhxw4aywxecjdzew6hylgvsesrxh6hy", "0020000000c4a5cad46221b2a187905e5266362b99d5e91c6ce24d165dab93e86433"},
+	{"tb1pqqqqp399et2xygdj5xreqhjjvcmzhxw4aywxecjdzew6hylgvsesf3hn0c", "5120000000c4a5cad46221b2a187905e5266362b99d5e91c6ce24d165dab93e86433"},
+	{"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0", "512079be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"},
+}
+
+func TestSegwitAddresses(t *testing.T) {
+	for _, v := range validAddresses {
+		hrp, version, program, err := DecodeSegwit(v.addr)
+		if err != nil {
+			t.Errorf("%s: %v", v.addr, err)
+			continue
+		}
+		// The output script is OP_n followed by a push of the program.
+		op := byte(0)
+		if version > 0 {
+			op = 0x50 + version
+		}
+		script := append([]byte{op, byte(len(program))}, program...)
+		if got := hex.EncodeToString(script); got != v.script {
+			t.Errorf("%s: script %s, want %s", v.addr, got, v.script)
+		}
+
+		got, err := EncodeSegwitAddress(hrp, version, program)
+		if err != nil || got != strings.ToLower(v.addr) {
+			t.Errorf("%s: EncodeSegwitAddress gives %s, %v", v.addr, got, err)
+		}
+		if _, _, err := DecodeSegwitAddress(hrp, v.addr); err != nil {
+			t.Errorf("%s: DecodeSegwitAddress: %v", v.addr, err)
+		}
+		other := MainnetHRP
+		if hrp == MainnetHRP {
+			other = TestnetHRP
+		}
+		if _, _, err := DecodeSegwitAddress(other, v.addr); err == nil {
+			t.Errorf("%s: DecodeSegwitAddress accepted it for %s", v.addr, other)
+		}
+	}
+}
+
+// invalidAddresses are the invalid segwit addresses of BIP350. None may
+// decode for mainnet or testnet.
+var invalidAddresses = []struct {
+	addr   string
+	reason string
+}{
+	{"tc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vq5zuyut", "invalid human-readable part"},
+	{"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqh2y7hd", "bech32 instead of bech32m"},
+	{"tb1z0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqglt7rf", "bech32 instead of bech32m"},
+	{"BC1S0XLXVLHEMJA6C4DQV22UAPCTQUPFHLXM9H8Z3K2E72Q4K9HCZ7VQ54WELL", "bech32 instead of bech32m"},
+	{"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kemeawh", "bech32m instead of bech32"},
+	{"tb1q0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vq24jc47", "bech32m instead of bech32"},
+	{"bc1p38j9r5y49hruaue7wxjce0updqjuyyx0kh56v8s25huc6995vvpql3jow4", "invalid character in checksum"},
+	{"BC130XLXVLHEMJA6C4DQV22UAPCTQUPFHLXM9H8Z3K2E72Q4K9HCZ7VQ7ZWS8R", "invalid witness version"},
+	{"bc1pw5dgrnzv", "invalid program length"},
+	{"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7v8n0nx0muaewav253zgeav", "invalid program length"},
+	{"BC1QR508D6QEJXTDG4Y5R3ZARVARYV98GJ9P", "invalid program length for witness version 0"},
+	{"tb1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vq47Zagq", "mixed case"},
+	{"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7v07qwwzcrf", "zero padding of more than 4 bits"},
+	{"tb1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vpggkg4j", "non-zero padding"},
+	{"bc1gmk9yu", "empty data section"},
+}
+
+func TestSegwitAddressesInvalid(t *testing.T) {
+	for _, v := range invalidAddresses {
+		for _, hrp := range []string{MainnetHRP, TestnetHRP} {
+			if _, _, err := DecodeSegwitAddress(hrp, v.addr); err == nil {
+				t.Errorf("%s (%s) decoded for %s", v.addr, v.reason, hrp)
+			}
+		}
+	}
+
+	// The checksum confusion cases are valid bech32 strings, only the
+	// witness version tells which checksum they need.
+	for _, s := range []string{
+		"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqh2y7hd",
+		"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kemeawh",
+	} {
+		if _, _, _, err := Decode(s); err != nil {
+			t.Errorf("%s: %v", s, err)
+		}
+		var segwitErr SegwitError
+		if _, _, err := DecodeSegwitAddress(MainnetHRP, s); !errors.As(err, &segwitErr) {
+			t.Errorf("%s: %v, want a SegwitError", s, err)
+		}
+	}
+}
+
+func TestConvertBits(t *testing.T) {
+	program := []byte{0x75, 0x1e, 0x76, 0xe8, 0x19}
+	five, err := ConvertBits(program, 8, 5, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	eight, err := ConvertBits(five, 5, 8, false)
+	if err != nil || hex.EncodeToString(eight) != hex.EncodeToString(program) {
+		t.Errorf("round trip gives %x, %v", eight, err)
+	}
+	if _, err := ConvertBits([]byte{32}, 5, 8, true); err == nil {
+		t.Error("ConvertBits accepted a 6-bit value")
+	}
+	// Five 5-bit values leave one bit that must be zero.
+	if _, err := ConvertBits([]byte{0, 0, 0, 0, 1}, 5, 8, false); err == nil {
+		t.Error("ConvertBits accepted non-zero padding")
+	}
+}
@@ -0,0 +1,104 @@
+package bech32
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Human readable parts of segwit addresses for each bitcoin network.
+// Signet shares its prefix with testnet.
+const (
+	MainnetHRP = "bc"
+	TestnetHRP = "tb"
+	SignetHRP  = "tb"
+	RegtestHRP = "bcrt"
+)
+
+// SegwitError is returned when a string is valid bech32 but not a valid
+// segwit address.
+type SegwitError string
+
+func (e SegwitError) Error() string {
+	return "bech32: " + string(e)
+}
+
+// EncodeSegwitAddress encodes a witness program as a segwit address.
+// Version 0 uses Bech32, versions 1 to 16 use Bech32m as required by BIP350.
+func EncodeSegwitAddress(hrp string, version byte, program []byte) (string, error) {
+	if err := checkProgram(version, program); err != nil {
+		return "", err
+	}
+
+	enc := Bech32
+	if version > 0 {
+		enc = Bech32m
+	}
+
+	converted, err := ConvertBits(program, 8, 5, true)
+	if err != nil {
+		return "", err
+	}
+	return Encode(hrp, append([]byte{version}, converted...), enc)
+}
+
+// DecodeSegwitAddress decodes a segwit address and checks that its human
+// readable part is hrp. It returns the witness version and program.
+func DecodeSegwitAddress(hrp string, addr string) (version byte, program []byte, err error) {
+	gotHRP, data, enc, err := Decode(addr)
+	if err != nil {
+		return 0, nil, err
+	}
+	if gotHRP != strings.ToLower(hrp) {
+		return 0, nil, SegwitError(fmt.Sprintf("human readable part %q, expected %q", gotHRP, hrp))
+	}
+	return segwitData(data, enc)
+}
+
+// DecodeSegwit decodes a segwit address for any human readable part and
+// returns it along with the witness version and program.
+func DecodeSegwit(addr string) (hrp string, version byte, program []byte, err error) {
+	hrp, data, enc, err := Decode(addr)
+	if err != nil {
+		return "", 0, nil, err
+	}
+	version, program, err = segwitData(data, enc)
+	if err != nil {
+		return "", 0, nil, err
+	}
+	return hrp, version, program, nil
+}
+
+func segwitData(data []byte, enc Encoding) (byte, []byte, error) {
+	if len(data) < 1 {
+		return 0, nil, SegwitError("empty data")
+	}
+	version := data[0]
+	if version == 0 && enc != Bech32 {
+		return 0, nil, SegwitError("witness version 0 must use bech32")
+	}
+	if version != 0 && enc != Bech32m {
+		return 0, nil, SegwitError(fmt.Sprintf("witness version %d must use bech32m", version))
+	}
+
+	program, err := ConvertBits(data[1:], 5, 8, false)
+	if err != nil {
+		return 0, nil, err
+	}
+	if err := checkProgram(version, program); err != nil {
+		return 0, nil, err
+	}
+	return version, program, nil
+}
+
+func checkProgram(version byte, program []byte) error {
+	if version > 16 {
+		return SegwitError(fmt.Sprintf("invalid witness version %d", version))
+	}
+	if len(program) < 2 || len(program) > 40 {
+		return SegwitError(fmt.Sprintf("invalid witness program length %d", len(program)))
+	}
+	if version == 0 && len(program) != 20 && len(program) != 32 {
+		return SegwitError(fmt.Sprintf("invalid witness v0 program length %d", len(program)))
+	}
+	return nil
+}
@@ -0,0 +1,247 @@
+// Package bip21 builds and parses bitcoin: payment URIs as described in
+// BIP21, e.g.
+//
+//	bitcoin:1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa?amount=0.001&label=Luke-Jr
+//
+// Besides the amount, label and message of BIP21 the lightning parameter
+// of unified QR codes is supported, with which the address may be empty.
+package bip21
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/smallnest/bitcoin/wallet/address"
+)
+
+// Scheme is the URI scheme of payment requests, matched case-insensitively.
+const Scheme = "bitcoin"
+
+const (
+	satoshisPerBitcoin = 100000000
+	// maxSatoshis is the 21 million bitcoins that will ever exist.
+	maxSatoshis = 21000000 * satoshisPerBitcoin
+)
+
+var (
+	// ErrScheme is returned for URIs that don't start with bitcoin:.
+	ErrScheme = errors.New("bip21: not a bitcoin: URI")
+	// ErrAmount is returned for amounts that are not a decimal number of
+	// bitcoins with at most 8 decimals.
+	ErrAmount = errors.New("bip21: invalid amount")
+)
+
+// RequiredParamError is returned for a req- parameter the parser does not
+// know. BIP21 requires the whole URI to be rejected then, as the payment
+// would not be made the way the requester asked for.
+type RequiredParamError struct {
+	Name string
+}
+
+func (e *RequiredParamError) Error() string {
+	return fmt.Sprintf("bip21: unsupported required parameter %q", e.Name)
+}
+
+// URI is a payment request.
+type URI struct {
+	// Address is the address to pay to. It may be empty only with
+	// Lightning.
+	Address string
+	// Amount is the requested amount in satoshis, 0 if none.
+	Amount int64
+	// Label is the name of the recipient, Message a note about the
+	// payment.
+	Label   string
+	Message string
+	// Lightning is a BOLT11 invoice for the same payment.
+	Lightning string
+	// Params holds the other parameters, including req- parameters when
+	// building a URI.
+	Params map[string]string
+}
+
+// known are the parameters with a field in URI.
+var known = map[string]bool{"amount": true, "label": true, "message": true, "lightning": true}
+
+// Parse parses and validates a bitcoin: URI. The address, if any, must be
+// a valid address of any network.
+func Parse(s string) (*URI, error) {
+	colon := strings.IndexByte(s, ':')
+	if colon < 0 || !strings.EqualFold(s[:colon], Scheme) {
+		return nil, ErrScheme
+	}
+	rest := s[colon+1:]
+	query := ""
+	if q := strings.IndexByte(rest, '?'); q >= 0 {
+		rest, query = rest[:q], rest[q+1:]
+	}
+
+	u := &URI{}
+	addr, err := url.PathUnescape(rest)
+	if err != nil {
+		return nil, fmt.Errorf("bip21: %v", err)
+	}
+	u.Address = addr
+
+	seen := make(map[string]bool)
+	for _, pair := range strings.Split(query, "&") {
+		if pair == "" {
+			continue
+		}
+		name, value := pair, ""
+		if eq := strings.IndexByte(pair, '='); eq >= 0 {
+			name, value = pair[:eq], pair[eq+1:]
+		}
+		if name, err = url.PathUnescape(name); err != nil {
+			return nil, fmt.Errorf("bip21: %v", err)
+		}
+		if value, err = url.PathUnescape(value); err != nil {
+			return nil, fmt.Errorf("bip21: %v", err)
+		}
+		name = strings.ToLower(name)
+		if seen[name] {
+			return nil, fmt.Errorf("bip21: duplicate parameter %q", name)
+		}
+		seen[name] = true
+
+		switch name {
+		case "amount":
+			if u.Amount, err = ParseAmount(value); err != nil {
+				return nil, err
+			}
+		case "label":
+			u.Label = value
+		case "message":
+			u.Message = value
+		case "lightning":
+			u.Lightning = value
+		default:
+			if strings.HasPrefix(name, "req-") {
+				return nil, &RequiredParamError{name}
+			}
+			if u.Params == nil {
+				u.Params = make(map[string]string)
+			}
+			u.Params[name] = value
+		}
+	}
+
+	if u.Address == "" {
+		if u.Lightning == "" {
+			return nil, errors.New("bip21: no address")
+		}
+	} else if _, err := address.Parse(u.Address); err != nil {
+		return nil, fmt.Errorf("bip21: invalid address %q: %v", u.Address, err)
+	}
+	return u, nil
+}
+
+// String encodes u as a URI with percent-encoded parameters. Amount,
+// label, message and lightning come first, then Params in sorted order.
+func (u *URI) String() string {
+	var b strings.Builder
+	b.WriteString(Scheme + ":")
+	b.WriteString(escape(u.Address))
+
+	var params []string
+	add := func(name, value string) {
+		params = append(params, escape(name)+"="+escape(value))
+	}
+	if u.Amount != 0 {
+		add("amount", FormatAmount(u.Amount))
+	}
+	if u.Label != "" {
+		add("label", u.Label)
+	}
+	if u.Message != "" {
+		add("message", u.Message)
+	}
+	if u.Lightning != "" {
+		add("lightning", u.Lightning)
+	}
+	names := make([]string, 0, len(u.Params))
+	for name := range u.Params {
+		if !known[strings.ToLower(name)] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		add(name, u.Params[name])
+	}
+
+	if len(params) > 0 {
+		b.WriteByte('?')
+		b.WriteString(strings.Join(params, "&"))
+	}
+	return b.String()
+}
+
+// escape percent-encodes everything but the unreserved characters of
+// RFC 3986. Unlike url.QueryEscape a space is %20, as + is a literal plus
+// in BIP21.
+func escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '.' || c == '_' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// ParseAmount parses a decimal number of bitcoins, e.g. "0.001", into
+// satoshis. Exponents, signs, more than 8 decimals and more than the 21
+// million bitcoins that exist are rejected.
+func ParseAmount(s string) (int64, error) {
+	whole, frac := s, ""
+	if dot := strings.IndexByte(s, '.'); dot >= 0 {
+		whole, frac = s[:dot], s[dot+1:]
+	}
+	if whole == "" && frac == "" || len(frac) > 8 || !digits(whole) || !digits(frac) {
+		return 0, ErrAmount
+	}
+	frac += strings.Repeat("0", 8-len(frac))
+	if whole == "" {
+		whole = "0"
+	}
+
+	bitcoins, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || bitcoins > maxSatoshis/satoshisPerBitcoin {
+		return 0, ErrAmount
+	}
+	sats, _ := strconv.ParseInt(frac, 10, 64)
+	total := bitcoins*satoshisPerBitcoin + sats
+	if total > maxSatoshis {
+		return 0, ErrAmount
+	}
+	return total, nil
+}
+
+// FormatAmount formats satoshis as a decimal number of bitcoins without
+// trailing zeros.
+func FormatAmount(satoshis int64) string {
+	sign := ""
+	if satoshis < 0 {
+		sign, satoshis = "-", -satoshis
+	}
+	s := fmt.Sprintf("%s%d.%08d", sign, satoshis/satoshisPerBitcoin, satoshis%satoshisPerBitcoin)
+	return strings.TrimSuffix(strings.TrimRight(s, "0"), ".")
+}
+
+func digits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,160 @@
+package bip21
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+const addr = "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa"
+
+func TestParse(t *testing.T) {
+	for _, v := range []struct {
+		uri  string
+		want URI
+	}{
+		{"bitcoin:" + addr, URI{Address: addr}},
+		{"bitcoin:" + addr + "?label=Luke-Jr", URI{Address: addr, Label: "Luke-Jr"}},
+		{
+			"bitcoin:" + addr + "?amount=20.3&label=Luke-Jr",
+			URI{Address: addr, Amount: 2030000000, Label: "Luke-Jr"},
+		},
+		{
+			"bitcoin:" + addr + "?amount=50&label=Luke-Jr&message=Donation%20for%20project%20xyz",
+			URI{Address: addr, Amount: 5000000000, Label: "Luke-Jr", Message: "Donation for project xyz"},
+		},
+		{
+			"BITCOIN:" + addr + "?AMOUNT=0.001",
+			URI{Address: addr, Amount: 100000},
+		},
+		{
+			"bitcoin:" + addr + "?somethingyoudontunderstand=50&somethingelseyoudontget=999",
+			URI{Address: addr, Params: map[string]string{"somethingyoudontunderstand": "50", "somethingelseyoudontget": "999"}},
+		},
+		{
+			"bitcoin:bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4?lightning=lnbc1",
+			URI{Address: "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", Lightning: "lnbc1"},
+		},
+		{"bitcoin:?lightning=lnbc1", URI{Lightning: "lnbc1"}},
+	} {
+		u, err := Parse(v.uri)
+		if err != nil {
+			t.Errorf("%s: %v", v.uri, err)
+			continue
+		}
+		if !reflect.DeepEqual(*u, v.want) {
+			t.Errorf("%s: got %+v, want %+v", v.uri, *u, v.want)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, uri := range []string{
+		addr,
+		"litecoin:" + addr,
+		"bitcoin:",
+		"bitcoin:?amount=1",
+		"bitcoin:1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNb",
+		"bitcoin:" + addr + "?amount=1e3",
+		"bitcoin:" + addr + "?amount=1&amount=2",
+		"bitcoin:" + addr + "?label=a&LABEL=b",
+		"bitcoin:" + addr + "?label=%zz",
+	} {
+		if _, err := Parse(uri); err == nil {
+			t.Errorf("%s: no error", uri)
+		}
+	}
+}
+
+func TestRequiredParam(t *testing.T) {
+	for _, uri := range []string{
+		"bitcoin:" + addr + "?req-somethingyoudontunderstand=50",
+		"bitcoin:" + addr + "?amount=1&REQ-x=1",
+		"bitcoin:?lightning=lnbc1&req-x",
+	} {
+		_, err := Parse(uri)
+		var required *RequiredParamError
+		if !errors.As(err, &required) {
+			t.Errorf("%s: got %v, want a RequiredParamError", uri, err)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, v := range []struct {
+		u   URI
+		uri string
+	}{
+		{URI{Address: addr}, "bitcoin:" + addr},
+		{
+			URI{Address: addr, Amount: 100000, Label: "Luke Jr", Message: "1+1=2 & more"},
+			"bitcoin:" + addr + "?amount=0.001&label=Luke%20Jr&message=1%2B1%3D2%20%26%20more",
+		},
+		{
+			URI{Address: addr, Label: "Zoë ☕"},
+			"bitcoin:" + addr + "?label=Zo%C3%AB%20%E2%98%95",
+		},
+		{
+			URI{Lightning: "lnbc1", Params: map[string]string{"z": "1", "pj": "https://x/?a=b"}},
+			"bitcoin:?lightning=lnbc1&pj=https%3A%2F%2Fx%2F%3Fa%3Db&z=1",
+		},
+	} {
+		if got := v.u.String(); got != v.uri {
+			t.Errorf("%+v: got %s, want %s", v.u, got, v.uri)
+		}
+		u, err := Parse(v.uri)
+		if err != nil {
+			t.Errorf("%s: %v", v.uri, err)
+			continue
+		}
+		if !reflect.DeepEqual(*u, v.u) {
+			t.Errorf("%s: got %+v, want %+v", v.uri, *u, v.u)
+		}
+	}
+
+	// A + is a literal plus, not a space as in form encoding.
+	u, err := Parse("bitcoin:" + addr + "?message=a+b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Message != "a+b" {
+		t.Errorf("message %q, want %q", u.Message, "a+b")
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	for _, v := range []struct {
+		s    string
+		want int64
+	}{
+		{"0", 0},
+		{"1", 100000000},
+		{"1.", 100000000},
+		{".5", 50000000},
+		{"0.00000001", 1},
+		{"20.3", 2030000000},
+		{"21000000", 2100000000000000},
+		{"21000000.00000000", 2100000000000000},
+	} {
+		got, err := ParseAmount(v.s)
+		if err != nil {
+			t.Errorf("%s: %v", v.s, err)
+		} else if got != v.want {
+			t.Errorf("%s: got %d, want %d", v.s, got, v.want)
+		}
+		if err == nil && got != 0 {
+			if back, _ := ParseAmount(FormatAmount(got)); back != got {
+				t.Errorf("%s: formatted as %s", v.s, FormatAmount(got))
+			}
+		}
+	}
+
+	for _, s := range []string{
+		"", ".", "-1", "+1", "1e3", "0x10", " 1", "1,5",
+		"0.000000001", "21000000.00000001", "21000001", "99999999999999999999",
+	} {
+		if _, err := ParseAmount(s); err != ErrAmount {
+			t.Errorf("%q: got %v, want %v", s, err, ErrAmount)
+		}
+	}
+}
@@ -0,0 +1,352 @@
+// Package bip322 signs and verifies messages with the BIP322 generic
+// format, which proves ownership of any address by signing a virtual
+// transaction that spends from it.
+//
+// The message is committed to by to_spend, a transaction that pays to the
+// address and cannot be mined. to_sign spends it to an OP_RETURN output,
+// and its input is signed like a real spend. A simple signature is the
+// witness stack of that input, a full signature is the whole to_sign
+// transaction, needed for addresses without witness such as P2PKH.
+//
+// https://github.com/bitcoin/bips/blob/master/bip-0322.mediawiki
+package bip322
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/smallnest/bitcoin/wallet/address"
+	"github.com/smallnest/bitcoin/wallet/ecc"
+	"github.com/smallnest/bitcoin/wallet/entropy"
+	"github.com/smallnest/bitcoin/wallet/message"
+	"github.com/smallnest/bitcoin/wallet/schnorr"
+	"github.com/smallnest/bitcoin/wallet/taproot"
+	"github.com/smallnest/bitcoin/wallet/tx"
+	"github.com/smallnest/bitcoin/wallet/wif"
+)
+
+var (
+	// ErrInvalid is returned when a signature does not prove ownership of
+	// the address for the message.
+	ErrInvalid = errors.New("bip322: invalid signature")
+	// ErrKeyMismatch is returned when signing for an address with the key
+	// of another one.
+	ErrKeyMismatch = errors.New("bip322: the key does not belong to the address")
+)
+
+// MessageHash returns the tagged hash of the message committed to by
+// to_spend.
+func MessageHash(msg string) [32]byte {
+	return taproot.TaggedHash("BIP0322-signed-message", []byte(msg))
+}
+
+// ToSpend returns the virtual transaction that commits to msg and pays to
+// scriptPubKey, the output script of the address that signs.
+func ToSpend(scriptPubKey []byte, msg string) *tx.Tx {
+	hash := MessageHash(msg)
+	scriptSig := append([]byte{tx.Op0}, tx.PushData(hash[:])...)
+	return &tx.Tx{
+		Version: 0,
+		Inputs: []*tx.TxIn{{
+			PreviousOutPoint: tx.OutPoint{Index: 0xffffffff},
+			ScriptSig:        scriptSig,
+			Sequence:         0,
+		}},
+		Outputs: []*tx.TxOut{{Value: 0, ScriptPubKey: scriptPubKey}},
+	}
+}
+
+// ToSign returns the unsigned virtual transaction that spends toSpend to an
+// OP_RETURN output.
+func ToSign(toSpend *tx.Tx) *tx.Tx {
+	return &tx.Tx{
+		Version: 0,
+		Inputs: []*tx.TxIn{{
+			PreviousOutPoint: tx.OutPoint{Hash: toSpend.TxID(), Index: 0},
+			Sequence:         0,
+		}},
+		Outputs: []*tx.TxOut{{Value: 0, ScriptPubKey: []byte{tx.OpReturn}}},
+	}
+}
+
+// SignSimple signs msg for a P2WPKH, P2SH-P2WPKH or P2TR address with its
+// key and returns the base64 encoded witness stack.
+func SignSimple(key *wif.WIF, addr *address.Address, msg string) (string, error) {
+	if addr.Kind == address.P2PKH {
+		return "", errors.New("bip322: p2pkh addresses have no witness, use a full or a legacy signature")
+	}
+	toSign, err := sign(key, addr, msg)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(tx.SerializeWitness(toSign.Inputs[0].Witness)), nil
+}
+
+// SignFull signs msg for a P2PKH, P2WPKH, P2SH-P2WPKH or P2TR address with
+// its key and returns the base64 encoded to_sign transaction.
+func SignFull(key *wif.WIF, addr *address.Address, msg string) (string, error) {
+	toSign, err := sign(key, addr, msg)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(toSign.Serialize()), nil
+}
+
+// sign returns the to_sign transaction of msg, signed for addr.
+func sign(key *wif.WIF, addr *address.Address, msg string) (*tx.Tx, error) {
+	privateKey := key.PrivateKey[:]
+	pubKey, err := ecc.Default.PublicKey(privateKey, key.Compressed)
+	if err != nil {
+		return nil, err
+	}
+
+	toSpend := ToSpend(addr.ScriptPubKey(), msg)
+	toSign := ToSign(toSpend)
+	in := toSign.Inputs[0]
+
+	switch addr.Kind {
+	case address.P2PKH:
+		if !address.NewP2PKHFromKey(addr.Network, pubKey).Equal(addr) {
+			return nil, ErrKeyMismatch
+		}
+		hash, err := toSign.LegacySigHash(0, addr.ScriptPubKey(), tx.SigHashAll)
+		if err != nil {
+			return nil, err
+		}
+		sig, err := signECDSA(hash, privateKey)
+		if err != nil {
+			return nil, err
+		}
+		in.ScriptSig = append(tx.PushData(sig), tx.PushData(pubKey)...)
+
+	case address.P2WPKH, address.P2SH:
+		w, err := address.NewP2WPKHFromKey(addr.Network, pubKey)
+		if err != nil {
+			return nil, err
+		}
+		if addr.Kind == address.P2SH {
+			nested, _ := address.NewP2SHP2WPKHFromKey(addr.Network, pubKey)
+			if !nested.Equal(addr) {
+				return nil, ErrKeyMismatch
+			}
+			in.ScriptSig = tx.PushData(w.ScriptPubKey())
+		} else if !w.Equal(addr) {
+			return nil, ErrKeyMismatch
+		}
+		hash, err := toSign.WitnessV0SigHash(0, tx.P2PKHScript(w.Hash), 0, tx.SigHashAll)
+		if err != nil {
+			return nil, err
+		}
+		sig, err := signECDSA(hash, privateKey)
+		if err != nil {
+			return nil, err
+		}
+		in.Witness = [][]byte{sig, pubKey}
+
+	case address.P2TR:
+		outputKey, _, err := taproot.OutputKey(pubKey, nil)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(outputKey, addr.Hash) {
+			return nil, ErrKeyMismatch
+		}
+		tweaked, err := taproot.TweakPrivateKey(privateKey, nil)
+		if err != nil {
+			return nil, err
+		}
+		hash, err := toSign.TaprootSigHash(0, toSpend.Outputs, tx.SigHashDefault)
+		if err != nil {
+			return nil, err
+		}
+		var aux [32]byte
+		if _, err := entropy.Default.Read(aux[:]); err != nil {
+			return nil, err
+		}
+		sig, err := schnorr.Sign(hash[:], tweaked, aux[:])
+		if err != nil {
+			return nil, err
+		}
+		in.Witness = [][]byte{sig}
+
+	default:
+		return nil, fmt.Errorf("bip322: cannot sign for %s addresses", addr.Kind)
+	}
+	return toSign, nil
+}
+
+// signECDSA returns a low-s DER signature of hash with SIGHASH_ALL
+// appended. Like Bitcoin Core, the RFC 6979 nonce is ground with a counter
+// until r is below 2^255, which saves a byte and gives the same signatures
+// as Core.
+func signECDSA(hash [32]byte, privateKey []byte) ([]byte, error) {
+	var extra []byte
+	for counter := uint32(1); ; counter++ {
+		nonce := ecc.NonceRFC6979Extra(privateKey, hash[:], extra)
+		sig, err := ecc.Default.Sign(hash[:], privateKey, nonce)
+		if err != nil {
+			return nil, err
+		}
+		if sig, err = ecc.NormalizeSignature(sig); err != nil {
+			return nil, err
+		}
+		// 0x30 len 0x02 len(r): a 32-byte r has no leading zero.
+		if sig[3] <= 32 {
+			return append(sig, tx.SigHashAll), nil
+		}
+		extra = make([]byte, 32)
+		binary.LittleEndian.PutUint32(extra, counter)
+	}
+}
+
+// Verify checks that signature proves ownership of addr for msg. It accepts
+// simple and full BIP322 signatures for P2PKH, P2WPKH, P2SH-P2WPKH and P2TR
+// addresses, and legacy signed messages for P2PKH addresses. Full
+// signatures with more than one input, which prove control of funds, are
+// rejected.
+func Verify(addr, msg, signature string) error {
+	a, err := address.Parse(addr)
+	if err != nil {
+		return err
+	}
+	b, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("bip322: invalid base64 signature: %v", err)
+	}
+	if a.Kind == address.P2PKH && len(b) == 65 {
+		return message.Verify(addr, signature, msg)
+	}
+
+	toSpend := ToSpend(a.ScriptPubKey(), msg)
+	toSign, err := tx.Deserialize(b)
+	if err != nil {
+		// Not a transaction, so a simple signature.
+		witness, err := tx.ParseWitness(b)
+		if err != nil {
+			return ErrInvalid
+		}
+		toSign = ToSign(toSpend)
+		toSign.Inputs[0].Witness = witness
+		// A simple signature has no scriptSig, rebuild the one of a
+		// P2SH-P2WPKH spend from the key in the witness.
+		if a.Kind == address.P2SH && len(witness) == 2 {
+			if w, err := address.NewP2WPKHFromKey(a.Network, witness[1]); err == nil {
+				toSign.Inputs[0].ScriptSig = tx.PushData(w.ScriptPubKey())
+			}
+		}
+	}
+	return verify(a, toSpend, toSign)
+}
+
+func verify(a *address.Address, toSpend, toSign *tx.Tx) error {
+	if len(toSign.Inputs) != 1 {
+		return fmt.Errorf("bip322: proofs of funds with %d inputs are not supported", len(toSign.Inputs))
+	}
+	in := toSign.Inputs[0]
+	if in.PreviousOutPoint.Hash != toSpend.TxID() || in.PreviousOutPoint.Index != 0 {
+		return ErrInvalid
+	}
+	if len(toSign.Outputs) != 1 || toSign.Outputs[0].Value != 0 || !bytes.Equal(toSign.Outputs[0].ScriptPubKey, []byte{tx.OpReturn}) {
+		return ErrInvalid
+	}
+
+	switch a.Kind {
+	case address.P2PKH:
+		pushes, ok := parsePushes(in.ScriptSig)
+		if !ok || len(pushes) != 2 || len(in.Witness) != 0 {
+			return ErrInvalid
+		}
+		sig, pubKey := pushes[0], pushes[1]
+		if !bytes.Equal(address.Hash160(pubKey), a.Hash) {
+			return ErrInvalid
+		}
+		hash, err := toSign.LegacySigHash(0, a.ScriptPubKey(), tx.SigHashAll)
+		if err != nil {
+			return err
+		}
+		return verifyECDSA(hash, sig, pubKey)
+
+	case address.P2WPKH, address.P2SH:
+		if len(in.Witness) != 2 || len(in.Witness[1]) != 33 {
+			return ErrInvalid
+		}
+		sig, pubKey := in.Witness[0], in.Witness[1]
+		w, _ := address.NewP2WPKHFromKey(a.Network, pubKey)
+		if a.Kind == address.P2SH {
+			// Only P2SH-P2WPKH: the scriptSig pushes the P2WPKH script.
+			if !bytes.Equal(in.ScriptSig, tx.PushData(w.ScriptPubKey())) ||
+				!bytes.Equal(address.Hash160(w.ScriptPubKey()), a.Hash) {
+				return ErrInvalid
+			}
+		} else if len(in.ScriptSig) != 0 || !bytes.Equal(w.Hash, a.Hash) {
+			return ErrInvalid
+		}
+		hash, err := toSign.WitnessV0SigHash(0, tx.P2PKHScript(w.Hash), 0, tx.SigHashAll)
+		if err != nil {
+			return err
+		}
+		return verifyECDSA(hash, sig, pubKey)
+
+	case address.P2TR:
+		if len(in.Witness) != 1 || len(in.ScriptSig) != 0 {
+			return ErrInvalid
+		}
+		sig := in.Witness[0]
+		hashType := tx.SigHashDefault
+		switch {
+		case len(sig) == 65 && sig[64] == tx.SigHashAll:
+			hashType, sig = tx.SigHashAll, sig[:64]
+		case len(sig) != 64:
+			return ErrInvalid
+		}
+		hash, err := toSign.TaprootSigHash(0, toSpend.Outputs, hashType)
+		if err != nil {
+			return err
+		}
+		if !schnorr.Verify(hash[:], sig, a.Hash) {
+			return ErrInvalid
+		}
+		return nil
+	}
+	return fmt.Errorf("bip322: cannot verify %s addresses", a.Kind)
+}
+
+// verifyECDSA checks a DER signature followed by SIGHASH_ALL.
+func verifyECDSA(hash [32]byte, sig, pubKey []byte) error {
+	if len(sig) == 0 || sig[len(sig)-1] != tx.SigHashAll {
+		return ErrInvalid
+	}
+	if !ecc.Default.Verify(hash[:], sig[:len(sig)-1], pubKey) {
+		return ErrInvalid
+	}
+	return nil
+}
+
+// parsePushes splits a script made only of data pushes into the pushed
+// items.
+func parsePushes(script []byte) ([][]byte, bool) {
+	var items [][]byte
+	for len(script) > 0 {
+		op := script[0]
+		var n, skip int
+		switch {
+		case op > 0 && op < tx.OpPushData1:
+			n, skip = int(op), 1
+		case op == tx.OpPushData1 && len(script) > 1:
+			n, skip = int(script[1]), 2
+		case op == tx.OpPushData2 && len(script) > 2:
+			n, skip = int(script[1])|int(script[2])<<8, 3
+		default:
+			return nil, false
+		}
+		if len(script) < skip+n {
+			return nil, false
+		}
+		items = append(items, script[skip:skip+n])
+		script = script[skip+n:]
+	}
+	return items, true
+}
@@ -0,0 +1,127 @@
+package bip322
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/smallnest/bitcoin/wallet/address"
+	"github.com/smallnest/bitcoin/wallet/wif"
+)
+
+// The test vectors of BIP322, all for the key below.
+const (
+	vectorKey    = "L3VFeEujGtevx9w18HD1fhRbCH67Az2dpCymeRE1SoPK6XQtaN2k"
+	vectorP2WPKH = "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l"
+	vectorP2TR   = "bc1ppv609nr0vr25u07u95waq5lucwfm6tde4nydujnu8npg4q75mr5sxq8lt3"
+)
+
+var messageVectors = []struct {
+	msg     string
+	hash    string
+	toSpend string
+	toSign  string
+	// P2WPKH simple signature.
+	signature string
+}{
+	{
+		msg:       "",
+		hash:      "c90c269c4f8fcbe6880f72a721ddfbf1914268a794cbb21cfafee13770ae19f1",
+		toSpend:   "c5680aa69bb8d860bf82d4e9cd3504b55dde018de765a91bb566283c545a99a7",
+		toSign:    "1e9654e951a5ba44c8604c4de6c67fd78a27e81dcadcfe1edf638ba3aaebaed6",
+		signature: "AkcwRAIgM2gBAQqvZX15ZiysmKmQpDrG83avLIT492QBzLnQIxYCIBaTpOaD20qRlEylyxFSeEA2ba9YOixpX8z46TSDtS40ASECx/EgAxlkQpQ9hYjgGu6EBCPMVPwVIVJqO4XCsMvViHI=",
+	},
+	{
+		msg:       "Hello World",
+		hash:      "f0eb03b1a75ac6d9847f55c624a99169b5dccba2a31f5b23bea77ba270de0a7a",
+		toSpend:   "b79d196740ad5217771c1098fc4a4b51e0535c32236c71f1ea4d61a2d603352b",
+		toSign:    "88737ae86f2077145f93cc4b153ae9a1cb8d56afa511988c149c5c8c9d93bddf",
+		signature: "AkcwRAIgZRfIY3p7/DoVTty6YZbWS71bc5Vct9p9Fia83eRmw2QCICK/ENGfwLtptFluMGs2KsqoNSk89pO7F29zJLUx9a/sASECx/EgAxlkQpQ9hYjgGu6EBCPMVPwVIVJqO4XCsMvViHI=",
+	},
+}
+
+func TestMessageVectors(t *testing.T) {
+	addr, err := address.Parse(vectorP2WPKH)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := wif.Decode(vectorKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, v := range messageVectors {
+		hash := MessageHash(v.msg)
+		if got := hex.EncodeToString(hash[:]); got != v.hash {
+			t.Errorf("%q: message hash %s, want %s", v.msg, got, v.hash)
+		}
+		toSpend := ToSpend(addr.ScriptPubKey(), v.msg)
+		if got := toSpend.TxID().String(); got != v.toSpend {
+			t.Errorf("%q: to_spend %s, want %s", v.msg, got, v.toSpend)
+		}
+		if got := ToSign(toSpend).TxID().String(); got != v.toSign {
+			t.Errorf("%q: to_sign %s, want %s", v.msg, got, v.toSign)
+		}
+
+		// RFC 6979 with low r grinding gives the signatures of the BIP.
+		sig, err := SignSimple(key, addr, v.msg)
+		if err != nil {
+			t.Errorf("%q: %v", v.msg, err)
+		} else if sig != v.signature {
+			t.Errorf("%q: signature %s, want %s", v.msg, sig, v.signature)
+		}
+		if err := Verify(vectorP2WPKH, v.msg, v.signature); err != nil {
+			t.Errorf("%q: %v", v.msg, err)
+		}
+	}
+
+	// The signature of "" does not prove "Hello World", nor the other way.
+	if err := Verify(vectorP2WPKH, "Hello World", messageVectors[0].signature); err != ErrInvalid {
+		t.Errorf("signature of another message: %v, want ErrInvalid", err)
+	}
+	// The BIP also lists a second, high r, signature of "Hello World".
+	const highR = "AkgwRQIhAOzyynlqt93lOKJr+wmmxIens//zPzl9tqIOua93wO6MAiBi5n5EyAcPScOjf1lAqIUIQtr3zKNeavYabHyR8eGhowEhAsfxIAMZZEKUPYWI4BruhAQjzFT8FSFSajuFwrDL1Yhy"
+	if err := Verify(vectorP2WPKH, "Hello World", highR); err != nil {
+		t.Errorf("high r signature: %v", err)
+	}
+}
+
+func TestTaprootVector(t *testing.T) {
+	const sig = "AUHd69PrJQEv+oKTfZ8l+WROBHuy9HKrbFCJu7U1iK2iiEy1vMU5EfMtjc+VSHM7aU0SDbak5IUZRVno2P5mjSafAQ=="
+	if err := Verify(vectorP2TR, "Hello World", sig); err != nil {
+		t.Errorf("BIP322 vector: %v", err)
+	}
+	if err := Verify(vectorP2TR, "", sig); err != ErrInvalid {
+		t.Errorf("signature of another message: %v, want ErrInvalid", err)
+	}
+
+	// Schnorr signatures use random auxiliary data, so only check that a
+	// new one verifies.
+	key, _ := wif.Decode(vectorKey)
+	addr, _ := address.Parse(vectorP2TR)
+	for _, sign := range []func(*wif.WIF, *address.Address, string) (string, error){SignSimple, SignFull} {
+		s, err := sign(key, addr, "Hello World")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := Verify(vectorP2TR, "Hello World", s); err != nil {
+			t.Errorf("%s: %v", s, err)
+		}
+	}
+}
+
+func TestSignKeyMismatch(t *testing.T) {
+	key, _ := wif.Decode(vectorKey)
+	for _, a := range []string{
+		"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4",
+		"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0",
+		"1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH",
+	} {
+		addr, err := address.Parse(a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := SignFull(key, addr, "Hello World"); err != ErrKeyMismatch {
+			t.Errorf("%s: %v, want ErrKeyMismatch", a, err)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+// Package bip38 encrypts private keys with a passphrase as described in
+// BIP38. Encrypted keys start with 6P.
+//
+// Without EC multiplication the owner encrypts a key they already have.
+// With EC multiplication the owner hands an intermediate code to a third
+// party, which generates encrypted keys and confirmation codes without ever
+// learning the private keys.
+package bip38
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/sha256"
+	"errors"
+
+	"github.com/smallnest/bitcoin/wallet/address"
+	"github.com/smallnest/bitcoin/wallet/base58check"
+	"github.com/smallnest/bitcoin/wallet/ecc"
+	"github.com/smallnest/bitcoin/wallet/wif"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/text/unicode/norm"
+)
+
+var (
+	// ErrPassphrase is returned when the decrypted key does not match the
+	// address hash, which means the passphrase is wrong.
+	ErrPassphrase = errors.New("bip38: wrong passphrase")
+	// ErrFormat is returned for strings that are not BIP38 encrypted keys,
+	// intermediate codes or confirmation codes.
+	ErrFormat = errors.New("bip38: invalid format")
+)
+
+// Prefixes and flags of the encoded values.
+var (
+	prefixNonEC        = []byte{0x01, 0x42}
+	prefixEC           = []byte{0x01, 0x43}
+	prefixConfirmation = []byte{0x64, 0x3b, 0xf6, 0xa8, 0x9a}
+)
+
+const (
+	flagNonEC      = 0xc0
+	flagCompressed = 0x20
+	flagLotSeq     = 0x04
+)
+
+// scrypt parameters of the passphrase and of the key derivation from the
+// passpoint, which only has to resist brute force as much as the point does.
+const (
+	scryptN, scryptR, scryptP                = 16384, 8, 8
+	pointScryptN, pointScryptR, pointScryptP = 1024, 1, 1
+)
+
+// Encrypt encrypts a private key with a passphrase, without EC
+// multiplication.
+func Encrypt(w *wif.WIF, passphrase string) (string, error) {
+	pubKey, err := ecc.PublicKey(w.PrivateKey[:], w.Compressed)
+	if err != nil {
+		return "", err
+	}
+	hash := addressHash(address.NewP2PKHFromKey(w.Network, pubKey))
+
+	derived, err := scrypt.Key(normalize(passphrase), hash, scryptN, scryptR, scryptP, 64)
+	if err != nil {
+		return "", err
+	}
+
+	flag := byte(flagNonEC)
+	if w.Compressed {
+		flag |= flagCompressed
+	}
+	payload := append([]byte{flag}, hash...)
+	payload = append(payload, encryptHalves(w.PrivateKey[:], derived)...)
+	return base58check.EncodeVersion(prefixNonEC, payload), nil
+}
+
+// Decrypt decrypts an encrypted key of either mode. The network selects the
+// address the embedded address hash is checked against.
+func Decrypt(encrypted, passphrase string, net address.Network) (*wif.WIF, error) {
+	version, payload, err := base58check.DecodeVersion(encrypted, 2)
+	if err != nil {
+		return nil, err
+	}
+	if len(payload) != 37 {
+		return nil, ErrFormat
+	}
+	switch {
+	case bytes.Equal(version, prefixNonEC):
+		return decryptNonEC(payload, passphrase, net)
+	case bytes.Equal(version, prefixEC):
+		return decryptEC(payload, passphrase, net)
+	}
+	return nil, ErrFormat
+}
+
+func decryptNonEC(payload []byte, passphrase string, net address.Network) (*wif.WIF, error) {
+	flag, hash := payload[0], payload[1:5]
+	if flag&^flagCompressed != flagNonEC {
+		return nil, ErrFormat
+	}
+
+	derived, err := scrypt.Key(normalize(passphrase), hash, scryptN, scryptR, scryptP, 64)
+	if err != nil {
+		return nil, err
+	}
+	key := decryptHalves(payload[5:37], derived)
+
+	w, err := wif.New(key, net, flag&flagCompressed != 0)
+	if err != nil {
+		return nil, ErrPassphrase
+	}
+	if err := checkAddressHash(w, hash); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func decryptEC(payload []byte, passphrase string, net address.Network) (*wif.WIF, error) {
+	flag, hash, ownerEntropy := payload[0], payload[1:5], payload[5:13]
+	encryptedPart1, encryptedPart2 := payload[13:21], payload[21:37]
+
+	passFactor, err := passFactor(passphrase, ownerEntropy, flag&flagLotSeq != 0)
+	if err != nil {
+		return nil, err
+	}
+	passPoint := ecc.ScalarBaseMult(passFactor).Serialize(true)
+	derived, err := scrypt.Key(passPoint, append(append([]byte(nil), hash...), ownerEntropy...),
+		pointScryptN, pointScryptR, pointScryptP, 64)
+	if err != nil {
+		return nil, err
+	}
+	half1, half2 := derived[:32], derived[32:]
+
+	// encryptedpart2 holds the second half of encryptedpart1 and the last 8
+	// bytes of seedb.
+	part2 := xor(aesDecrypt(half2, encryptedPart2), half1[16:32])
+	part1 := append(append([]byte(nil), encryptedPart1...), part2[:8]...)
+	seedB := append(xor(aesDecrypt(half2, part1), half1[:16]), part2[8:]...)
+
+	factorB := doubleSHA256(seedB)
+	key := ecc.MulScalars(passFactor, factorB)
+
+	w, err := wif.New(key, net, flag&flagCompressed != 0)
+	if err != nil {
+		return nil, ErrPassphrase
+	}
+	if err := checkAddressHash(w, hash); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// addressHash returns the first 4 bytes of the double SHA256 of the address.
+func addressHash(addr *address.Address) []byte {
+	return doubleSHA256([]byte(addr.String()))[:4]
+}
+
+func checkAddressHash(w *wif.WIF, hash []byte) error {
+	pubKey, err := ecc.PublicKey(w.PrivateKey[:], w.Compressed)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(addressHash(address.NewP2PKHFromKey(w.Network, pubKey)), hash) {
+		return ErrPassphrase
+	}
+	return nil
+}
+
+// encryptHalves encrypts 32 bytes as two AES blocks, each XORed with half of
+// derivedhalf1 and encrypted with derivedhalf2.
+func encryptHalves(data, derived []byte) []byte {
+	half1, half2 := derived[:32], derived[32:]
+	out := aesEncrypt(half2, xor(data[:16], half1[:16]))
+	return append(out, aesEncrypt(half2, xor(data[16:32], half1[16:32]))...)
+}
+
+func decryptHalves(data, derived []byte) []byte {
+	half1, half2 := derived[:32], derived[32:]
+	out := xor(aesDecrypt(half2, data[:16]), half1[:16])
+	return append(out, xor(aesDecrypt(half2, data[16:32]), half1[16:32])...)
+}
+
+// aesEncrypt encrypts a single block with AES-256, which is all BIP38 uses.
+func aesEncrypt(key, block []byte) []byte {
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		panic(err)
+	}
+	out := make([]byte, aes.BlockSize)
+	c.Encrypt(out, block)
+	return out
+}
+
+func aesDecrypt(key, block []byte) []byte {
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		panic(err)
+	}
+	out := make([]byte, aes.BlockSize)
+	c.Decrypt(out, block)
+	return out
+}
+
+func xor(a, b []byte) []byte {
+	out := make([]byte, len(a))
+	for i := range a {
+		out[i] = a[i] ^ b[i]
+	}
+	return out
+}
+
+func doubleSHA256(b []byte) []byte {
+	first := sha256.Sum256(b)
+	second := sha256.Sum256(first[:])
+	return second[:]
+}
+
+// normalize returns the passphrase in Unicode normalization form C.
+func normalize(passphrase string) []byte {
+	return []byte(norm.NFC.String(passphrase))
+}
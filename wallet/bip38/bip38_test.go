@@ -0,0 +1,187 @@
+package bip38
+
+import (
+	"testing"
+
+	"github.com/smallnest/bitcoin/wallet/address"
+	"github.com/smallnest/bitcoin/wallet/ecc"
+	"github.com/smallnest/bitcoin/wallet/entropy"
+	"github.com/smallnest/bitcoin/wallet/wif"
+)
+
+// vectors are the test vectors of BIP38.
+var vectors = []struct {
+	name       string
+	encrypted  string
+	passphrase string
+	wif        string
+	// address, confirmation, lot and sequence of EC multiplied keys.
+	address      string
+	confirmation string
+	lot, seq     uint32
+}{
+	{
+		name:       "no EC multiply, no compression 1",
+		encrypted:  "6PRVWUbkzzsbcVac2qwfssoUJAN1Xhrg6bNk8J7Nzm5H7kxEbn2Nh2ZoGg",
+		passphrase: "TestingOneTwoThree",
+		wif:        "5KN7MzqK5wt2TP1fQCYyHBtDrXdJuXbUzm4A9rKAteGu3Qi5CVR",
+	},
+	{
+		name:       "no EC multiply, no compression 2",
+		encrypted:  "6PRNFFkZc2NZ6dJqFfhRoFNMR9Lnyj7dYGrzdgXXVMXcxoKTePPX1dWByq",
+		passphrase: "Satoshi",
+		wif:        "5HtasZ6ofTHP6HCwTqTkLDuLQisYPah7aUnSKfC7h4hMUVw2gi5",
+	},
+	{
+		name:       "no EC multiply, no compression, unicode passphrase",
+		encrypted:  "6PRW5o9FLp4gJDDVqJQKJFTpMvdsSGJxMYHtHaQBF3ooa8mwD69bapcDQn",
+		passphrase: "ϓ\u0000\U00010400\U0001f4a9",
+		wif:        "5Jajm8eQ22H3pGWLEVCXyvND8dQZhiQhoLJNKjYXk9roUFTMSZ4",
+	},
+	{
+		name:       "no EC multiply, compression 1",
+		encrypted:  "6PYNKZ1EAgYgmQfmNVamxyXVWHzK5s6DGhwP4J5o44cvXdoY7sRzhtpUeo",
+		passphrase: "TestingOneTwoThree",
+		wif:        "L44B5gGEpqEDRS9vVPz7QT35jcBG2r3CZwSwQ4fCewXAhAhqGVpP",
+	},
+	{
+		name:       "no EC multiply, compression 2",
+		encrypted:  "6PYLtMnXvfG3oJde97zRyLYFZCYizPU5T3LwgdYJz1fRhh16bU7u6PPmY7",
+		passphrase: "Satoshi",
+		wif:        "KwYgW8gcxj1JWJXhPSu4Fqwzfhp5Yfi42mdYmMa4XqK7NJxXUSK7",
+	},
+	{
+		name:       "EC multiply, no lot/sequence 1",
+		encrypted:  "6PfQu77ygVyJLZjfvMLyhLMQbYnu5uguoJJ4kMCLqWwPEdfpwANVS76gTX",
+		passphrase: "TestingOneTwoThree",
+		wif:        "5K4caxezwjGCGfnoPTZ8tMcJBLB7Jvyjv4xxeacadhq8nLisLR2",
+		address:    "1PE6TQi6HTVNz5DLwB1LcpMBALubfuN2z2",
+	},
+	{
+		name:       "EC multiply, no lot/sequence 2",
+		encrypted:  "6PfLGnQs6VZnrNpmVKfjotbnQuaJK4KZoPFrAjx1JMJUa1Ft8gnf5WxfKd",
+		passphrase: "Satoshi",
+		wif:        "5KJ51SgxWaAYR13zd9ReMhJpwrcX47xTJh2D3fGPG9CM8vkv5sH",
+		address:    "1CqzrtZC6mXSAhoxtFwVjz8LtwLJjDYU3V",
+	},
+	{
+		name:         "EC multiply, lot/sequence 1",
+		encrypted:    "6PgNBNNzDkKdhkT6uJntUXwwzQV8Rr2tZcbkDcuC9DZRsS6AtHts4Ypo1j",
+		passphrase:   "MOLON LABE",
+		wif:          "5JLdxTtcTHcfYcmJsNVy1v2PMDx432JPoYcBTVVRHpPaxUrdtf8",
+		address:      "1Jscj8ALrYu2y9TD8NrpvDBugPedmbj4Yh",
+		confirmation: "cfrm38V8aXBn7JWA1ESmFMUn6erxeBGZGAxJPY4e36S9QWkzZKtaVqLNMgnifETYw7BPwWC9aPD",
+		lot:          263183,
+		seq:          1,
+	},
+	{
+		name:         "EC multiply, lot/sequence 2",
+		encrypted:    "6PgGWtx25kUg8QWvwuJAgorN6k9FbE25rv5dMRwu5SKMnfpfVe5mar2ngH",
+		passphrase:   "ΜΟΛΩΝ ΛΑΒΕ",
+		wif:          "5KMKKuUmAkiNbA3DazMQiLfDq47qs8MAEThm4yL8R2PhV1ov33D",
+		address:      "1Lurmih3KruL4xDB5FmHof38yawNtP9oGf",
+		confirmation: "cfrm38V8G4qq2ywYEFfWLD5Cc6msj9UwsG2Mj4Z6QdGJAFQpdatZLavkgRd1i4iBMdRngDqDs51",
+		lot:          806938,
+		seq:          1,
+	},
+}
+
+func TestVectors(t *testing.T) {
+	for _, v := range vectors {
+		w, err := Decrypt(v.encrypted, v.passphrase, address.Mainnet)
+		if err != nil {
+			t.Errorf("%s: %v", v.name, err)
+			continue
+		}
+		if got := w.String(); got != v.wif {
+			t.Errorf("%s: decrypted %s, want %s", v.name, got, v.wif)
+		}
+
+		if v.address == "" {
+			// Without EC multiplication encryption is deterministic.
+			key, _ := wif.Decode(v.wif)
+			if got, err := Encrypt(key, v.passphrase); err != nil || got != v.encrypted {
+				t.Errorf("%s: encrypted %s, %v", v.name, got, err)
+			}
+			continue
+		}
+
+		pubKey, _ := ecc.PublicKey(w.PrivateKey[:], w.Compressed)
+		if got := address.NewP2PKHFromKey(address.Mainnet, pubKey).String(); got != v.address {
+			t.Errorf("%s: address %s, want %s", v.name, got, v.address)
+		}
+		lot, seq, ok := LotSequence(v.encrypted)
+		if ok != (v.confirmation != "") || lot != v.lot || seq != v.seq {
+			t.Errorf("%s: lot %d sequence %d (%v), want %d %d", v.name, lot, seq, ok, v.lot, v.seq)
+		}
+		if v.confirmation != "" {
+			addr, err := VerifyConfirmation(v.confirmation, v.passphrase, address.Mainnet)
+			if err != nil || addr.String() != v.address {
+				t.Errorf("%s: confirmation gives %v, %v", v.name, addr, err)
+			}
+			if lot, seq, ok := LotSequence(v.confirmation); !ok || lot != v.lot || seq != v.seq {
+				t.Errorf("%s: confirmation lot %d sequence %d", v.name, lot, seq)
+			}
+		}
+	}
+}
+
+func TestWrongPassphrase(t *testing.T) {
+	if _, err := Decrypt(vectors[0].encrypted, "TestingOneTwoThreeX", address.Mainnet); err != ErrPassphrase {
+		t.Errorf("non-EC: %v, want ErrPassphrase", err)
+	}
+	if _, err := Decrypt(vectors[5].encrypted, "Satoshi", address.Mainnet); err != ErrPassphrase {
+		t.Errorf("EC: %v, want ErrPassphrase", err)
+	}
+	if _, err := VerifyConfirmation(vectors[7].confirmation, "molon labe", address.Mainnet); err != ErrPassphrase {
+		t.Errorf("confirmation: %v, want ErrPassphrase", err)
+	}
+}
+
+// TestGenerate runs the whole EC multiply flow: the owner makes an
+// intermediate code, a third party generates a key with it, the owner
+// confirms the address and decrypts the key.
+func TestGenerate(t *testing.T) {
+	src := entropy.NewDeterministic([]byte("bip38"))
+	for _, lotSeq := range []bool{false, true} {
+		var code string
+		var err error
+		if lotSeq {
+			code, err = NewIntermediateLot(src, "owner", 100000, 7)
+		} else {
+			code, err = NewIntermediate(src, "owner")
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, compressed := range []bool{false, true} {
+			g, err := Generate(src, code, compressed, address.Mainnet)
+			if err != nil {
+				t.Fatal(err)
+			}
+			addr, err := VerifyConfirmation(g.Confirmation, "owner", address.Mainnet)
+			if err != nil || !addr.Equal(g.Address) {
+				t.Errorf("lot %v, compressed %v: confirmation gives %v, %v", lotSeq, compressed, addr, err)
+			}
+			w, err := Decrypt(g.Encrypted, "owner", address.Mainnet)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pubKey, _ := ecc.PublicKey(w.PrivateKey[:], w.Compressed)
+			if w.Compressed != compressed || !address.NewP2PKHFromKey(address.Mainnet, pubKey).Equal(g.Address) {
+				t.Errorf("lot %v, compressed %v: decrypted key does not match %s", lotSeq, compressed, g.Address)
+			}
+			if lot, seq, ok := LotSequence(g.Encrypted); ok != lotSeq || (ok && (lot != 100000 || seq != 7)) {
+				t.Errorf("lot %v: LotSequence gives %d %d %v", lotSeq, lot, seq, ok)
+			}
+		}
+	}
+
+	if _, err := NewIntermediateLot(src, "owner", 1<<20, 0); err != ErrLotSequence {
+		t.Errorf("lot 2^20: %v, want ErrLotSequence", err)
+	}
+	if _, err := NewIntermediateLot(src, "owner", 0, 4096); err != ErrLotSequence {
+		t.Errorf("sequence 4096: %v, want ErrLotSequence", err)
+	}
+}
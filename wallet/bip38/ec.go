@@ -0,0 +1,220 @@
+package bip38
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/smallnest/bitcoin/wallet/address"
+	"github.com/smallnest/bitcoin/wallet/base58check"
+	"github.com/smallnest/bitcoin/wallet/ecc"
+	"github.com/smallnest/bitcoin/wallet/entropy"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Magic bytes of intermediate codes, which make them start with
+// "passphrase".
+var (
+	magicLotSeq   = []byte{0x2c, 0xe9, 0xb3, 0xe1, 0xff, 0x39, 0xe2, 0x51}
+	magicNoLotSeq = []byte{0x2c, 0xe9, 0xb3, 0xe1, 0xff, 0x39, 0xe2, 0x53}
+)
+
+// Limits of the lot and sequence numbers.
+const (
+	MaxLot      = 1<<20 - 1
+	MaxSequence = 1<<12 - 1
+)
+
+// ErrLotSequence is returned for lot or sequence numbers out of range.
+var ErrLotSequence = errors.New("bip38: lot must be at most 1048575 and sequence at most 4095")
+
+// NewIntermediate returns an intermediate code for the passphrase, to be
+// given to the party generating encrypted keys.
+func NewIntermediate(src entropy.Source, passphrase string) (string, error) {
+	ownerSalt := make([]byte, 8)
+	if _, err := io.ReadFull(src, ownerSalt); err != nil {
+		return "", err
+	}
+	return intermediate(ownerSalt, passphrase, false)
+}
+
+// NewIntermediateLot returns an intermediate code that embeds a lot and
+// sequence number in every key generated from it, so that the owner can
+// tell batches apart.
+func NewIntermediateLot(src entropy.Source, passphrase string, lot, sequence uint32) (string, error) {
+	if lot > MaxLot || sequence > MaxSequence {
+		return "", ErrLotSequence
+	}
+	ownerEntropy := make([]byte, 8)
+	if _, err := io.ReadFull(src, ownerEntropy[:4]); err != nil {
+		return "", err
+	}
+	binary.BigEndian.PutUint32(ownerEntropy[4:], lot<<12|sequence)
+	return intermediate(ownerEntropy, passphrase, true)
+}
+
+func intermediate(ownerEntropy []byte, passphrase string, lotSeq bool) (string, error) {
+	passFactor, err := passFactor(passphrase, ownerEntropy, lotSeq)
+	if err != nil {
+		return "", err
+	}
+	magic := magicNoLotSeq
+	if lotSeq {
+		magic = magicLotSeq
+	}
+	payload := append(append([]byte(nil), ownerEntropy...), ecc.ScalarBaseMult(passFactor).Serialize(true)...)
+	return base58check.EncodeVersion(magic, payload), nil
+}
+
+// passFactor derives the owner's secret factor from the passphrase and the
+// owner entropy, whose first 4 bytes are the salt when a lot and sequence
+// number follow them.
+func passFactor(passphrase string, ownerEntropy []byte, lotSeq bool) ([]byte, error) {
+	ownerSalt := ownerEntropy
+	if lotSeq {
+		ownerSalt = ownerEntropy[:4]
+	}
+	preFactor, err := scrypt.Key(normalize(passphrase), ownerSalt, scryptN, scryptR, scryptP, 32)
+	if err != nil {
+		return nil, err
+	}
+	if !lotSeq {
+		return preFactor, nil
+	}
+	return doubleSHA256(append(preFactor, ownerEntropy...)), nil
+}
+
+// Generated is a key generated from an intermediate code. Only the owner
+// of the passphrase can decrypt it.
+type Generated struct {
+	Address *address.Address
+	// Encrypted is the encrypted private key, starting with 6P.
+	Encrypted string
+	// Confirmation is a code starting with cfrm38 that proves to the owner
+	// that Address belongs to the passphrase, see VerifyConfirmation.
+	Confirmation string
+}
+
+// Generate creates a new encrypted private key from an intermediate code,
+// without learning the private key.
+func Generate(src entropy.Source, intermediateCode string, compressed bool, net address.Network) (*Generated, error) {
+	magic, payload, err := base58check.DecodeVersion(intermediateCode, 8)
+	if err != nil {
+		return nil, err
+	}
+	if len(payload) != 41 {
+		return nil, ErrFormat
+	}
+	var flag byte
+	switch {
+	case bytes.Equal(magic, magicLotSeq):
+		flag |= flagLotSeq
+	case !bytes.Equal(magic, magicNoLotSeq):
+		return nil, ErrFormat
+	}
+	if compressed {
+		flag |= flagCompressed
+	}
+	ownerEntropy := payload[:8]
+	passPoint, err := ecc.ParsePoint(payload[8:])
+	if err != nil {
+		return nil, err
+	}
+
+	var seedB, factorB []byte
+	for {
+		seedB = make([]byte, 24)
+		if _, err := io.ReadFull(src, seedB); err != nil {
+			return nil, err
+		}
+		factorB = doubleSHA256(seedB)
+		if ecc.ValidScalar(factorB) {
+			break
+		}
+	}
+
+	pubKey := ecc.ScalarMult(passPoint, factorB).Serialize(compressed)
+	addr := address.NewP2PKHFromKey(net, pubKey)
+	hash := addressHash(addr)
+
+	derived, err := scrypt.Key(payload[8:], append(append([]byte(nil), hash...), ownerEntropy...),
+		pointScryptN, pointScryptR, pointScryptP, 64)
+	if err != nil {
+		return nil, err
+	}
+	half1, half2 := derived[:32], derived[32:]
+
+	encryptedPart1 := aesEncrypt(half2, xor(seedB[:16], half1[:16]))
+	encryptedPart2 := aesEncrypt(half2, xor(append(encryptedPart1[8:16:16], seedB[16:]...), half1[16:32]))
+
+	key := append([]byte{flag}, hash...)
+	key = append(key, ownerEntropy...)
+	key = append(key, encryptedPart1[:8]...)
+	key = append(key, encryptedPart2...)
+
+	pointB := ecc.ScalarBaseMult(factorB).Serialize(true)
+	confirmation := append([]byte{flag}, hash...)
+	confirmation = append(confirmation, ownerEntropy...)
+	confirmation = append(confirmation, pointB[0]^(half2[31]&0x01))
+	confirmation = append(confirmation, encryptHalves(pointB[1:], derived)...)
+
+	return &Generated{
+		Address:      addr,
+		Encrypted:    base58check.EncodeVersion(prefixEC, key),
+		Confirmation: base58check.EncodeVersion(prefixConfirmation, confirmation),
+	}, nil
+}
+
+// VerifyConfirmation checks a confirmation code against the passphrase and
+// returns the address of the encrypted key it was generated with. Compare
+// it with the address the third party paid to.
+func VerifyConfirmation(confirmation, passphrase string, net address.Network) (*address.Address, error) {
+	prefix, payload, err := base58check.DecodeVersion(confirmation, len(prefixConfirmation))
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(prefix, prefixConfirmation) || len(payload) != 46 {
+		return nil, ErrFormat
+	}
+	flag, hash, ownerEntropy := payload[0], payload[1:5], payload[5:13]
+
+	passFactor, err := passFactor(passphrase, ownerEntropy, flag&flagLotSeq != 0)
+	if err != nil {
+		return nil, err
+	}
+	passPoint := ecc.ScalarBaseMult(passFactor).Serialize(true)
+	derived, err := scrypt.Key(passPoint, append(append([]byte(nil), hash...), ownerEntropy...),
+		pointScryptN, pointScryptR, pointScryptP, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	pointB := append([]byte{payload[13] ^ (derived[63] & 0x01)}, decryptHalves(payload[14:46], derived)...)
+	b, err := ecc.ParsePoint(pointB)
+	if err != nil {
+		return nil, ErrPassphrase
+	}
+	pubKey := ecc.ScalarMult(b, passFactor).Serialize(flag&flagCompressed != 0)
+	addr := address.NewP2PKHFromKey(net, pubKey)
+	if !bytes.Equal(addressHash(addr), hash) {
+		return nil, ErrPassphrase
+	}
+	return addr, nil
+}
+
+// LotSequence returns the lot and sequence numbers of an encrypted key or
+// confirmation code made from an intermediate code that has them.
+func LotSequence(s string) (lot, sequence uint32, ok bool) {
+	var payload []byte
+	if version, p, err := base58check.DecodeVersion(s, len(prefixEC)); err == nil && bytes.Equal(version, prefixEC) {
+		payload = p
+	} else if version, p, err := base58check.DecodeVersion(s, len(prefixConfirmation)); err == nil && bytes.Equal(version, prefixConfirmation) {
+		payload = p
+	}
+	if len(payload) < 13 || payload[0]&flagLotSeq == 0 {
+		return 0, 0, false
+	}
+	n := binary.BigEndian.Uint32(payload[9:13])
+	return n >> 12, n & MaxSequence, true
+}
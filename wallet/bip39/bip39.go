@@ -0,0 +1,177 @@
+// Package bip39 implements BIP39 mnemonic sentences: generation from
+// entropy, checksum validation and seed derivation.
+//
+// https://github.com/bitcoin/bips/blob/master/bip-0039.mediawiki
+package bip39
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+
+	"github.com/smallnest/bitcoin/wallet/entropy"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/text/unicode/norm"
+)
+
+var (
+	// ErrEntropyLength is returned for entropy that is not 128 to 256 bits
+	// in steps of 32.
+	ErrEntropyLength = errors.New("bip39: entropy must be 128 to 256 bits in steps of 32")
+	// ErrWordCount is returned for mnemonics that are not 12, 15, 18, 21
+	// or 24 words long.
+	ErrWordCount = errors.New("bip39: mnemonic must have 12, 15, 18, 21 or 24 words")
+	// ErrChecksum is returned when the checksum bits of a mnemonic do not
+	// match its entropy.
+	ErrChecksum = errors.New("bip39: invalid mnemonic checksum")
+)
+
+// InvalidWordError is returned when a word is not in the wordlist.
+type InvalidWordError struct {
+	Word  string
+	Index int // position of the word in the mnemonic
+}
+
+func (e InvalidWordError) Error() string {
+	return fmt.Sprintf("bip39: word %d %q is not in the wordlist", e.Index+1, e.Word)
+}
+
+// NewEntropy reads bits of entropy from src. Bits must be one of 128, 160,
+// 192, 224 or 256, giving mnemonics of 12 to 24 words.
+func NewEntropy(src entropy.Source, bits int) ([]byte, error) {
+	if bits < 128 || bits > 256 || bits%32 != 0 {
+		return nil, ErrEntropyLength
+	}
+	ent := make([]byte, bits/8)
+	if _, err := io.ReadFull(src, ent); err != nil {
+		return nil, err
+	}
+	return ent, nil
+}
+
+// WordsToBits returns the entropy size of a mnemonic of the given length.
+func WordsToBits(words int) (int, error) {
+	if words < 12 || words > 24 || words%3 != 0 {
+		return 0, ErrWordCount
+	}
+	return words * 11 * 32 / 33, nil
+}
+
+// NewMnemonic encodes the entropy as a mnemonic sentence from wl.
+// The entropy is followed by len(ent)/4 bits of its SHA-256 as checksum and
+// the result is split into 11-bit word indexes.
+func NewMnemonic(ent []byte, wl *Wordlist) (string, error) {
+	bits := len(ent) * 8
+	if bits < 128 || bits > 256 || bits%32 != 0 {
+		return "", ErrEntropyLength
+	}
+	hash := sha256.Sum256(ent)
+	data := append(append([]byte(nil), ent...), hash[0])
+
+	count := (bits + bits/32) / 11
+	words := make([]string, count)
+	for i := 0; i < count; i++ {
+		words[i] = wl.Words[readBits(data, i*11, 11)]
+	}
+	return strings.Join(words, wl.Separator), nil
+}
+
+// MnemonicToEntropy validates a mnemonic against wl and returns its
+// entropy. It fails on unknown words, a wrong number of words or a bad
+// checksum.
+func MnemonicToEntropy(mnemonic string, wl *Wordlist) ([]byte, error) {
+	words := splitWords(mnemonic)
+	bits, err := WordsToBits(len(words))
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, (len(words)*11+7)/8)
+	for i, word := range words {
+		index, ok := wl.Index(word)
+		if !ok {
+			return nil, InvalidWordError{Word: word, Index: i}
+		}
+		writeBits(data, i*11, 11, index)
+	}
+
+	ent := data[:bits/8]
+	csBits := bits / 32
+	hash := sha256.Sum256(ent)
+	if hash[0]>>(8-uint(csBits)) != byte(readBits(data, bits, csBits)) {
+		return nil, ErrChecksum
+	}
+	return ent, nil
+}
+
+// Validate reports whether mnemonic is valid in wl.
+func Validate(mnemonic string, wl *Wordlist) error {
+	_, err := MnemonicToEntropy(mnemonic, wl)
+	return err
+}
+
+// DetectWordlist returns the wordlists in which every word of the mnemonic
+// is known. Simplified and traditional chinese share many words, so more
+// than one list may match.
+func DetectWordlist(mnemonic string) []*Wordlist {
+	words := splitWords(mnemonic)
+	var found []*Wordlist
+	for _, wl := range Wordlists {
+		known := len(words) > 0
+		for _, word := range words {
+			if _, ok := wl.Index(word); !ok {
+				known = false
+				break
+			}
+		}
+		if known {
+			found = append(found, wl)
+		}
+	}
+	return found
+}
+
+// NewSeed derives the 64-byte seed from a mnemonic and an optional
+// passphrase with PBKDF2-HMAC-SHA512, 2048 iterations and the salt
+// "mnemonic" + passphrase, both NFKD normalized.
+// It does not validate the mnemonic.
+func NewSeed(mnemonic string, passphrase string) []byte {
+	sentence := strings.Join(splitWords(mnemonic), " ")
+	salt := "mnemonic" + norm.NFKD.String(passphrase)
+	return pbkdf2.Key([]byte(norm.NFKD.String(sentence)), []byte(salt), 2048, 64, sha512.New)
+}
+
+// normalize returns the NFKD form used to compare words.
+func normalize(word string) string {
+	return norm.NFKD.String(word)
+}
+
+// splitWords splits on any white space, including the ideographic space
+// used by japanese mnemonics.
+func splitWords(mnemonic string) []string {
+	return strings.FieldsFunc(norm.NFKD.String(mnemonic), unicode.IsSpace)
+}
+
+// readBits returns n bits of data starting at bit offset.
+func readBits(data []byte, offset, n int) int {
+	v := 0
+	for i := 0; i < n; i++ {
+		bit := offset + i
+		v = v<<1 | int(data[bit/8]>>(7-uint(bit%8))&1)
+	}
+	return v
+}
+
+// writeBits stores the low n bits of v in data starting at bit offset.
+func writeBits(data []byte, offset, n int, v int) {
+	for i := 0; i < n; i++ {
+		bit := offset + i
+		if v>>(uint(n-1-i))&1 == 1 {
+			data[bit/8] |= 1 << (7 - uint(bit%8))
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package bip39
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/smallnest/bitcoin/wallet/bip39/wordlists"
+)
+
+// Wordlist is one of the 2048-word BIP39 lists.
+type Wordlist struct {
+	Name  string
+	Words []string
+	// Separator joins the words of a mnemonic. Japanese uses an ideographic
+	// space, every other language a plain space.
+	Separator string
+
+	index map[string]int
+}
+
+func newWordlist(name string, words []string, separator string) *Wordlist {
+	if len(words) != 2048 {
+		panic(fmt.Sprintf("bip39: %s wordlist has %d words", name, len(words)))
+	}
+	w := &Wordlist{Name: name, Words: words, Separator: separator, index: make(map[string]int, len(words))}
+	for i, word := range words {
+		w.index[normalize(word)] = i
+	}
+	return w
+}
+
+// The official wordlists.
+var (
+	English            = newWordlist("english", wordlists.English, " ")
+	Japanese           = newWordlist("japanese", wordlists.Japanese, "　")
+	Korean             = newWordlist("korean", wordlists.Korean, " ")
+	Spanish            = newWordlist("spanish", wordlists.Spanish, " ")
+	ChineseSimplified  = newWordlist("chinese_simplified", wordlists.ChineseSimplified, " ")
+	ChineseTraditional = newWordlist("chinese_traditional", wordlists.ChineseTraditional, " ")
+	French             = newWordlist("french", wordlists.French, " ")
+	Italian            = newWordlist("italian", wordlists.Italian, " ")
+	Czech              = newWordlist("czech", wordlists.Czech, " ")
+)
+
+// Wordlists lists every supported wordlist, English first.
+var Wordlists = []*Wordlist{
+	English, Japanese, Korean, Spanish, ChineseSimplified, ChineseTraditional, French, Italian, Czech,
+}
+
+// WordlistByName returns the wordlist with the given name, e.g. "english"
+// or "chinese_simplified".
+func WordlistByName(name string) (*Wordlist, error) {
+	for _, w := range Wordlists {
+		if strings.EqualFold(w.Name, name) {
+			return w, nil
+		}
+	}
+	return nil, fmt.Errorf("bip39: unknown wordlist %q", name)
+}
+
+// Index returns the position of word in the list.
+func (w *Wordlist) Index(word string) (int, bool) {
+	i, ok := w.index[normalize(word)]
+	return i, ok
+}
@@ -0,0 +1,2 @@
+// Package wordlists holds the official BIP39 wordlists, 2048 words each.
+package wordlists
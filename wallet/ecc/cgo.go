@@ -0,0 +1,124 @@
+//go:build secp256k1_cgo
+
+package ecc
+
+import (
+	"errors"
+	"math/big"
+
+	secp256k1 "github.com/toxeus/go-secp256k1"
+)
+
+// Default is the Curve used by the wallet programs: PureGo, or Cgo when
+// built with the secp256k1_cgo tag.
+var Default = Cgo
+
+// Cgo is the Curve backed by the C libsecp256k1 through
+// github.com/toxeus/go-secp256k1.
+//
+// The wrapper does not expose public key recovery or point addition.
+// RecoverCompact recovers in Go and lets the library verify the signature
+// with the recovered key, TweakPublicKey multiplies the tweak with the
+// library and adds the points in Go, and TweakPrivateKey is scalar
+// arithmetic only.
+var Cgo Curve = cgoCurve{}
+
+// The library keeps a global context, it is created once and never freed.
+func init() {
+	secp256k1.Start()
+}
+
+type cgoCurve struct{}
+
+func (cgoCurve) Name() string { return "cgo" }
+
+func (cgoCurve) PublicKey(privateKey []byte, compressed bool) ([]byte, error) {
+	if !ValidScalar(privateKey) {
+		return nil, ErrInvalidScalar
+	}
+	var key [32]byte
+	copy(key[:], privateKey)
+	pubKey, ok := secp256k1.Pubkey_create(key, compressed)
+	if !ok {
+		return nil, errors.New("ecc: libsecp256k1 failed to create the public key")
+	}
+	return pubKey, nil
+}
+
+func (cgoCurve) Sign(hash, privateKey, nonce []byte) ([]byte, error) {
+	if len(hash) != 32 {
+		return nil, ErrInvalidHash
+	}
+	if !ValidScalar(privateKey) || !ValidScalar(nonce) {
+		return nil, ErrInvalidScalar
+	}
+	var msg, key, k [32]byte
+	copy(msg[:], hash)
+	copy(key[:], privateKey)
+	copy(k[:], nonce)
+	sig, ok := secp256k1.Sign(msg, key, &k)
+	if !ok {
+		return nil, errors.New("ecc: libsecp256k1 failed to sign, use another nonce")
+	}
+	return sig, nil
+}
+
+func (cgoCurve) Verify(hash, signature, pubKey []byte) bool {
+	if len(hash) != 32 {
+		return false
+	}
+	var msg [32]byte
+	copy(msg[:], hash)
+	return secp256k1.Verify(msg, signature, pubKey)
+}
+
+func (cgoCurve) RecoverCompact(hash, signature []byte) ([]byte, bool, error) {
+	pubKey, compressed, err := RecoverCompact(hash, signature)
+	if err != nil {
+		return nil, false, err
+	}
+	r := new(big.Int).SetBytes(signature[1:33])
+	s := new(big.Int).SetBytes(signature[33:])
+	// Newer versions of the library reject a high s.
+	der, err := NormalizeSignature(encodeDER(r, s))
+	if err != nil {
+		return nil, false, err
+	}
+	if !Cgo.Verify(hash, der, pubKey) {
+		return nil, false, errors.New("ecc: libsecp256k1 rejects the recovered public key")
+	}
+	return pubKey, compressed, nil
+}
+
+func (cgoCurve) TweakPrivateKey(privateKey, tweak []byte) ([]byte, error) {
+	return TweakPrivateKey(privateKey, tweak)
+}
+
+func (cgoCurve) TweakPublicKey(pubKey, tweak []byte) ([]byte, error) {
+	p, err := ParsePoint(pubKey)
+	if err != nil {
+		return nil, err
+	}
+	if !validTweak(tweak) {
+		return nil, ErrInvalidScalar
+	}
+	if !ValidScalar(tweak) {
+		// A zero tweak leaves the key as it is.
+		return p.Serialize(len(pubKey) == 33), nil
+	}
+	var t [32]byte
+	copy(t[:], tweak)
+	tG, ok := secp256k1.Pubkey_create(t, false)
+	if !ok {
+		return nil, errors.New("ecc: libsecp256k1 failed to multiply the tweak")
+	}
+	tPoint, err := ParsePoint(tG)
+	if err != nil {
+		return nil, err
+	}
+	q := Add(p, tPoint)
+	if q.IsInfinity() {
+		return nil, ErrInvalidPoint
+	}
+	return q.Serialize(len(pubKey) == 33), nil
+}
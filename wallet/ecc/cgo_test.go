@@ -0,0 +1,102 @@
+//go:build secp256k1_cgo
+
+package ecc
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+)
+
+// TestCgo compares libsecp256k1 with the pure Go code on deterministic
+// keys: public keys, signatures with the same nonce, verification of each
+// other's signatures, public key recovery and key tweaks.
+func TestCgo(t *testing.T) {
+	for i := 0; i < 64; i++ {
+		seed := sha256.Sum256([]byte{byte(i)})
+		privateKey := seed[:]
+		if !ValidScalar(privateKey) {
+			continue
+		}
+		hash := sha256.Sum256(privateKey)
+		nonce := NonceRFC6979(privateKey, hash[:])
+		tweak := sha256.Sum256(hash[:])
+
+		for _, compressed := range []bool{true, false} {
+			pubCgo, err := Cgo.PublicKey(privateKey, compressed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pubGo, err := PureGo.PublicKey(privateKey, compressed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(pubCgo, pubGo) {
+				t.Fatalf("public keys differ: %x != %x", pubCgo, pubGo)
+			}
+		}
+		pubKey, _ := PureGo.PublicKey(privateKey, true)
+
+		sigCgo, err := Cgo.Sign(hash[:], privateKey, nonce)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sigGo, err := PureGo.Sign(hash[:], privateKey, nonce)
+		if err != nil {
+			t.Fatal(err)
+		}
+		normCgo, err := NormalizeSignature(sigCgo)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(normCgo, sigGo) {
+			t.Fatalf("signatures differ: %x != %x", sigCgo, sigGo)
+		}
+		if !Cgo.Verify(hash[:], sigGo, pubKey) || !PureGo.Verify(hash[:], sigCgo, pubKey) {
+			t.Fatal("signatures do not verify across backends")
+		}
+		other := sha256.Sum256(hash[:])
+		if Cgo.Verify(other[:], sigGo, pubKey) || PureGo.Verify(other[:], sigCgo, pubKey) {
+			t.Fatal("signature verifies for another hash")
+		}
+
+		compact, err := SignCompact(hash[:], privateKey, nonce, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, c := range []Curve{Cgo, PureGo} {
+			recovered, compressed, err := c.RecoverCompact(hash[:], compact)
+			if err != nil {
+				t.Fatalf("%s: %v", c.Name(), err)
+			}
+			if !compressed || !bytes.Equal(recovered, pubKey) {
+				t.Fatalf("%s recovered %x instead of %x", c.Name(), recovered, pubKey)
+			}
+		}
+
+		tweakedPrivate, err := Cgo.TweakPrivateKey(privateKey, tweak[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if goPrivate, _ := PureGo.TweakPrivateKey(privateKey, tweak[:]); !bytes.Equal(tweakedPrivate, goPrivate) {
+			t.Fatalf("tweaked private keys differ: %x != %x", tweakedPrivate, goPrivate)
+		}
+		expected, err := Cgo.PublicKey(tweakedPrivate, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, c := range []Curve{Cgo, PureGo} {
+			tweakedPublic, err := c.TweakPublicKey(pubKey, tweak[:])
+			if err != nil {
+				t.Fatalf("%s: %v", c.Name(), err)
+			}
+			if !bytes.Equal(tweakedPublic, expected) {
+				t.Fatalf("%s tweaked public key %x does not match libsecp256k1's %x", c.Name(), tweakedPublic, expected)
+			}
+		}
+		// A zero tweak keeps the key.
+		if same, err := Cgo.TweakPublicKey(pubKey, make([]byte, 32)); err != nil || !bytes.Equal(same, pubKey) {
+			t.Fatalf("zero tweak gives %x, %v", same, err)
+		}
+	}
+}
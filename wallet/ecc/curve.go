@@ -0,0 +1,57 @@
+package ecc
+
+// Curve creates public keys, signs and verifies with secp256k1.
+//
+// PureGo implements it with this package. Building with the secp256k1_cgo
+// tag adds Cgo, which wraps the C libsecp256k1 and is then the Default.
+type Curve interface {
+	// Name identifies the implementation.
+	Name() string
+	// PublicKey returns the SEC encoded public key of a 32-byte private key.
+	PublicKey(privateKey []byte, compressed bool) ([]byte, error)
+	// Sign signs a 32-byte hash with a private key and a secret nonce, and
+	// returns the DER encoded signature.
+	Sign(hash, privateKey, nonce []byte) ([]byte, error)
+	// Verify reports whether a DER signature of hash is valid for pubKey.
+	Verify(hash, signature, pubKey []byte) bool
+	// RecoverCompact returns the public key that made a 65-byte compact
+	// signature of hash, and whether the signature asks for it compressed.
+	RecoverCompact(hash, signature []byte) (pubKey []byte, compressed bool, err error)
+	// TweakPrivateKey returns (privateKey + tweak) mod N.
+	TweakPrivateKey(privateKey, tweak []byte) ([]byte, error)
+	// TweakPublicKey returns pubKey + tweak·G, encoded like pubKey.
+	TweakPublicKey(pubKey, tweak []byte) ([]byte, error)
+}
+
+// PureGo is the Curve implemented in Go by this package. It does not need
+// cgo, so it cross-compiles and links statically, but it is slower than the
+// C library and its arithmetic is not constant time.
+var PureGo Curve = pureGo{}
+
+type pureGo struct{}
+
+func (pureGo) Name() string { return "go" }
+
+func (pureGo) PublicKey(privateKey []byte, compressed bool) ([]byte, error) {
+	return PublicKey(privateKey, compressed)
+}
+
+func (pureGo) Sign(hash, privateKey, nonce []byte) ([]byte, error) {
+	return Sign(hash, privateKey, nonce)
+}
+
+func (pureGo) Verify(hash, signature, pubKey []byte) bool {
+	return Verify(hash, signature, pubKey)
+}
+
+func (pureGo) RecoverCompact(hash, signature []byte) ([]byte, bool, error) {
+	return RecoverCompact(hash, signature)
+}
+
+func (pureGo) TweakPrivateKey(privateKey, tweak []byte) ([]byte, error) {
+	return TweakPrivateKey(privateKey, tweak)
+}
+
+func (pureGo) TweakPublicKey(pubKey, tweak []byte) ([]byte, error) {
+	return TweakPublicKey(pubKey, tweak)
+}
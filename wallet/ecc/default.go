@@ -0,0 +1,7 @@
+//go:build !secp256k1_cgo
+
+package ecc
+
+// Default is the Curve used by the wallet programs: PureGo, or Cgo when
+// built with the secp256k1_cgo tag.
+var Default = PureGo
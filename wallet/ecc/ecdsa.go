@@ -0,0 +1,160 @@
+package ecc
+
+import (
+	"errors"
+	"math/big"
+)
+
+var (
+	// ErrInvalidSignature is returned for signatures that are not strict DER
+	// or compact encodings, or whose r and s are out of range.
+	ErrInvalidSignature = errors.New("ecc: invalid signature")
+	// ErrInvalidHash is returned for message hashes that are not 32 bytes.
+	ErrInvalidHash = errors.New("ecc: message hash must be 32 bytes")
+
+	halfN = new(big.Int).Rsh(N, 1)
+)
+
+// Sign signs a 32-byte hash with a private key and a secret nonce in
+// [1, N-1], and returns the DER encoded signature. s is normalized to the
+// lower half of the order as required by BIP62 and standard relay policy.
+func Sign(hash, privateKey, nonce []byte) ([]byte, error) {
+	r, s, _, err := sign(hash, privateKey, nonce)
+	if err != nil {
+		return nil, err
+	}
+	return encodeDER(r, s), nil
+}
+
+// sign returns r, s and the recovery id: the parity of R.y, plus 2 when
+// R.x overflowed N.
+func sign(hash, privateKey, nonce []byte) (r, s *big.Int, recID byte, err error) {
+	if len(hash) != 32 {
+		return nil, nil, 0, ErrInvalidHash
+	}
+	if !ValidScalar(privateKey) || !ValidScalar(nonce) {
+		return nil, nil, 0, ErrInvalidScalar
+	}
+
+	R := ScalarBaseMult(nonce)
+	r = new(big.Int).Mod(R.X, N)
+	if r.Sign() == 0 {
+		return nil, nil, 0, errors.New("ecc: nonce gives r = 0, use another nonce")
+	}
+	recID = byte(R.Y.Bit(0))
+	if R.X.Cmp(N) >= 0 {
+		recID |= 2
+	}
+
+	// s = k⁻¹ (e + r·d) mod N
+	k := new(big.Int).SetBytes(nonce)
+	s = new(big.Int).Mul(r, new(big.Int).SetBytes(privateKey))
+	s.Add(s, new(big.Int).SetBytes(hash))
+	s.Mul(s, k.ModInverse(k, N))
+	s.Mod(s, N)
+	if s.Sign() == 0 {
+		return nil, nil, 0, errors.New("ecc: nonce gives s = 0, use another nonce")
+	}
+	if s.Cmp(halfN) > 0 {
+		// -s signs the same with R negated, which flips the parity of R.y.
+		s.Sub(N, s)
+		recID ^= 1
+	}
+	return r, s, recID, nil
+}
+
+// Verify reports whether a DER signature of the 32-byte hash is valid for
+// the SEC encoded public key. Both low and high s values are accepted.
+func Verify(hash, signature, pubKey []byte) bool {
+	if len(hash) != 32 {
+		return false
+	}
+	r, s, err := ParseDER(signature)
+	if err != nil {
+		return false
+	}
+	q, err := ParsePoint(pubKey)
+	if err != nil {
+		return false
+	}
+	return verify(hash, r, s, q)
+}
+
+func verify(hash []byte, r, s *big.Int, q *Point) bool {
+	if r.Sign() <= 0 || r.Cmp(N) >= 0 || s.Sign() <= 0 || s.Cmp(N) >= 0 {
+		return false
+	}
+	// R = (e·s⁻¹)·G + (r·s⁻¹)·Q
+	w := new(big.Int).ModInverse(s, N)
+	u1 := new(big.Int).Mul(new(big.Int).SetBytes(hash), w)
+	u1.Mod(u1, N)
+	u2 := new(big.Int).Mul(r, w)
+	u2.Mod(u2, N)
+
+	R := Add(ScalarBaseMult(u1.Bytes()), ScalarMult(q, u2.Bytes()))
+	if R.IsInfinity() {
+		return false
+	}
+	return new(big.Int).Mod(R.X, N).Cmp(r) == 0
+}
+
+// NormalizeSignature returns the DER signature with a low s, so signatures
+// of implementations that do not normalize can be compared.
+func NormalizeSignature(signature []byte) ([]byte, error) {
+	r, s, err := ParseDER(signature)
+	if err != nil {
+		return nil, err
+	}
+	if s.Cmp(halfN) > 0 {
+		s.Sub(N, s)
+	}
+	return encodeDER(r, s), nil
+}
+
+// encodeDER encodes r and s as a DER sequence of two integers.
+func encodeDER(r, s *big.Int) []byte {
+	rb, sb := derInt(r), derInt(s)
+	out := []byte{0x30, byte(4 + len(rb) + len(sb)), 0x02, byte(len(rb))}
+	out = append(out, rb...)
+	out = append(out, 0x02, byte(len(sb)))
+	return append(out, sb...)
+}
+
+// derInt returns the minimal big-endian encoding of a positive integer,
+// with a leading zero if the high bit is set.
+func derInt(n *big.Int) []byte {
+	b := n.Bytes()
+	if len(b) == 0 || b[0]&0x80 != 0 {
+		b = append([]byte{0}, b...)
+	}
+	return b
+}
+
+// ParseDER decodes a strict DER signature, as defined by BIP66.
+func ParseDER(sig []byte) (r, s *big.Int, err error) {
+	if len(sig) < 8 || len(sig) > 72 || sig[0] != 0x30 || int(sig[1]) != len(sig)-2 {
+		return nil, nil, ErrInvalidSignature
+	}
+	rest := sig[2:]
+	ints := make([]*big.Int, 2)
+	for i := range ints {
+		if len(rest) < 2 || rest[0] != 0x02 {
+			return nil, nil, ErrInvalidSignature
+		}
+		n := int(rest[1])
+		if n == 0 || len(rest) < 2+n {
+			return nil, nil, ErrInvalidSignature
+		}
+		b := rest[2 : 2+n]
+		// negative, or not minimally encoded
+		if b[0]&0x80 != 0 || (n > 1 && b[0] == 0 && b[1]&0x80 == 0) {
+			return nil, nil, ErrInvalidSignature
+		}
+		ints[i] = new(big.Int).SetBytes(b)
+		rest = rest[2+n:]
+	}
+	if len(rest) != 0 {
+		return nil, nil, ErrInvalidSignature
+	}
+	return ints[0], ints[1], nil
+}
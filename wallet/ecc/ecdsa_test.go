@@ -0,0 +1,142 @@
+package ecc
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+// rfc6979Vectors are deterministic secp256k1 signatures of sha256(msg),
+// as used by bitcoinjs, python-ecdsa and Trezor.
+var rfc6979Vectors = []struct {
+	privateKey, msg, nonce, r, s string
+}{
+	{
+		"0000000000000000000000000000000000000000000000000000000000000001",
+		"Satoshi Nakamoto",
+		"8f8a276c19f4149656b280621e358cce24f5f52542772691ee69063b74f15d15",
+		"934b1ea10a4b3c1757e2b0c017d0b6143ce3c9a7e6a4a49860d7a6ab210ee3d8",
+		"2442ce9d2b916064108014783e923ec36b49743e2ffa1c4496f01a512aafd9e5",
+	},
+	{
+		"0000000000000000000000000000000000000000000000000000000000000001",
+		"All those moments will be lost in time, like tears in rain. Time to die...",
+		"38aa22d72376b4dbc472e06c3ba403ee0a394da63fc58d88686c611aba98d6b3",
+		"8600dbd41e348fe5c9465ab92d23e3db8b98b873beecd930736488696438cb6b",
+		"547fe64427496db33bf66019dacbf0039c04199abb0122918601db38a72cfc21",
+	},
+	{
+		"f8b8af8ce3c7cca5e300d33939540c10d45ce001b8f252bfbc57ba0342904181",
+		"Alan Turing",
+		"525a82b70e67874398067543fd84c83d30c175fdc45fdeee082fe13b1d7cfdf1",
+		"7063ae83e7f62bbb171798131b4a0564b956930092b33b07b395615d9ec7e15c",
+		"58dfcc1e00a35e1572f366ffe34ba0fc47db1e7189759b9fb233c5b05ab388ea",
+	},
+	{
+		"e91671c46231f833a6406ccbea0e3e392c76c167bac1cb013f6f1013980455c2",
+		"There is a computer disease that anybody who works with computers knows about. It's a very serious disease and it interferes completely with the work. The trouble with computers is that you 'play' with them!",
+		"1f4b84c23a86a221d233f2521be018d9318639d5b8bbd6374a8a59232d16ad3d",
+		"b552edd27580141f3b2a5463048cb7cd3e047b97c9f98076c32dbdf85a68718b",
+		"279fa72dd19bfae05577e06c7c0c1900c371fcd5893f7e1d56a37d30174671f6",
+	},
+}
+
+func TestRFC6979(t *testing.T) {
+	for _, v := range rfc6979Vectors {
+		privateKey := decodeHex(t, v.privateKey)
+		hash := sha256.Sum256([]byte(v.msg))
+
+		nonce := NonceRFC6979(privateKey, hash[:])
+		if got := hex.EncodeToString(nonce); got != v.nonce {
+			t.Errorf("%q: nonce %s, want %s", v.msg, got, v.nonce)
+			continue
+		}
+		sig, err := Sign(hash[:], privateKey, nonce)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, s, err := ParseDER(sig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(r.FillBytes(make([]byte, 32))); got != v.r {
+			t.Errorf("%q: r %s, want %s", v.msg, got, v.r)
+		}
+		if got := hex.EncodeToString(s.FillBytes(make([]byte, 32))); got != v.s {
+			t.Errorf("%q: s %s, want %s", v.msg, got, v.s)
+		}
+
+		pubKey, _ := PublicKey(privateKey, true)
+		if !Verify(hash[:], sig, pubKey) {
+			t.Errorf("%q: signature does not verify", v.msg)
+		}
+	}
+}
+
+// TestLowS checks that Sign always returns a low s, that the high s of the
+// same signature verifies too and that NormalizeSignature maps it back.
+func TestLowS(t *testing.T) {
+	privateKey := decodeHex(t, "b7e151628aed2a6abf7158809cf4f3c762e7160f38b4da56a784d9045190cfef")
+	pubKey, _ := PublicKey(privateKey, true)
+	for i := 0; i < 32; i++ {
+		hash := sha256.Sum256([]byte{byte(i)})
+		sig, err := Sign(hash[:], privateKey, NonceRFC6979(privateKey, hash[:]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, s, err := ParseDER(sig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.Cmp(halfN) > 0 {
+			t.Fatalf("signature %x has a high s", sig)
+		}
+		if !Verify(hash[:], sig, pubKey) {
+			t.Fatalf("signature %x does not verify", sig)
+		}
+
+		// The high s signature with the same r.
+		high := encodeDER(r, s.Sub(N, s))
+		if !Verify(hash[:], high, pubKey) {
+			t.Fatalf("high s signature %x does not verify", high)
+		}
+		norm, err := NormalizeSignature(high)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(norm, sig) {
+			t.Fatalf("NormalizeSignature(%x) = %x, want %x", high, norm, sig)
+		}
+	}
+}
+
+func TestParseDERStrict(t *testing.T) {
+	privateKey := decodeHex(t, "0000000000000000000000000000000000000000000000000000000000000001")
+	hash := sha256.Sum256([]byte("Satoshi Nakamoto"))
+	sig, err := Sign(hash[:], privateKey, NonceRFC6979(privateKey, hash[:]))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, mutate := range map[string]func([]byte) []byte{
+		"wrong length":   func(b []byte) []byte { b[1]++; return b },
+		"not a sequence": func(b []byte) []byte { b[0] = 0x31; return b },
+		"trailing byte":  func(b []byte) []byte { return append(b, 0x01) },
+		"truncated":      func(b []byte) []byte { return b[:len(b)-1] },
+	} {
+		bad := mutate(append([]byte(nil), sig...))
+		if _, _, err := ParseDER(bad); err == nil {
+			t.Errorf("%s: ParseDER(%x) accepted", name, bad)
+		}
+	}
+}
+
+func decodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
@@ -0,0 +1,127 @@
+package ecc
+
+import "math/big"
+
+// jacobian is a point (X/Z², Y/Z³). Z = 0 is the point at infinity.
+type jacobian struct {
+	x, y, z *big.Int
+}
+
+func toJacobian(p *Point) *jacobian {
+	if p.IsInfinity() {
+		return &jacobian{new(big.Int), new(big.Int), new(big.Int)}
+	}
+	return &jacobian{new(big.Int).Set(p.X), new(big.Int).Set(p.Y), big.NewInt(1)}
+}
+
+func (j *jacobian) affine() *Point {
+	if j.z.Sign() == 0 {
+		return &Point{}
+	}
+	zinv := new(big.Int).ModInverse(j.z, P)
+	zinv2 := new(big.Int).Mul(zinv, zinv)
+	x := new(big.Int).Mul(j.x, zinv2)
+	x.Mod(x, P)
+	zinv2.Mul(zinv2, zinv)
+	y := new(big.Int).Mul(j.y, zinv2)
+	y.Mod(y, P)
+	return &Point{X: x, Y: y}
+}
+
+// double returns 2j, using the a = 0 doubling formulas.
+func (j *jacobian) double() *jacobian {
+	if j.z.Sign() == 0 || j.y.Sign() == 0 {
+		return &jacobian{new(big.Int), new(big.Int), new(big.Int)}
+	}
+	a := new(big.Int).Mul(j.x, j.x) // X²
+	a.Mod(a, P)
+	b := new(big.Int).Mul(j.y, j.y) // Y²
+	b.Mod(b, P)
+	c := new(big.Int).Mul(b, b) // Y⁴
+	c.Mod(c, P)
+
+	d := new(big.Int).Add(j.x, b) // 2((X+B)²-A-C)
+	d.Mul(d, d)
+	d.Sub(d, a)
+	d.Sub(d, c)
+	d.Lsh(d, 1)
+	d.Mod(d, P)
+
+	e := new(big.Int).Lsh(a, 1) // 3A
+	e.Add(e, a)
+	f := new(big.Int).Mul(e, e)
+
+	x := new(big.Int).Sub(f, new(big.Int).Lsh(d, 1))
+	x.Mod(x, P)
+
+	y := new(big.Int).Sub(d, x)
+	y.Mul(y, e)
+	y.Sub(y, new(big.Int).Lsh(c, 3))
+	y.Mod(y, P)
+
+	z := new(big.Int).Mul(j.y, j.z)
+	z.Lsh(z, 1)
+	z.Mod(z, P)
+	return &jacobian{x, y, z}
+}
+
+// add returns j + k.
+func (j *jacobian) add(k *jacobian) *jacobian {
+	if j.z.Sign() == 0 {
+		return k
+	}
+	if k.z.Sign() == 0 {
+		return j
+	}
+
+	z1z1 := new(big.Int).Mul(j.z, j.z)
+	z1z1.Mod(z1z1, P)
+	z2z2 := new(big.Int).Mul(k.z, k.z)
+	z2z2.Mod(z2z2, P)
+
+	u1 := new(big.Int).Mul(j.x, z2z2)
+	u1.Mod(u1, P)
+	u2 := new(big.Int).Mul(k.x, z1z1)
+	u2.Mod(u2, P)
+
+	s1 := new(big.Int).Mul(j.y, k.z)
+	s1.Mul(s1, z2z2)
+	s1.Mod(s1, P)
+	s2 := new(big.Int).Mul(k.y, j.z)
+	s2.Mul(s2, z1z1)
+	s2.Mod(s2, P)
+
+	if u1.Cmp(u2) == 0 {
+		if s1.Cmp(s2) == 0 {
+			return j.double()
+		}
+		return &jacobian{new(big.Int), new(big.Int), new(big.Int)}
+	}
+
+	h := new(big.Int).Sub(u2, u1)
+	h.Mod(h, P)
+	r := new(big.Int).Sub(s2, s1)
+	r.Mod(r, P)
+
+	h2 := new(big.Int).Mul(h, h)
+	h2.Mod(h2, P)
+	h3 := new(big.Int).Mul(h2, h)
+	h3.Mod(h3, P)
+	u1h2 := new(big.Int).Mul(u1, h2)
+	u1h2.Mod(u1h2, P)
+
+	x := new(big.Int).Mul(r, r)
+	x.Sub(x, h3)
+	x.Sub(x, new(big.Int).Lsh(u1h2, 1))
+	x.Mod(x, P)
+
+	y := new(big.Int).Sub(u1h2, x)
+	y.Mul(y, r)
+	y.Sub(y, new(big.Int).Mul(s1, h3))
+	y.Mod(y, P)
+
+	z := new(big.Int).Mul(j.z, k.z)
+	z.Mul(z, h)
+	z.Mod(z, P)
+	return &jacobian{x, y, z}
+}
@@ -0,0 +1,143 @@
+// Package ecc implements the secp256k1 elliptic curve arithmetic used by
+// bitcoin keys, in pure Go: point and scalar operations, key tweaks, and
+// ECDSA signatures with public key recovery.
+//
+// secp256k1 is y² = x³ + 7 over the prime field P. Go's crypto/elliptic only
+// handles curves with a = -3, so the group law is implemented here with
+// Jacobian coordinates.
+//
+// The Curve interface selects between this implementation and the cgo
+// wrapper of libsecp256k1, see Default.
+package ecc
+
+import (
+	"errors"
+	"math/big"
+)
+
+var (
+	// P is the prime of the underlying field.
+	P, _ = new(big.Int).SetString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEFFFFFC2F", 16)
+	// N is the order of the group, private keys are in [1, N-1].
+	N, _ = new(big.Int).SetString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141", 16)
+	// G is the generator point.
+	G = &Point{
+		X: fromHex("79BE667EF9DCBBAC55A06295CE870B07029BFCDB2DCE28D959F2815B16F81798"),
+		Y: fromHex("483ADA7726A3C4655DA4FBFC0E1108A8FD17B448A68554199C47D08FFB10D4B8"),
+	}
+
+	seven = big.NewInt(7)
+)
+
+func fromHex(s string) *big.Int {
+	n, _ := new(big.Int).SetString(s, 16)
+	return n
+}
+
+// ErrInvalidPoint is returned when parsing bytes that are not the encoding
+// of a point on the curve.
+var ErrInvalidPoint = errors.New("ecc: invalid point")
+
+// Point is a point of the curve in affine coordinates. The point at
+// infinity has nil coordinates.
+type Point struct {
+	X, Y *big.Int
+}
+
+// IsInfinity reports whether p is the point at infinity.
+func (p *Point) IsInfinity() bool {
+	return p == nil || p.X == nil
+}
+
+// Equal reports whether p and q are the same point.
+func (p *Point) Equal(q *Point) bool {
+	if p.IsInfinity() || q.IsInfinity() {
+		return p.IsInfinity() == q.IsInfinity()
+	}
+	return p.X.Cmp(q.X) == 0 && p.Y.Cmp(q.Y) == 0
+}
+
+// IsOnCurve reports whether p satisfies y² = x³ + 7.
+func (p *Point) IsOnCurve() bool {
+	if p.IsInfinity() {
+		return false
+	}
+	if p.X.Sign() < 0 || p.X.Cmp(P) >= 0 || p.Y.Sign() < 0 || p.Y.Cmp(P) >= 0 {
+		return false
+	}
+	y2 := new(big.Int).Mul(p.Y, p.Y)
+	y2.Mod(y2, P)
+	return y2.Cmp(curveRHS(p.X)) == 0
+}
+
+// curveRHS returns x³ + 7 mod P.
+func curveRHS(x *big.Int) *big.Int {
+	r := new(big.Int).Mul(x, x)
+	r.Mul(r, x)
+	r.Add(r, seven)
+	return r.Mod(r, P)
+}
+
+// Neg returns -p.
+func (p *Point) Neg() *Point {
+	if p.IsInfinity() {
+		return &Point{}
+	}
+	return &Point{X: new(big.Int).Set(p.X), Y: new(big.Int).Sub(P, p.Y)}
+}
+
+// Serialize encodes p in SEC format: 33 bytes (0x02/0x03 and X) when
+// compressed, 65 bytes (0x04, X and Y) otherwise.
+func (p *Point) Serialize(compressed bool) []byte {
+	if compressed {
+		b := make([]byte, 33)
+		b[0] = 0x02 + byte(p.Y.Bit(0))
+		p.X.FillBytes(b[1:])
+		return b
+	}
+	b := make([]byte, 65)
+	b[0] = 0x04
+	p.X.FillBytes(b[1:33])
+	p.Y.FillBytes(b[33:])
+	return b
+}
+
+// ParsePoint decodes a compressed or uncompressed SEC encoded point and
+// checks that it is on the curve.
+func ParsePoint(b []byte) (*Point, error) {
+	switch {
+	case len(b) == 33 && (b[0] == 0x02 || b[0] == 0x03):
+		x := new(big.Int).SetBytes(b[1:])
+		if x.Cmp(P) >= 0 {
+			return nil, ErrInvalidPoint
+		}
+		y, ok := liftX(x, b[0] == 0x03)
+		if !ok {
+			return nil, ErrInvalidPoint
+		}
+		return &Point{X: x, Y: y}, nil
+	case len(b) == 65 && b[0] == 0x04:
+		p := &Point{X: new(big.Int).SetBytes(b[1:33]), Y: new(big.Int).SetBytes(b[33:])}
+		if !p.IsOnCurve() {
+			return nil, ErrInvalidPoint
+		}
+		return p, nil
+	}
+	return nil, ErrInvalidPoint
+}
+
+// liftX returns the y coordinate of the point with the given x and parity.
+func liftX(x *big.Int, odd bool) (*big.Int, bool) {
+	// P ≡ 3 mod 4, so the square root is c^((P+1)/4).
+	c := curveRHS(x)
+	y := new(big.Int).Exp(c, sqrtExp, P)
+	if new(big.Int).Mod(new(big.Int).Mul(y, y), P).Cmp(c) != 0 {
+		return nil, false
+	}
+	if (y.Bit(0) == 1) != odd {
+		y.Sub(P, y)
+	}
+	return y, true
+}
+
+var sqrtExp = new(big.Int).Rsh(new(big.Int).Add(P, big.NewInt(1)), 2)
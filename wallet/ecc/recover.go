@@ -0,0 +1,86 @@
+package ecc
+
+import (
+	"errors"
+	"math/big"
+)
+
+// compactHeader is the first byte of a compact signature for recovery id
+// 0 and an uncompressed public key. The recovery id is added to it, and 4
+// more for a compressed public key.
+const compactHeader = 27
+
+// SignCompact signs a 32-byte hash and returns the 65-byte recoverable
+// signature used by signed messages: a header byte, then r and s as 32
+// bytes each. compressed tells which encoding of the public key RecoverCompact
+// returns.
+func SignCompact(hash, privateKey, nonce []byte, compressed bool) ([]byte, error) {
+	r, s, recID, err := sign(hash, privateKey, nonce)
+	if err != nil {
+		return nil, err
+	}
+	sig := make([]byte, 65)
+	sig[0] = compactHeader + recID
+	if compressed {
+		sig[0] += 4
+	}
+	r.FillBytes(sig[1:33])
+	s.FillBytes(sig[33:])
+	return sig, nil
+}
+
+// RecoverCompact returns the public key whose private key made a compact
+// signature of hash, encoded as the header of the signature says.
+func RecoverCompact(hash, signature []byte) (pubKey []byte, compressed bool, err error) {
+	if len(hash) != 32 {
+		return nil, false, ErrInvalidHash
+	}
+	if len(signature) != 65 || signature[0] < compactHeader || signature[0] >= compactHeader+8 {
+		return nil, false, ErrInvalidSignature
+	}
+	recID := signature[0] - compactHeader
+	compressed = recID&4 != 0
+	recID &= 3
+
+	r := new(big.Int).SetBytes(signature[1:33])
+	s := new(big.Int).SetBytes(signature[33:])
+	q, err := RecoverPublicKey(hash, r, s, recID)
+	if err != nil {
+		return nil, false, err
+	}
+	return q.Serialize(compressed), compressed, nil
+}
+
+// RecoverPublicKey returns the public key Q for which (r, s) is a valid
+// signature of hash, given the recovery id of the signature:
+// Q = r⁻¹ (s·R - e·G), where R is the nonce point.
+func RecoverPublicKey(hash []byte, r, s *big.Int, recID byte) (*Point, error) {
+	if r.Sign() <= 0 || r.Cmp(N) >= 0 || s.Sign() <= 0 || s.Cmp(N) >= 0 || recID > 3 {
+		return nil, ErrInvalidSignature
+	}
+	x := new(big.Int).Set(r)
+	if recID&2 != 0 {
+		x.Add(x, N)
+		if x.Cmp(P) >= 0 {
+			return nil, ErrInvalidSignature
+		}
+	}
+	y, ok := liftX(x, recID&1 == 1)
+	if !ok {
+		return nil, ErrInvalidSignature
+	}
+	R := &Point{X: x, Y: y}
+
+	rInv := new(big.Int).ModInverse(r, N)
+	u1 := new(big.Int).Neg(new(big.Int).SetBytes(hash))
+	u1.Mul(u1, rInv)
+	u1.Mod(u1, N)
+	u2 := new(big.Int).Mul(s, rInv)
+	u2.Mod(u2, N)
+
+	q := Add(ScalarBaseMult(u1.Bytes()), ScalarMult(R, u2.Bytes()))
+	if q.IsInfinity() {
+		return nil, errors.New("ecc: recovered the point at infinity")
+	}
+	return q, nil
+}
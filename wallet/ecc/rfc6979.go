@@ -0,0 +1,53 @@
+package ecc
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"math/big"
+)
+
+// NonceRFC6979 derives the signing nonce deterministically from the private
+// key and the 32-byte hash, as specified by RFC 6979 with HMAC-SHA256. It is
+// what libsecp256k1 and Bitcoin Core use, so signatures are reproducible
+// and never depend on the quality of a random source.
+func NonceRFC6979(privateKey, hash []byte) []byte {
+	return NonceRFC6979Extra(privateKey, hash, nil)
+}
+
+// NonceRFC6979Extra is NonceRFC6979 with additional data mixed into the
+// derivation, as in section 3.6 of RFC 6979. Bitcoin Core passes a counter
+// there to grind for signatures with a low r.
+func NonceRFC6979Extra(privateKey, hash, extra []byte) []byte {
+	h := new(big.Int).SetBytes(hash)
+	h.Mod(h, N)
+	x := make([]byte, 32)
+	copy(x[32-len(privateKey):], privateKey)
+	h1 := h.FillBytes(make([]byte, 32))
+
+	v := make([]byte, 32)
+	k := make([]byte, 32)
+	for i := range v {
+		v[i] = 0x01
+	}
+
+	mac := func(key []byte, data ...[]byte) []byte {
+		m := hmac.New(sha256.New, key)
+		for _, d := range data {
+			m.Write(d)
+		}
+		return m.Sum(nil)
+	}
+
+	k = mac(k, v, []byte{0x00}, x, h1, extra)
+	v = mac(k, v)
+	k = mac(k, v, []byte{0x01}, x, h1, extra)
+	v = mac(k, v)
+	for {
+		v = mac(k, v)
+		if ValidScalar(v) {
+			return v
+		}
+		k = mac(k, v, []byte{0x00})
+		v = mac(k, v)
+	}
+}
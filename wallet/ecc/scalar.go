@@ -0,0 +1,67 @@
+package ecc
+
+import (
+	"errors"
+	"math/big"
+)
+
+// ErrInvalidScalar is returned for private keys and tweaks outside [1, N-1].
+var ErrInvalidScalar = errors.New("ecc: scalar out of range")
+
+// Add returns p + q.
+func Add(p, q *Point) *Point {
+	return toJacobian(p).add(toJacobian(q)).affine()
+}
+
+// ScalarMult returns k·p for a big-endian scalar k.
+func ScalarMult(p *Point, k []byte) *Point {
+	acc := toJacobian(&Point{})
+	base := toJacobian(p)
+	for _, b := range k {
+		for bit := 7; bit >= 0; bit-- {
+			acc = acc.double()
+			if b>>uint(bit)&1 == 1 {
+				acc = acc.add(base)
+			}
+		}
+	}
+	return acc.affine()
+}
+
+// ScalarBaseMult returns k·G.
+func ScalarBaseMult(k []byte) *Point {
+	return ScalarMult(G, k)
+}
+
+// PublicKey returns the SEC encoded public key of a 32-byte private key.
+func PublicKey(privateKey []byte, compressed bool) ([]byte, error) {
+	if !ValidScalar(privateKey) {
+		return nil, ErrInvalidScalar
+	}
+	return ScalarBaseMult(privateKey).Serialize(compressed), nil
+}
+
+// ValidScalar reports whether k is a 32-byte big-endian number in [1, N-1].
+func ValidScalar(k []byte) bool {
+	if len(k) != 32 {
+		return false
+	}
+	n := new(big.Int).SetBytes(k)
+	return n.Sign() > 0 && n.Cmp(N) < 0
+}
+
+// AddScalars returns (a + b) mod N as 32 bytes.
+func AddScalars(a, b []byte) []byte {
+	n := new(big.Int).SetBytes(a)
+	n.Add(n, new(big.Int).SetBytes(b))
+	n.Mod(n, N)
+	return n.FillBytes(make([]byte, 32))
+}
+
+// MulScalars returns (a · b) mod N as 32 bytes.
+func MulScalars(a, b []byte) []byte {
+	n := new(big.Int).SetBytes(a)
+	n.Mul(n, new(big.Int).SetBytes(b))
+	n.Mod(n, N)
+	return n.FillBytes(make([]byte, 32))
+}
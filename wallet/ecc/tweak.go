@@ -0,0 +1,46 @@
+package ecc
+
+// TweakPrivateKey returns (privateKey + tweak) mod N.
+func TweakPrivateKey(privateKey, tweak []byte) ([]byte, error) {
+	if !ValidScalar(privateKey) || !validTweak(tweak) {
+		return nil, ErrInvalidScalar
+	}
+	k := AddScalars(privateKey, tweak)
+	if !ValidScalar(k) {
+		return nil, ErrInvalidScalar
+	}
+	return k, nil
+}
+
+// TweakPublicKey returns P + tweak·G, encoded like pubKey.
+// It is the public key of TweakPrivateKey(privateKey, tweak).
+func TweakPublicKey(pubKey, tweak []byte) ([]byte, error) {
+	p, err := ParsePoint(pubKey)
+	if err != nil {
+		return nil, err
+	}
+	if !validTweak(tweak) {
+		return nil, ErrInvalidScalar
+	}
+	q := Add(p, ScalarBaseMult(tweak))
+	if q.IsInfinity() {
+		return nil, ErrInvalidPoint
+	}
+	return q.Serialize(len(pubKey) == 33), nil
+}
+
+// validTweak reports whether tweak is a 32-byte number below N.
+func validTweak(tweak []byte) bool {
+	if ValidScalar(tweak) {
+		return true
+	}
+	if len(tweak) != 32 {
+		return false
+	}
+	for _, c := range tweak {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,143 @@
+// Package entropy provides the randomness used for private keys and
+// signature nonces.
+//
+// Keys must come from a cryptographically secure source: Default reads from
+// crypto/rand. User supplied entropy such as dice rolls or coin flips can be
+// mixed in with Mix, and NewDeterministic returns a repeatable source for
+// tests.
+package entropy
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// Source is a source of random bytes.
+type Source interface {
+	// Read fills p entirely or returns an error.
+	Read(p []byte) (n int, err error)
+}
+
+// Default is the operating system's cryptographically secure random number
+// generator.
+var Default Source = rand.Reader
+
+// curveOrder is the order n of the secp256k1 group. Private keys and nonces
+// must be in [1, n-1].
+var curveOrder = []byte{
+	0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF,
+	0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFE,
+	0xBA, 0xAE, 0xDC, 0xE6, 0xAF, 0x48, 0xA0, 0x3B,
+	0xBF, 0xD2, 0x5E, 0x8C, 0xD0, 0x36, 0x41, 0x41,
+}
+
+// maxAttempts bounds the rejection sampling in Scalar. A value is rejected
+// with probability below 2^-127, so hitting the bound means the source is
+// broken.
+const maxAttempts = 16
+
+// ErrBrokenSource is returned when a source keeps producing values outside
+// the valid range.
+var ErrBrokenSource = errors.New("entropy: source keeps producing invalid scalars")
+
+// ValidScalar reports whether b is a valid secp256k1 private key, i.e. a
+// 32-byte big-endian number in [1, n-1].
+func ValidScalar(b []byte) bool {
+	if len(b) != 32 {
+		return false
+	}
+	if bytes.Equal(b, make([]byte, 32)) {
+		return false
+	}
+	return bytes.Compare(b, curveOrder) < 0
+}
+
+// Scalar reads 32 bytes from src until they form a valid secp256k1 scalar.
+// It is used for both private keys and signature nonces.
+func Scalar(src Source) ([32]byte, error) {
+	var k [32]byte
+	for i := 0; i < maxAttempts; i++ {
+		if _, err := io.ReadFull(src, k[:]); err != nil {
+			return k, err
+		}
+		if ValidScalar(k[:]) {
+			return k, nil
+		}
+	}
+	return [32]byte{}, ErrBrokenSource
+}
+
+// Mix returns a source whose output depends on both src and extra.
+// Each 32-byte block is HMAC-SHA256(SHA256(extra), block from src || counter),
+// so the result is at least as unpredictable as the stronger of the two.
+func Mix(src Source, extra []byte) Source {
+	key := sha256.Sum256(extra)
+	return &mixer{src: src, key: key[:]}
+}
+
+type mixer struct {
+	src     Source
+	key     []byte
+	counter uint64
+	buf     []byte
+}
+
+func (m *mixer) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if len(m.buf) == 0 {
+			var block [32 + 8]byte
+			if _, err := io.ReadFull(m.src, block[:32]); err != nil {
+				return n, err
+			}
+			binary.BigEndian.PutUint64(block[32:], m.counter)
+			m.counter++
+
+			mac := hmac.New(sha256.New, m.key)
+			mac.Write(block[:])
+			m.buf = mac.Sum(nil)
+		}
+		c := copy(p[n:], m.buf)
+		m.buf = m.buf[c:]
+		n += c
+	}
+	return n, nil
+}
+
+// NewDeterministic returns a source that always produces the same stream
+// for the same seed: SHA256(seed || counter) for each 32-byte block.
+// It must only be used in tests.
+func NewDeterministic(seed []byte) Source {
+	return &deterministic{seed: append([]byte(nil), seed...)}
+}
+
+type deterministic struct {
+	seed    []byte
+	counter uint64
+	buf     []byte
+}
+
+func (d *deterministic) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if len(d.buf) == 0 {
+			var c [8]byte
+			binary.BigEndian.PutUint64(c[:], d.counter)
+			d.counter++
+
+			h := sha256.New()
+			h.Write(d.seed)
+			h.Write(c[:])
+			d.buf = h.Sum(nil)
+		}
+		c := copy(p[n:], d.buf)
+		d.buf = d.buf[c:]
+		n += c
+	}
+	return n, nil
+}
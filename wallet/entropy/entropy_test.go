@@ -0,0 +1,186 @@
+package entropy
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"math"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestValidScalar(t *testing.T) {
+	one := make([]byte, 32)
+	one[31] = 1
+	orderMinus1 := append([]byte(nil), curveOrder...)
+	orderMinus1[31]--
+
+	for _, v := range []struct {
+		name string
+		b    []byte
+		want bool
+	}{
+		{"zero", make([]byte, 32), false},
+		{"one", one, true},
+		{"n-1", orderMinus1, true},
+		{"n", curveOrder, false},
+		{"all ones", bytes.Repeat([]byte{0xff}, 32), false},
+		{"31 bytes", one[1:], false},
+		{"33 bytes", append([]byte{0}, one...), false},
+	} {
+		if got := ValidScalar(v.b); got != v.want {
+			t.Errorf("%s: %v, want %v", v.name, got, v.want)
+		}
+	}
+}
+
+func TestScalar(t *testing.T) {
+	valid := bytes.Repeat([]byte{0x42}, 32)
+	// Zero and the group order are skipped.
+	src := io.MultiReader(bytes.NewReader(make([]byte, 32)), bytes.NewReader(curveOrder), bytes.NewReader(valid))
+	k, err := Scalar(src)
+	if err != nil || !bytes.Equal(k[:], valid) {
+		t.Errorf("got %x, %v, want %x", k, err, valid)
+	}
+
+	if _, err := Scalar(bytes.NewReader(make([]byte, 32*maxAttempts))); err != ErrBrokenSource {
+		t.Errorf("zero source: %v, want ErrBrokenSource", err)
+	}
+	if _, err := Scalar(bytes.NewReader(valid[:20])); err != io.ErrUnexpectedEOF {
+		t.Errorf("short source: %v, want io.ErrUnexpectedEOF", err)
+	}
+}
+
+func TestDeterministic(t *testing.T) {
+	a := make([]byte, 100)
+	b := make([]byte, 100)
+	NewDeterministic([]byte("seed")).Read(a)
+	// Reading in pieces gives the same stream.
+	if _, err := io.ReadFull(iotest.HalfReader(NewDeterministic([]byte("seed"))), b); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(a, b) {
+		t.Errorf("streams differ:\n%x\n%x", a, b)
+	}
+
+	first := sha256.Sum256(append([]byte("seed"), 0, 0, 0, 0, 0, 0, 0, 0))
+	if !bytes.Equal(a[:32], first[:]) {
+		t.Errorf("first block %x, want %x", a[:32], first)
+	}
+
+	NewDeterministic([]byte("other")).Read(b)
+	if bytes.Equal(a, b) {
+		t.Error("different seeds give the same stream")
+	}
+}
+
+func TestMix(t *testing.T) {
+	block := bytes.Repeat([]byte{0x5a}, 64)
+	extra := []byte("dice:\x00\x01\x02")
+
+	// HMAC-SHA256(SHA256(extra), block || counter) for two blocks.
+	key := sha256.Sum256(extra)
+	var want []byte
+	for i := 0; i < 2; i++ {
+		mac := hmac.New(sha256.New, key[:])
+		mac.Write(block[32*i : 32*i+32])
+		mac.Write([]byte{0, 0, 0, 0, 0, 0, 0, byte(i)})
+		want = mac.Sum(want)
+	}
+
+	got := make([]byte, 64)
+	if _, err := io.ReadFull(Mix(bytes.NewReader(block), extra), got); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("got %x, want %x", got, want)
+	}
+
+	// Odd read sizes give the same stream.
+	mixed := Mix(bytes.NewReader(block), extra)
+	var pieces []byte
+	for _, n := range []int{1, 7, 30, 26} {
+		p := make([]byte, n)
+		if _, err := mixed.Read(p); err != nil {
+			t.Fatal(err)
+		}
+		pieces = append(pieces, p...)
+	}
+	if !bytes.Equal(pieces, want) {
+		t.Errorf("pieces %x, want %x", pieces, want)
+	}
+
+	other := make([]byte, 64)
+	io.ReadFull(Mix(bytes.NewReader(block), []byte("dice:\x00\x01\x03")), other)
+	if bytes.Equal(other, want) {
+		t.Error("different user entropy gives the same stream")
+	}
+
+	readErr := errors.New("read failed")
+	if _, err := Mix(iotest.ErrReader(readErr), extra).Read(got); err != readErr {
+		t.Errorf("got %v, want the read error", err)
+	}
+}
+
+func TestParse(t *testing.T) {
+	u, err := ParseDice("16 25\n34")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := u.Values; len(got) != 6 || got[0] != 0 || got[1] != 5 || got[5] != 3 {
+		t.Errorf("dice values %v", got)
+	}
+	if got := string(u.Bytes()); got != "dice:\x00\x05\x01\x04\x02\x03" {
+		t.Errorf("dice bytes %q", got)
+	}
+	for _, s := range []string{"7", "0", "12a"} {
+		if _, err := ParseDice(s); err == nil {
+			t.Errorf("ParseDice(%q) succeeded", s)
+		}
+	}
+
+	u, err = ParseCoins("HhTt 10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(u.Bytes()); got != "coins:\x01\x01\x00\x00\x01\x00" {
+		t.Errorf("coin bytes %q", got)
+	}
+	if _, err := ParseCoins("HTX"); err == nil {
+		t.Error("ParseCoins accepted X")
+	}
+}
+
+func TestStats(t *testing.T) {
+	fair, _ := ParseDice(strings.Repeat("123456", 10))
+	s := fair.Stats()
+	if s.Biased || s.ChiSquare != 0 {
+		t.Errorf("fair dice: biased %v, chi-square %v", s.Biased, s.ChiSquare)
+	}
+	if math.Abs(s.EstimatedBits-s.MaxBits) > 1e-9 || math.Abs(s.MaxBits-60*math.Log2(6)) > 1e-9 {
+		t.Errorf("fair dice: %v bits, %v estimated", s.MaxBits, s.EstimatedBits)
+	}
+
+	// Sixes come up twice as often as they should.
+	biased, _ := ParseDice(strings.Repeat("1234566", 30))
+	if s := biased.Stats(); !s.Biased || s.EstimatedBits >= s.MaxBits {
+		t.Errorf("biased dice: %+v", s)
+	}
+
+	// Too few rolls to tell.
+	few, _ := ParseDice("666666")
+	if s := few.Stats(); s.Biased {
+		t.Errorf("six rolls: %+v", s)
+	}
+
+	heads, _ := ParseCoins(strings.Repeat("H", 20))
+	if s := heads.Stats(); !s.Biased || s.EstimatedBits != 0 {
+		t.Errorf("only heads: %+v", s)
+	}
+	if s := (&UserEntropy{Kind: "coins", Sides: 2}).Stats(); s.Count != 0 || s.Biased {
+		t.Errorf("no flips: %+v", s)
+	}
+}
@@ -0,0 +1,115 @@
+package entropy
+
+import (
+	"fmt"
+	"math"
+	"unicode"
+)
+
+// UserEntropy is randomness collected by hand, such as dice rolls or coin
+// flips. It is never used on its own, only mixed into a Source with Mix.
+type UserEntropy struct {
+	Kind  string // "dice" or "coins"
+	Sides int
+	// Values are the outcomes, from 0 to Sides-1.
+	Values []int
+}
+
+// ParseDice parses six sided dice rolls written as digits 1 to 6.
+// White space is ignored.
+func ParseDice(s string) (*UserEntropy, error) {
+	u := &UserEntropy{Kind: "dice", Sides: 6}
+	for i, r := range s {
+		switch {
+		case unicode.IsSpace(r):
+		case r >= '1' && r <= '6':
+			u.Values = append(u.Values, int(r-'1'))
+		default:
+			return nil, fmt.Errorf("entropy: invalid dice roll %q at position %d", r, i)
+		}
+	}
+	return u, nil
+}
+
+// ParseCoins parses coin flips written as H/T or 1/0.
+// White space is ignored.
+func ParseCoins(s string) (*UserEntropy, error) {
+	u := &UserEntropy{Kind: "coins", Sides: 2}
+	for i, r := range s {
+		switch {
+		case unicode.IsSpace(r):
+		case r == 'H' || r == 'h' || r == '1':
+			u.Values = append(u.Values, 1)
+		case r == 'T' || r == 't' || r == '0':
+			u.Values = append(u.Values, 0)
+		default:
+			return nil, fmt.Errorf("entropy: invalid coin flip %q at position %d", r, i)
+		}
+	}
+	return u, nil
+}
+
+// Bytes returns the canonical encoding of the outcomes to pass to Mix.
+func (u *UserEntropy) Bytes() []byte {
+	b := make([]byte, 0, len(u.Kind)+1+len(u.Values))
+	b = append(b, u.Kind...)
+	b = append(b, ':')
+	for _, v := range u.Values {
+		b = append(b, byte(v))
+	}
+	return b
+}
+
+// Stats describes how much entropy the outcomes hold and whether they look
+// biased.
+type Stats struct {
+	Count  int
+	Counts []int // occurrences of each outcome
+	// MaxBits is the entropy of Count fair outcomes.
+	MaxBits float64
+	// EstimatedBits is Count times the Shannon entropy of the observed
+	// distribution, lower than MaxBits when outcomes are uneven.
+	EstimatedBits float64
+	// ChiSquare is the Pearson chi-square statistic against a uniform
+	// distribution.
+	ChiSquare float64
+	// Biased is set when ChiSquare rejects uniformity at the 1% level.
+	// It is only computed once every outcome is expected at least 5 times.
+	Biased bool
+}
+
+// chiSquare1 holds the 1% critical values of the chi-square distribution
+// by degrees of freedom.
+var chiSquare1 = map[int]float64{
+	1: 6.635,
+	5: 15.086,
+}
+
+// Stats computes the bias statistics of the outcomes.
+func (u *UserEntropy) Stats() Stats {
+	s := Stats{Count: len(u.Values), Counts: make([]int, u.Sides)}
+	for _, v := range u.Values {
+		s.Counts[v]++
+	}
+	if s.Count == 0 {
+		return s
+	}
+
+	n := float64(s.Count)
+	s.MaxBits = n * math.Log2(float64(u.Sides))
+
+	expected := n / float64(u.Sides)
+	for _, c := range s.Counts {
+		if c > 0 {
+			p := float64(c) / n
+			s.EstimatedBits -= n * p * math.Log2(p)
+		}
+		d := float64(c) - expected
+		s.ChiSquare += d * d / expected
+	}
+
+	if critical, ok := chiSquare1[u.Sides-1]; ok && expected >= 5 {
+		s.Biased = s.ChiSquare > critical
+	}
+	return s
+}
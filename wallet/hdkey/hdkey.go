@@ -0,0 +1,287 @@
+// Package hdkey implements BIP32 hierarchical deterministic keys.
+//
+// An extended key is a private or public key together with a chain code,
+// from which child keys are derived. Keys serialize to the base58check
+// xprv/xpub (mainnet) and tprv/tpub (test networks) formats.
+//
+// https://github.com/bitcoin/bips/blob/master/bip-0032.mediawiki
+package hdkey
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/smallnest/bitcoin/wallet/address"
+	"github.com/smallnest/bitcoin/wallet/base58check"
+	"github.com/smallnest/bitcoin/wallet/ecc"
+)
+
+// HardenedOffset is added to a child index to derive a hardened child.
+const HardenedOffset uint32 = 0x80000000
+
+// Serialization versions.
+var (
+	MainnetPrivate = [4]byte{0x04, 0x88, 0xAD, 0xE4} // xprv
+	MainnetPublic  = [4]byte{0x04, 0x88, 0xB2, 0x1E} // xpub
+	TestnetPrivate = [4]byte{0x04, 0x35, 0x83, 0x94} // tprv
+	TestnetPublic  = [4]byte{0x04, 0x35, 0x87, 0xCF} // tpub
+)
+
+// serializedLength is the length of an extended key without version and
+// checksum: depth, fingerprint, child number, chain code and key.
+const serializedLength = 1 + 4 + 4 + 32 + 33
+
+var (
+	// ErrInvalidChild is returned when a child index yields an invalid key,
+	// which happens with probability below 2^-127. BIP32 says to proceed
+	// with the next index.
+	ErrInvalidChild = errors.New("hdkey: invalid child key, use the next index")
+	// ErrHardenedFromPublic is returned when deriving a hardened child from
+	// a public key.
+	ErrHardenedFromPublic = errors.New("hdkey: cannot derive a hardened child from a public key")
+	// ErrDepth is returned when deriving beyond depth 255.
+	ErrDepth = errors.New("hdkey: maximum depth reached")
+)
+
+// Key is an extended private or public key.
+type Key struct {
+	Network           address.Network
+	Depth             byte
+	ParentFingerprint [4]byte
+	ChildNumber       uint32
+	ChainCode         [32]byte
+	// Key is the 32-byte private key or the 33-byte compressed public key.
+	Key     []byte
+	Private bool
+}
+
+// NewMaster derives the master key from a seed of 16 to 64 bytes, such as
+// a BIP39 seed.
+func NewMaster(seed []byte, net address.Network) (*Key, error) {
+	if len(seed) < 16 || len(seed) > 64 {
+		return nil, fmt.Errorf("hdkey: seed must be 16 to 64 bytes, got %d", len(seed))
+	}
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	i := mac.Sum(nil)
+
+	if !ecc.ValidScalar(i[:32]) {
+		return nil, errors.New("hdkey: invalid master key, use another seed")
+	}
+	k := &Key{Network: net, Key: i[:32], Private: true}
+	copy(k.ChainCode[:], i[32:])
+	return k, nil
+}
+
+// PublicKey returns the 33-byte compressed public key.
+func (k *Key) PublicKey() []byte {
+	if !k.Private {
+		return k.Key
+	}
+	return ecc.ScalarBaseMult(k.Key).Serialize(true)
+}
+
+// Identifier is the Hash160 of the public key.
+func (k *Key) Identifier() []byte {
+	return address.Hash160(k.PublicKey())
+}
+
+// Fingerprint is the first four bytes of the identifier. Children record
+// the fingerprint of their parent.
+func (k *Key) Fingerprint() [4]byte {
+	var fp [4]byte
+	copy(fp[:], k.Identifier())
+	return fp
+}
+
+// IsHardened reports whether the key is a hardened child.
+func (k *Key) IsHardened() bool {
+	return k.ChildNumber >= HardenedOffset
+}
+
+// Neuter returns the extended public key of k.
+func (k *Key) Neuter() *Key {
+	if !k.Private {
+		return k
+	}
+	return &Key{
+		Network:           k.Network,
+		Depth:             k.Depth,
+		ParentFingerprint: k.ParentFingerprint,
+		ChildNumber:       k.ChildNumber,
+		ChainCode:         k.ChainCode,
+		Key:               k.PublicKey(),
+	}
+}
+
+// Child derives the child key with index i. Indexes from HardenedOffset on
+// are hardened and need a private key.
+func (k *Key) Child(i uint32) (*Key, error) {
+	if k.Depth == 255 {
+		return nil, ErrDepth
+	}
+
+	// Hardened: HMAC-SHA512(c, 0x00 || k || i), otherwise HMAC-SHA512(c, K || i)
+	data := make([]byte, 0, 37)
+	if i >= HardenedOffset {
+		if !k.Private {
+			return nil, ErrHardenedFromPublic
+		}
+		data = append(data, 0x00)
+		data = append(data, k.Key...)
+	} else {
+		data = append(data, k.PublicKey()...)
+	}
+	data = binary.BigEndian.AppendUint32(data, i)
+
+	mac := hmac.New(sha512.New, k.ChainCode[:])
+	mac.Write(data)
+	sum := mac.Sum(nil)
+	il, ir := sum[:32], sum[32:]
+
+	if !ecc.ValidScalar(il) {
+		return nil, ErrInvalidChild
+	}
+
+	child := &Key{
+		Network:           k.Network,
+		Depth:             k.Depth + 1,
+		ParentFingerprint: k.Fingerprint(),
+		ChildNumber:       i,
+		Private:           k.Private,
+	}
+	copy(child.ChainCode[:], ir)
+
+	if k.Private {
+		// k_i = IL + k_par (mod n)
+		child.Key = ecc.AddScalars(il, k.Key)
+		if !ecc.ValidScalar(child.Key) {
+			return nil, ErrInvalidChild
+		}
+		return child, nil
+	}
+
+	// K_i = IL·G + K_par
+	parent, err := ecc.ParsePoint(k.Key)
+	if err != nil {
+		return nil, err
+	}
+	point := ecc.Add(ecc.ScalarBaseMult(il), parent)
+	if point.IsInfinity() {
+		return nil, ErrInvalidChild
+	}
+	child.Key = point.Serialize(true)
+	return child, nil
+}
+
+// Derive follows a path such as m/44'/0'/0'/0/5 from k. The path may also
+// be relative, without the leading m.
+func (k *Key) Derive(path string) (*Key, error) {
+	indexes, err := ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	return k.DerivePath(indexes)
+}
+
+// DerivePath derives the child at each index in turn.
+func (k *Key) DerivePath(indexes []uint32) (*Key, error) {
+	var err error
+	for _, i := range indexes {
+		if k, err = k.Child(i); err != nil {
+			return nil, err
+		}
+	}
+	return k, nil
+}
+
+// version returns the serialization version of k.
+func (k *Key) version() [4]byte {
+	switch {
+	case k.Network == address.Mainnet && k.Private:
+		return MainnetPrivate
+	case k.Network == address.Mainnet:
+		return MainnetPublic
+	case k.Private:
+		return TestnetPrivate
+	}
+	return TestnetPublic
+}
+
+// String serializes the key as xprv, xpub, tprv or tpub.
+func (k *Key) String() string {
+	data := make([]byte, 0, serializedLength)
+	data = append(data, k.Depth)
+	data = append(data, k.ParentFingerprint[:]...)
+	data = binary.BigEndian.AppendUint32(data, k.ChildNumber)
+	data = append(data, k.ChainCode[:]...)
+	if k.Private {
+		data = append(data, 0x00)
+	}
+	data = append(data, k.Key...)
+
+	version := k.version()
+	return base58check.EncodeVersion(version[:], data)
+}
+
+// Parse decodes an xprv, xpub, tprv or tpub string.
+func Parse(s string) (*Key, error) {
+	version, data, err := base58check.DecodeVersion(s, 4)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) != serializedLength {
+		return nil, fmt.Errorf("hdkey: invalid length %d", len(data))
+	}
+
+	k := &Key{
+		Depth:       data[0],
+		ChildNumber: binary.BigEndian.Uint32(data[5:9]),
+	}
+	copy(k.ParentFingerprint[:], data[1:5])
+	copy(k.ChainCode[:], data[9:41])
+
+	var v [4]byte
+	copy(v[:], version)
+	switch v {
+	case MainnetPrivate:
+		k.Network, k.Private = address.Mainnet, true
+	case MainnetPublic:
+		k.Network = address.Mainnet
+	case TestnetPrivate:
+		k.Network, k.Private = address.Testnet, true
+	case TestnetPublic:
+		k.Network = address.Testnet
+	default:
+		return nil, fmt.Errorf("hdkey: unknown version %x", version)
+	}
+
+	if k.Depth == 0 && (k.ParentFingerprint != [4]byte{} || k.ChildNumber != 0) {
+		return nil, errors.New("hdkey: master key with parent fingerprint or child number")
+	}
+
+	keyData := data[41:]
+	if k.Private {
+		if keyData[0] != 0x00 || !ecc.ValidScalar(keyData[1:]) {
+			return nil, errors.New("hdkey: invalid private key")
+		}
+		k.Key = append([]byte(nil), keyData[1:]...)
+	} else {
+		if _, err := ecc.ParsePoint(keyData); err != nil || keyData[0] == 0x04 {
+			return nil, errors.New("hdkey: invalid public key")
+		}
+		k.Key = append([]byte(nil), keyData...)
+	}
+	return k, nil
+}
+
+// Equal reports whether both keys serialize identically.
+func (k *Key) Equal(o *Key) bool {
+	return k.Private == o.Private && k.Network == o.Network && k.Depth == o.Depth &&
+		k.ParentFingerprint == o.ParentFingerprint && k.ChildNumber == o.ChildNumber &&
+		k.ChainCode == o.ChainCode && bytes.Equal(k.Key, o.Key)
+}
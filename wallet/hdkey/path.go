@@ -0,0 +1,55 @@
+package hdkey
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParsePath parses a derivation path such as m/44'/0'/0'/0/5.
+// Hardened indexes are marked with ', h or H. The leading m is optional.
+func ParsePath(path string) ([]uint32, error) {
+	path = strings.TrimSpace(path)
+	parts := strings.Split(path, "/")
+	if parts[0] == "m" || parts[0] == "M" {
+		parts = parts[1:]
+	}
+	if len(parts) == 1 && parts[0] == "" {
+		return nil, nil
+	}
+
+	indexes := make([]uint32, 0, len(parts))
+	for _, part := range parts {
+		hardened := false
+		if n := len(part); n > 0 && (part[n-1] == '\'' || part[n-1] == 'h' || part[n-1] == 'H') {
+			hardened = true
+			part = part[:n-1]
+		}
+		i, err := strconv.ParseUint(part, 10, 32)
+		if err != nil || uint32(i) >= HardenedOffset {
+			return nil, fmt.Errorf("hdkey: invalid path element %q in %q", part, path)
+		}
+		index := uint32(i)
+		if hardened {
+			index += HardenedOffset
+		}
+		indexes = append(indexes, index)
+	}
+	return indexes, nil
+}
+
+// FormatPath formats indexes as a path such as m/44'/0'/0'/0/5.
+func FormatPath(indexes []uint32) string {
+	var sb strings.Builder
+	sb.WriteString("m")
+	for _, i := range indexes {
+		sb.WriteByte('/')
+		if i >= HardenedOffset {
+			sb.WriteString(strconv.FormatUint(uint64(i-HardenedOffset), 10))
+			sb.WriteByte('\'')
+		} else {
+			sb.WriteString(strconv.FormatUint(uint64(i), 10))
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,407 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/smallnest/bitcoin/wallet/address"
+	"github.com/smallnest/bitcoin/wallet/ecc"
+	"github.com/smallnest/bitcoin/wallet/wif"
+)
+
+// runAudit replays the private key generator of old builds of this tool to
+// find the key of an address, or to tell whether a key came from it. The
+// generator reseeded math/rand with the clock for every byte:
+//
+//	rand.Seed(time.Now().UTC().UnixNano())
+//	b := uint8(rand.Intn(255))
+//
+// and made uncompressed P2PKH addresses. A key is the bytes drawn at the
+// seeds t, t+r, t+2r, ... where r is the resolution of the clock, and the
+// clock only advanced a few times while the 32 bytes were drawn: on a clock
+// with a 1ms or coarser resolution, most keys are a single byte repeated or
+// two runs of bytes. The search replays every start time t in the window
+// and every way the clock can have advanced up to -changes times.
+//
+// Keys made where time.Now has a fine resolution, such as Linux, have
+// hundreds of nanoseconds of jitter between the bytes and can't be found
+// this way. Builds with Go 1.24 or later ignore rand.Seed and are not
+// affected.
+//
+//	key audit -from 2021-03-01 [-to 2021-03-02T12:00:00Z] [-resolution 1ms] [-changes 1] [-workers N] <address|WIF>
+func runAudit(args []string) error {
+	fs := flag.NewFlagSet("audit", flag.ExitOnError)
+	from := fs.String("from", "", "Start of the time window the key was made in, RFC 3339 or 2006-01-02. (required)")
+	to := fs.String("to", "", "End of the time window. (optional, defaults to now)")
+	resolution := fs.Duration("resolution", time.Millisecond, "Resolution of the clock of the machine that made the key.")
+	changes := fs.Int("changes", 1, "Maximum number of times the clock advanced while the key was made.")
+	workers := fs.Int("workers", runtime.NumCPU(), "Number of CPU cores to search with.")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return errors.New("usage: key audit -from TIME [flags] <address|WIF>")
+	}
+	target, err := newAuditTarget(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if *from == "" {
+		return errors.New("audit needs the -from time")
+	}
+	start, err := parseAuditTime(*from)
+	if err != nil {
+		return err
+	}
+	end := time.Now()
+	if *to != "" {
+		if end, err = parseAuditTime(*to); err != nil {
+			return err
+		}
+	}
+	if !end.After(start) {
+		return errors.New("-to must be after -from")
+	}
+	if *resolution <= 0 {
+		return errors.New("-resolution must be positive")
+	}
+	if *changes < 0 || *changes >= keyLength {
+		return fmt.Errorf("-changes must be between 0 and %d", keyLength-1)
+	}
+	if *workers < 1 {
+		*workers = 1
+	}
+
+	s := newAuditSearch(target, start, int64(end.Sub(start) / *resolution), *resolution, *changes)
+	total := float64(s.ticks) * paths(*changes)
+	fmt.Fprintf(os.Stderr, "Replaying %d start times at %s resolution, up to %.0f keys\n", s.ticks, *resolution, total)
+
+	begin := time.Now()
+	done := make(chan struct{})
+	go s.report(done, begin)
+
+	var wg sync.WaitGroup
+	for i := 0; i < *workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.work()
+		}()
+	}
+	wg.Wait()
+	close(done)
+
+	tried := atomic.LoadUint64(&s.tried)
+	elapsed := time.Since(begin)
+	fmt.Fprintf(os.Stderr, "Tried %d keys in %s (%.0f keys/s)\n",
+		tried, elapsed.Round(time.Millisecond), float64(tried)/elapsed.Seconds())
+	if s.key == nil {
+		return errors.New("no key of the old generator matches in the time window")
+	}
+
+	if target.privateKey != nil {
+		fmt.Println("The key is vulnerable, it was made by the old time-seeded generator")
+	} else {
+		privateKeyWif, err := wif.New(s.key, target.net, s.compressed)
+		if err != nil {
+			return err
+		}
+		fmt.Println("Your private key is")
+		fmt.Println(privateKeyWif)
+	}
+	// rand.Seed takes the seed modulo 2³¹-1, so the same key is drawn
+	// every 2.147483647s and this is only the first such time.
+	fmt.Println("Its first byte was drawn with the clock at")
+	fmt.Println(time.Unix(0, s.seed).UTC().Format(time.RFC3339Nano))
+	return nil
+}
+
+// keyLength is the number of bytes, and clock readings, of a private key.
+const keyLength = 32
+
+// auditTarget is what the replayed keys are compared with: the hash of a
+// P2PKH address, or a private key whose origin is checked.
+type auditTarget struct {
+	net        address.Network
+	hash       []byte
+	privateKey []byte
+}
+
+func newAuditTarget(s string) (*auditTarget, error) {
+	if a, err := address.Parse(s); err == nil {
+		if a.Kind != address.P2PKH {
+			return nil, fmt.Errorf("%s is a %s address, the old generator only made P2PKH addresses", s, a.Kind)
+		}
+		return &auditTarget{net: a.Network, hash: a.Hash}, nil
+	}
+	w, err := wif.Decode(s)
+	if err != nil {
+		return nil, fmt.Errorf("%q is neither a P2PKH address nor a WIF", s)
+	}
+	return &auditTarget{net: w.Network, privateKey: w.PrivateKey[:]}, nil
+}
+
+// match tests a replayed key, whose public key is point, and reports
+// whether the address uses the compressed public key. The old generator
+// made uncompressed keys, but the WIF may have been imported as compressed
+// later.
+func (t *auditTarget) match(key []byte, point *ecc.Point) (found, compressed bool) {
+	if t.privateKey != nil {
+		return bytes.Equal(key, t.privateKey), true
+	}
+	if point.IsInfinity() {
+		return false, false
+	}
+	if bytes.Equal(address.Hash160(point.Serialize(false)), t.hash) {
+		return true, false
+	}
+	return bytes.Equal(address.Hash160(point.Serialize(true)), t.hash), true
+}
+
+// newByteTable returns the points v·256^(31-i)·G for every byte position i
+// and value v the generator can draw. The public key of a key is the sum of
+// the points of its bytes, and keys that share a prefix share the partial
+// sums, which is much faster than a scalar multiplication per key.
+func newByteTable() [][]*ecc.Point {
+	table := make([][]*ecc.Point, keyLength)
+	for i := range table {
+		unit := make([]byte, keyLength)
+		unit[i] = 1
+		base := ecc.ScalarBaseMult(unit)
+		row := make([]*ecc.Point, 255)
+		row[0] = &ecc.Point{}
+		for v := 1; v < len(row); v++ {
+			row[v] = ecc.Add(row[v-1], base)
+		}
+		table[i] = row
+	}
+	return table
+}
+
+// auditSearch is the state shared by the workers.
+type auditSearch struct {
+	target     *auditTarget
+	from       int64 // seed of the first start time, in nanoseconds
+	ticks      int64 // number of start times
+	resolution int64
+	changes    int
+	// table is the output of newByteTable, nil when the target is a
+	// private key.
+	table [][]*ecc.Point
+
+	next  int64  // next start time to replay, updated atomically
+	tried uint64 // updated atomically
+	found int32  // set atomically once a worker has a result
+
+	// tested is a bit set of the keys made of one or two runs of bytes,
+	// which many start times share, so that each is only tested once.
+	tested []uint32
+
+	mu         sync.Mutex
+	key        []byte
+	seed       int64
+	compressed bool
+}
+
+// newAuditSearch returns the search of ticks start times from start on.
+func newAuditSearch(target *auditTarget, start time.Time, ticks int64, resolution time.Duration, changes int) *auditSearch {
+	s := &auditSearch{
+		target:     target,
+		from:       start.UnixNano(),
+		ticks:      ticks,
+		resolution: int64(resolution),
+		changes:    changes,
+		tested:     make([]uint32, (255*255*keyLength+31)/32),
+	}
+	if target.privateKey == nil {
+		s.table = newByteTable()
+	}
+	return s
+}
+
+// auditWorker is owned by a single goroutine.
+type auditWorker struct {
+	s   *auditSearch
+	src rand.Source
+	rng *rand.Rand
+	// values are the bytes drawn at the start time and after each time
+	// the clock advanced.
+	values []byte
+	key    []byte
+	seed   int64
+	// points[i] is the public key of the first i bytes of key, up to
+	// points[valid].
+	points []*ecc.Point
+	valid  int
+}
+
+// draw replays one byte of the old generator.
+func (w *auditWorker) draw(seed int64) byte {
+	w.src.Seed(seed)
+	return byte(w.rng.Intn(255))
+}
+
+func (s *auditSearch) work() {
+	src := rand.NewSource(0)
+	w := &auditWorker{
+		s:      s,
+		src:    src,
+		rng:    rand.New(src),
+		values: make([]byte, s.changes+1),
+		key:    make([]byte, keyLength),
+		points: make([]*ecc.Point, keyLength+1),
+	}
+	w.points[0] = &ecc.Point{}
+
+	const batch = 64
+	for atomic.LoadInt32(&s.found) == 0 {
+		first := atomic.AddInt64(&s.next, batch) - batch
+		if first >= s.ticks {
+			return
+		}
+		last := first + batch
+		if last > s.ticks {
+			last = s.ticks
+		}
+		for t := first; t < last; t++ {
+			w.seed = s.from + t*s.resolution
+			for k := range w.values {
+				w.values[k] = w.draw(w.seed + int64(k)*s.resolution)
+			}
+			w.replay(0, 0)
+		}
+	}
+}
+
+// replay fills the key from byte i on, with the clock advanced k times so
+// far, in every way the clock can advance the remaining times.
+func (w *auditWorker) replay(i, k int) {
+	if i == len(w.key) {
+		w.test()
+		return
+	}
+	w.set(i, w.values[k])
+	w.replay(i+1, k)
+	if i > 0 && k+1 < len(w.values) {
+		w.set(i, w.values[k+1])
+		w.replay(i+1, k+1)
+	}
+}
+
+// set sets byte i of the key, invalidating the partial sums from there.
+func (w *auditWorker) set(i int, v byte) {
+	if w.key[i] != v && i < w.valid {
+		w.valid = i
+	}
+	w.key[i] = v
+}
+
+// point returns the public key of the key, completing the partial sums.
+func (w *auditWorker) point() *ecc.Point {
+	if w.s.table == nil {
+		return nil
+	}
+	for ; w.valid < keyLength; w.valid++ {
+		w.points[w.valid+1] = ecc.Add(w.points[w.valid], w.s.table[w.valid][w.key[w.valid]])
+	}
+	return w.points[keyLength]
+}
+
+func (w *auditWorker) test() {
+	s := w.s
+	if atomic.LoadInt32(&s.found) != 0 || s.seen(w.key) {
+		return
+	}
+	atomic.AddUint64(&s.tried, 1)
+	found, compressed := s.target.match(w.key, w.point())
+	if !found {
+		return
+	}
+	if atomic.CompareAndSwapInt32(&s.found, 0, 1) {
+		s.mu.Lock()
+		s.key, s.seed, s.compressed = append([]byte(nil), w.key...), w.seed, compressed
+		s.mu.Unlock()
+	}
+}
+
+// seen marks a key made of one or two runs of bytes as tested, and
+// reports whether it already was. Other keys are never seen.
+func (s *auditSearch) seen(key []byte) bool {
+	a, b := int(key[0]), int(key[len(key)-1])
+	j := 0
+	for j < len(key) && int(key[j]) == a {
+		j++
+	}
+	if j == len(key) {
+		j = 0
+	} else {
+		for _, c := range key[j:] {
+			if int(c) != b {
+				return false
+			}
+		}
+	}
+
+	i := (a*255+b)*keyLength + j
+	word, bit := &s.tested[i/32], uint32(1)<<(i%32)
+	for {
+		old := atomic.LoadUint32(word)
+		if old&bit != 0 {
+			return true
+		}
+		if atomic.CompareAndSwapUint32(word, old, old|bit) {
+			return false
+		}
+	}
+}
+
+// report prints the search speed and progress every few seconds.
+func (s *auditSearch) report(done chan struct{}, start time.Time) {
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			next := atomic.LoadInt64(&s.next)
+			if next > s.ticks {
+				next = s.ticks
+			}
+			tried := atomic.LoadUint64(&s.tried)
+			fmt.Fprintf(os.Stderr, "%d keys, %.0f keys/s, %.1f%% of the window\n",
+				tried, float64(tried)/time.Since(start).Seconds(), 100*float64(next)/float64(s.ticks))
+		}
+	}
+}
+
+// paths is the number of ways the clock can advance up to changes times
+// between the 32 bytes of a key: the sum of C(31, c) for c <= changes.
+func paths(changes int) float64 {
+	sum, term := 0.0, 1.0
+	for c := 0; c <= changes; c++ {
+		sum += term
+		term = term * float64(keyLength-1-c) / float64(c+1)
+	}
+	return math.Round(sum)
+}
+
+// parseAuditTime parses an RFC 3339 time or a UTC date.
+func parseAuditTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q, use RFC 3339 or 2006-01-02", s)
+	}
+	return t, nil
+}
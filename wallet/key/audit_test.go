@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/smallnest/bitcoin/wallet/address"
+	"github.com/smallnest/bitcoin/wallet/ecc"
+	"github.com/smallnest/bitcoin/wallet/wif"
+)
+
+// oldGeneratorKey makes a key the way old builds did on a clock of 1ms
+// resolution that advanced once, after the first 20 bytes. A new source
+// stands in for rand.Seed, which Go 1.24 and later ignore.
+func oldGeneratorKey(seed int64) []byte {
+	key := make([]byte, keyLength)
+	for i := range key {
+		if i == 20 {
+			seed += int64(time.Millisecond)
+		}
+		key[i] = uint8(rand.New(rand.NewSource(seed)).Intn(255))
+	}
+	return key
+}
+
+func TestAuditSearch(t *testing.T) {
+	start := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	made := start.Add(3 * time.Millisecond)
+	key := oldGeneratorKey(made.UnixNano())
+
+	uncompressed, err := ecc.Default.PublicKey(key, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compressed, err := ecc.Default.PublicKey(key, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := wif.New(key, address.Mainnet, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, v := range []struct {
+		name       string
+		target     string
+		compressed bool
+	}{
+		{"address", address.NewP2PKHFromKey(address.Mainnet, uncompressed).String(), false},
+		{"compressed address", address.NewP2PKHFromKey(address.Mainnet, compressed).String(), true},
+		{"wif", w.String(), true},
+	} {
+		target, err := newAuditTarget(v.target)
+		if err != nil {
+			t.Fatalf("%s: %v", v.name, err)
+		}
+
+		s := newAuditSearch(target, start, 10, time.Millisecond, 1)
+		s.work()
+		if !bytes.Equal(s.key, key) {
+			t.Errorf("%s: got key %x, want %x", v.name, s.key, key)
+			continue
+		}
+		if s.seed != made.UnixNano() || s.compressed != v.compressed {
+			t.Errorf("%s: got seed %d compressed %v, want %d and %v",
+				v.name, s.seed, s.compressed, made.UnixNano(), v.compressed)
+		}
+
+		// Without the clock advancing, or before the key was made, there
+		// is nothing to find.
+		s = newAuditSearch(target, start, 10, time.Millisecond, 0)
+		s.work()
+		if s.key != nil {
+			t.Errorf("%s: found %x without a clock change", v.name, s.key)
+		}
+		s = newAuditSearch(target, start, 3, time.Millisecond, 1)
+		s.work()
+		if s.key != nil {
+			t.Errorf("%s: found %x before the key was made", v.name, s.key)
+		}
+	}
+}
+
+func TestAuditSeen(t *testing.T) {
+	runs := func(a byte, n int, b byte) []byte {
+		key := bytes.Repeat([]byte{a}, keyLength)
+		for i := n; i < keyLength; i++ {
+			key[i] = b
+		}
+		return key
+	}
+
+	s := newAuditSearch(&auditTarget{privateKey: make([]byte, keyLength)}, time.Now(), 1, time.Millisecond, 1)
+	for _, key := range [][]byte{
+		runs(7, keyLength, 7),
+		runs(0, keyLength, 0),
+		runs(254, keyLength, 254),
+		runs(7, 20, 9),
+		runs(7, 21, 9),
+		runs(9, 20, 7),
+		runs(0, 1, 254),
+		runs(254, 31, 0),
+	} {
+		if s.seen(key) {
+			t.Errorf("%x: seen before it was tested", key)
+		}
+		if !s.seen(key) {
+			t.Errorf("%x: not seen after it was tested", key)
+		}
+	}
+
+	// Keys of three runs or more are always tested.
+	three := runs(7, 10, 9)
+	three[20] = 8
+	for i := 0; i < 2; i++ {
+		if s.seen(three) {
+			t.Errorf("%x: seen", three)
+		}
+	}
+}
+
+func TestAuditPaths(t *testing.T) {
+	for _, v := range []struct {
+		changes int
+		want    float64
+	}{
+		{0, 1},
+		{1, 32},
+		{2, 1 + 31 + 465},
+		{3, 1 + 31 + 465 + 4495},
+		{keyLength - 1, 1 << (keyLength - 1)},
+	} {
+		if got := paths(v.changes); got != v.want {
+			t.Errorf("paths(%d): got %.0f, want %.0f", v.changes, got, v.want)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/smallnest/bitcoin/wallet/address"
+	"github.com/smallnest/bitcoin/wallet/bip38"
+	"github.com/smallnest/bitcoin/wallet/ecc"
+	"github.com/smallnest/bitcoin/wallet/wif"
+)
+
+// runBIP38 dispatches the BIP38 commands:
+//
+//	key bip38 encrypt -passphrase ... [WIF]
+//	key bip38 decrypt -passphrase ... <6P...>
+//	key bip38 intermediate -passphrase ... [-lot N -sequence N]
+//	key bip38 generate <intermediate code>
+//	key bip38 confirm -passphrase ... <cfrm38...>
+//
+// The owner of a passphrase creates an intermediate code and gives it to a
+// third party, who generates encrypted keys and confirmation codes with it
+// but cannot decrypt them.
+func runBIP38(args []string) error {
+	if len(args) == 0 {
+		return errors.New("bip38 needs a sub command: encrypt, decrypt, intermediate, generate or confirm")
+	}
+	switch args[0] {
+	case "encrypt":
+		return runBIP38Encrypt(args[1:])
+	case "decrypt":
+		return runBIP38Decrypt(args[1:])
+	case "intermediate":
+		return runBIP38Intermediate(args[1:])
+	case "generate":
+		return runBIP38Generate(args[1:])
+	case "confirm":
+		return runBIP38Confirm(args[1:])
+	}
+	return fmt.Errorf("unknown bip38 command %q", args[0])
+}
+
+func passphraseFlag(fs *flag.FlagSet) *string {
+	return fs.String("passphrase", "", "Passphrase protecting the private key.")
+}
+
+func requirePassphrase(passphrase string) error {
+	if passphrase == "" {
+		return errors.New("a -passphrase is required")
+	}
+	return nil
+}
+
+func runBIP38Encrypt(args []string) error {
+	fs := flag.NewFlagSet("bip38 encrypt", flag.ExitOnError)
+	passphrase := passphraseFlag(fs)
+	fs.Parse(args)
+	if err := requirePassphrase(*passphrase); err != nil {
+		return err
+	}
+
+	var w *wif.WIF
+	var err error
+	if fs.NArg() > 0 {
+		w, err = wif.Decode(fs.Arg(0))
+	} else {
+		var privateKey []byte
+		if privateKey, err = generatePrivateKey(entropySource()); err != nil {
+			return err
+		}
+		w, err = wif.New(privateKey, network(), *compressed)
+	}
+	if err != nil {
+		return err
+	}
+
+	encrypted, err := bip38.Encrypt(w, *passphrase)
+	if err != nil {
+		return err
+	}
+	pubKey, err := ecc.PublicKey(w.PrivateKey[:], w.Compressed)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Your encrypted private key is")
+	fmt.Println(encrypted)
+	fmt.Println("Your address is")
+	fmt.Println(address.NewP2PKHFromKey(w.Network, pubKey))
+	return nil
+}
+
+func runBIP38Decrypt(args []string) error {
+	fs := flag.NewFlagSet("bip38 decrypt", flag.ExitOnError)
+	passphrase := passphraseFlag(fs)
+	fs.Parse(args)
+	if err := requirePassphrase(*passphrase); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("bip38 decrypt needs an encrypted private key")
+	}
+
+	w, err := bip38.Decrypt(fs.Arg(0), *passphrase, network())
+	if err != nil {
+		return err
+	}
+	fmt.Println("Your private key is")
+	fmt.Println(w)
+	return nil
+}
+
+func runBIP38Intermediate(args []string) error {
+	fs := flag.NewFlagSet("bip38 intermediate", flag.ExitOnError)
+	passphrase := passphraseFlag(fs)
+	lot := fs.Int("lot", -1, "Lot number (0-1048575) embedded in the generated keys. (optional)")
+	sequence := fs.Uint("sequence", 0, "Sequence number (0-4095) within the lot.")
+	fs.Parse(args)
+	if err := requirePassphrase(*passphrase); err != nil {
+		return err
+	}
+
+	var code string
+	var err error
+	if *lot >= 0 {
+		code, err = bip38.NewIntermediateLot(entropySource(), *passphrase, uint32(*lot), uint32(*sequence))
+	} else {
+		code, err = bip38.NewIntermediate(entropySource(), *passphrase)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Println("Give this intermediate code to the party generating the keys")
+	fmt.Println(code)
+	return nil
+}
+
+func runBIP38Generate(args []string) error {
+	fs := flag.NewFlagSet("bip38 generate", flag.ExitOnError)
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return errors.New("bip38 generate needs an intermediate code")
+	}
+
+	g, err := bip38.Generate(entropySource(), fs.Arg(0), *compressed, network())
+	if err != nil {
+		return err
+	}
+	fmt.Println("The encrypted private key is")
+	fmt.Println(g.Encrypted)
+	fmt.Println("The address is")
+	fmt.Println(g.Address)
+	fmt.Println("The confirmation code is")
+	fmt.Println(g.Confirmation)
+	return nil
+}
+
+func runBIP38Confirm(args []string) error {
+	fs := flag.NewFlagSet("bip38 confirm", flag.ExitOnError)
+	passphrase := passphraseFlag(fs)
+	fs.Parse(args)
+	if err := requirePassphrase(*passphrase); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("bip38 confirm needs a confirmation code")
+	}
+
+	addr, err := bip38.VerifyConfirmation(fs.Arg(0), *passphrase, network())
+	if err != nil {
+		return err
+	}
+	fmt.Println("The confirmation code is valid for address")
+	fmt.Println(addr)
+	if lot, sequence, ok := bip38.LotSequence(fs.Arg(0)); ok {
+		fmt.Printf("lot %d, sequence %d\n", lot, sequence)
+	}
+	return nil
+}
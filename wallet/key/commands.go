@@ -0,0 +1,69 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+)
+
+// A command is a sub command of the key tool, e.g. "key recover".
+// It parses its own flags from args.
+type command struct {
+	usage string
+	run   func(args []string) error
+}
+
+var commands = map[string]command{
+	"derive":        {"list BIP44/49/84/86 addresses of a new or existing HD wallet (default)", runDerive},
+	"new":           {"generate a single private key and its addresses", runNew},
+	"recover":       {"recover a mistyped WIF or address", runRecover},
+	"inspect":       {"validate an existing WIF, hex or extended key and list its addresses", runInspect},
+	"mnemonic":      {"create (new) or check (restore) a BIP39 mnemonic", runMnemonic},
+	"vanity":        {"search for a key whose address matches a prefix, suffix or regex", runVanity},
+	"audit":         {"find keys made by the old time-seeded generator of early builds", runAudit},
+	"bip38":         {"encrypt and decrypt private keys with a passphrase (BIP38)", runBIP38},
+	"multisig":      {"build a BIP67 m-of-n multisig script and its P2SH/P2WSH addresses", runMultisig},
+	"split":         {"split a secret into SLIP-39 Shamir mnemonic shares", runSplit},
+	"combine":       {"recover a secret from SLIP-39 mnemonic shares", runCombine},
+	"paper":         {"render paper wallets with address and key QR codes to PDF, SVG or PNG", runPaper},
+	"request":       {"print a BIP21 bitcoin: payment request URI and its QR code", runRequest},
+	"taproot":       {"generate a taproot key and its x-only keys and P2TR address", runTaproot},
+	"wallet":        {"store keys and HD seeds in an encrypted wallet file", runWallet},
+	"selftest":      {"check the secp256k1 backend against the pure Go implementation", runSelftest},
+	"signmessage":   {"sign a message with the key of an address (legacy or BIP322)", runSignMessage},
+	"verifymessage": {"verify a legacy or BIP322 signed message against an address", runVerifyMessage},
+}
+
+func runCommand(name string, args []string) {
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", name)
+		usage()
+		os.Exit(2)
+	}
+	if err := cmd.run(args); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: %s [flags] [command] [command flags]\n\n", os.Args[0])
+	fmt.Fprintln(out, "Without a command a new HD wallet is created and its addresses are listed.")
+	fmt.Fprintln(out, "Nothing is sent over the network unless an -explorer is given.")
+	fmt.Fprintln(out, "\nCommands:")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(out, "  %-12s %s\n", name, commands[name].usage)
+	}
+
+	fmt.Fprintln(out, "\nFlags:")
+	flag.PrintDefaults()
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/smallnest/bitcoin/wallet/address"
+	"github.com/smallnest/bitcoin/wallet/bip39"
+	"github.com/smallnest/bitcoin/wallet/hdkey"
+	"github.com/smallnest/bitcoin/wallet/taproot"
+	"github.com/smallnest/bitcoin/wallet/wif"
+)
+
+// purpose is a standard derivation scheme: m/purpose'/coin_type'/account'/change/index.
+type purpose struct {
+	number  uint32
+	name    string
+	address func(net address.Network, pubKey []byte) (*address.Address, error)
+}
+
+var purposes = []purpose{
+	{44, "p2pkh", func(net address.Network, pubKey []byte) (*address.Address, error) {
+		return address.NewP2PKHFromKey(net, pubKey), nil
+	}},
+	{49, "p2sh-p2wpkh", address.NewP2SHP2WPKHFromKey},
+	{84, "p2wpkh", address.NewP2WPKHFromKey},
+	{86, "p2tr", func(net address.Network, pubKey []byte) (*address.Address, error) {
+		// BIP86: key path only, no script tree.
+		outputKey, _, err := taproot.OutputKey(pubKey, nil)
+		if err != nil {
+			return nil, err
+		}
+		return address.New(net, address.P2TR, outputKey)
+	}},
+}
+
+func purposeByNumber(n uint32) (purpose, error) {
+	for _, p := range purposes {
+		if p.number == n {
+			return p, nil
+		}
+	}
+	return purpose{}, fmt.Errorf("unsupported purpose %d, use 44, 49, 84 or 86", n)
+}
+
+type derivedAddress struct {
+	Path      string `json:"path"`
+	Address   string `json:"address"`
+	PublicKey string `json:"public_key"`
+	WIF       string `json:"wif,omitempty"`
+}
+
+type derivedAccount struct {
+	Purpose           uint32           `json:"purpose"`
+	Type              string           `json:"type"`
+	Path              string           `json:"path"`
+	ExtendedPublicKey string           `json:"xpub"`
+	Receive           []derivedAddress `json:"receive"`
+	Change            []derivedAddress `json:"change"`
+}
+
+type derivation struct {
+	Network  string           `json:"network"`
+	Mnemonic string           `json:"mnemonic,omitempty"`
+	Accounts []derivedAccount `json:"accounts"`
+}
+
+// runDerive lists the receive and change addresses of BIP44 (p2pkh),
+// BIP49 (p2sh-p2wpkh), BIP84 (p2wpkh) and BIP86 (p2tr) accounts.
+// Without -mnemonic or -key a new mnemonic is generated.
+//
+//	key derive [-mnemonic words | -key xprv] [-purpose 44,49,84,86] [-account 0] [-start 0] [-count 5] [-json]
+func runDerive(args []string) error {
+	fs := flag.NewFlagSet("derive", flag.ExitOnError)
+	mnemonic := fs.String("mnemonic", "", "BIP39 mnemonic to derive from. (optional, a new one is generated by default)")
+	passphrase := fs.String("passphrase", "", "BIP39 passphrase of the mnemonic. (optional)")
+	extendedKey := fs.String("key", "", "Master key (xprv/tprv) or account key (xprv/xpub at depth 3) to derive from, instead of a mnemonic.")
+	purposeList := fs.String("purpose", "44,49,84,86", "Comma separated purposes: 44 (p2pkh), 49 (p2sh-p2wpkh), 84 (p2wpkh), 86 (p2tr).")
+	account := fs.Uint("account", 0, "Account number.")
+	start := fs.Uint("start", 0, "First address index.")
+	count := fs.Uint("count", 5, "Number of receive and change addresses per account.")
+	private := fs.Bool("private", false, "Also print the WIF private key of each address.")
+	asJSON := fs.Bool("json", false, "Print JSON instead of a table.")
+	fs.Parse(args)
+
+	if *account >= uint(hdkey.HardenedOffset) || *start+*count > uint(hdkey.HardenedOffset) {
+		return errors.New("account and address indexes must be below 2^31")
+	}
+
+	var selected []purpose
+	for _, s := range strings.Split(*purposeList, ",") {
+		n, err := strconv.ParseUint(strings.TrimSpace(s), 10, 32)
+		if err != nil {
+			return fmt.Errorf("invalid purpose %q", s)
+		}
+		p, err := purposeByNumber(uint32(n))
+		if err != nil {
+			return err
+		}
+		selected = append(selected, p)
+	}
+
+	out := derivation{}
+	var root *hdkey.Key
+	switch {
+	case *extendedKey != "":
+		k, err := hdkey.Parse(*extendedKey)
+		if err != nil {
+			return err
+		}
+		root = k
+	default:
+		if *mnemonic == "" {
+			ent, err := bip39.NewEntropy(entropySource(), 256)
+			if err != nil {
+				return err
+			}
+			if *mnemonic, err = bip39.NewMnemonic(ent, bip39.English); err != nil {
+				return err
+			}
+			out.Mnemonic = *mnemonic
+		} else if err := validateMnemonic(*mnemonic); err != nil {
+			return err
+		}
+		k, err := hdkey.NewMaster(bip39.NewSeed(*mnemonic, *passphrase), network())
+		if err != nil {
+			return err
+		}
+		root = k
+	}
+	out.Network = root.Network.String()
+
+	for _, p := range selected {
+		acct, err := deriveAccount(root, p, uint32(*account), len(selected))
+		if err != nil {
+			return err
+		}
+		if acct.Receive, err = acct.deriveChain(0, uint32(*start), uint32(*count), *private); err != nil {
+			return err
+		}
+		if acct.Change, err = acct.deriveChain(1, uint32(*start), uint32(*count), *private); err != nil {
+			return err
+		}
+		out.Accounts = append(out.Accounts, acct.derivedAccount)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+	printDerivation(out)
+	return nil
+}
+
+// accountKey is an account being derived, with its extended key.
+type accountKey struct {
+	derivedAccount
+	purpose purpose
+	key     *hdkey.Key
+}
+
+// deriveAccount derives m/purpose'/coin_type'/account' from a master key,
+// or uses root directly when it already is an account key.
+func deriveAccount(root *hdkey.Key, p purpose, account uint32, purposes int) (*accountKey, error) {
+	coinType := uint32(0)
+	if root.Network != address.Mainnet {
+		coinType = 1
+	}
+	path := []uint32{
+		p.number + hdkey.HardenedOffset,
+		coinType + hdkey.HardenedOffset,
+		account + hdkey.HardenedOffset,
+	}
+
+	var key *hdkey.Key
+	switch root.Depth {
+	case 0:
+		if !root.Private {
+			return nil, errors.New("a master public key cannot derive hardened account keys")
+		}
+		k, err := root.DerivePath(path)
+		if err != nil {
+			return nil, err
+		}
+		key = k
+	case 3:
+		if purposes != 1 {
+			return nil, errors.New("an account key belongs to one purpose, select it with -purpose")
+		}
+		key = root
+		path[2] = root.ChildNumber
+	default:
+		return nil, fmt.Errorf("the key must be a master key (depth 0) or an account key (depth 3), not depth %d", root.Depth)
+	}
+
+	return &accountKey{
+		derivedAccount: derivedAccount{
+			Purpose:           p.number,
+			Type:              p.name,
+			Path:              hdkey.FormatPath(path),
+			ExtendedPublicKey: key.Neuter().String(),
+		},
+		purpose: p,
+		key:     key,
+	}, nil
+}
+
+// deriveChain derives count addresses of the receive (0) or change (1) chain.
+func (acct *accountKey) deriveChain(change, start, count uint32, private bool) ([]derivedAddress, error) {
+	net := acct.key.Network
+	chain, err := acct.key.Child(change)
+	if err != nil {
+		return nil, err
+	}
+
+	addrs := make([]derivedAddress, 0, count)
+	for i := start; i < start+count; i++ {
+		k, err := chain.Child(i)
+		if err == hdkey.ErrInvalidChild {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		pubKey := k.PublicKey()
+		addr, err := acct.purpose.address(net, pubKey)
+		if err != nil {
+			return nil, err
+		}
+		d := derivedAddress{
+			Path:      fmt.Sprintf("%s/%d/%d", acct.Path, change, i),
+			Address:   addr.String(),
+			PublicKey: hex.EncodeToString(pubKey),
+		}
+		if private && k.Private {
+			w, err := wif.New(k.Key, net, true)
+			if err != nil {
+				return nil, err
+			}
+			d.WIF = w.String()
+		}
+		addrs = append(addrs, d)
+	}
+	return addrs, nil
+}
+
+func printDerivation(d derivation) {
+	if d.Mnemonic != "" {
+		fmt.Println("Your new mnemonic is")
+		fmt.Println(d.Mnemonic)
+		fmt.Println()
+	}
+
+	for _, a := range d.Accounts {
+		fmt.Printf("%s %s (%s)\n", a.Path, a.Type, d.Network)
+		fmt.Println(a.ExtendedPublicKey)
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "PATH\tADDRESS\tWIF")
+		for _, list := range [][]derivedAddress{a.Receive, a.Change} {
+			for _, addr := range list {
+				// The WIF column stays empty without -private.
+				fmt.Fprintf(w, "%s\t%s\t%s\n", addr.Path, addr.Address, addr.WIF)
+			}
+		}
+		w.Flush()
+		fmt.Println()
+	}
+}
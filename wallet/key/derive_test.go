@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/smallnest/bitcoin/wallet/address"
+	"github.com/smallnest/bitcoin/wallet/bip39"
+	"github.com/smallnest/bitcoin/wallet/hdkey"
+)
+
+const abandonMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+// accountVectors are the published addresses of the "abandon ... about"
+// mnemonic without a passphrase, from BIP49, BIP84 and BIP86 and the
+// usual BIP44 wallet vectors.
+var accountVectors = []struct {
+	net      address.Network
+	purpose  uint32
+	xpub     string
+	receive0 string
+	receive1 string
+	change0  string
+	wif0     string
+}{
+	{
+		net:      address.Mainnet,
+		purpose:  44,
+		xpub:     "xpub6BosfCnifzxcFwrSzQiqu2DBVTshkCXacvNsWGYJVVhhawA7d4R5WSWGFNbi8Aw6ZRc1brxMyWMzG3DSSSSoekkudhUd9yLb6qx39T9nMdj",
+		receive0: "1LqBGSKuX5yYUonjxT5qGfpUsXKYYWeabA",
+		wif0:     "L4p2b9VAf8k5aUahF1JCJUzZkgNEAqLfq8DDdQiyAprQAKSbu8hf",
+	},
+	{
+		net:      address.Mainnet,
+		purpose:  49,
+		receive0: "37VucYSaXLCAsxYyAPfbSi9eh4iEcbShgf",
+	},
+	{
+		net:      address.Testnet,
+		purpose:  49,
+		receive0: "2Mww8dCYPUpKHofjgcXcBCEGmniw9CoaiD2",
+	},
+	{
+		net:      address.Mainnet,
+		purpose:  84,
+		receive0: "bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu",
+		receive1: "bc1qnjg0jd8228aq7egyzacy8cys3knf9xvrerkf9g",
+		change0:  "bc1q8c6fshw2dlwun7ekn9qwf37cu2rn755upcp6el",
+	},
+	{
+		net:      address.Mainnet,
+		purpose:  86,
+		xpub:     "xpub6BgBgsespWvERF3LHQu6CnqdvfEvtMcQjYrcRzx53QJjSxarj2afYWcLteoGVky7D3UKDP9QyrLprQ3VCECoY49yfdDEHGCtMMj92pReUsQ",
+		receive0: "bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr",
+		receive1: "bc1p4qhjn9zdvkux4e44uhx8tc55attvtyu358kutcqkudyccelu0was9fqzwh",
+		change0:  "bc1p3qkhfews2uk44qtvauqyr2ttdsw7svhkl9nkm9s9c3x4ax5h60wqwruhk7",
+	},
+}
+
+func TestDeriveAccounts(t *testing.T) {
+	seed := bip39.NewSeed(abandonMnemonic, "")
+	for _, v := range accountVectors {
+		master, err := hdkey.NewMaster(seed, v.net)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p, err := purposeByNumber(v.purpose)
+		if err != nil {
+			t.Fatal(err)
+		}
+		acct, err := deriveAccount(master, p, 0, 1)
+		if err != nil {
+			t.Fatalf("%d on %s: %v", v.purpose, v.net, err)
+		}
+		if v.xpub != "" && acct.ExtendedPublicKey != v.xpub {
+			t.Errorf("%d on %s: xpub %s, want %s", v.purpose, v.net, acct.ExtendedPublicKey, v.xpub)
+		}
+
+		receive, err := acct.deriveChain(0, 0, 2, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		change, err := acct.deriveChain(1, 0, 1, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, c := range []struct {
+			got  derivedAddress
+			want string
+		}{
+			{receive[0], v.receive0},
+			{receive[1], v.receive1},
+			{change[0], v.change0},
+		} {
+			if c.want != "" && c.got.Address != c.want {
+				t.Errorf("%s on %s: %s, want %s", c.got.Path, v.net, c.got.Address, c.want)
+			}
+		}
+		if v.wif0 != "" && receive[0].WIF != v.wif0 {
+			t.Errorf("%s: WIF %s, want %s", receive[0].Path, receive[0].WIF, v.wif0)
+		}
+		if change[0].WIF != "" {
+			t.Errorf("%s: WIF printed without -private", change[0].Path)
+		}
+
+		coinType := "0'"
+		if v.net != address.Mainnet {
+			coinType = "1'"
+		}
+		if want := fmt.Sprintf("m/%d'/", v.purpose) + coinType + "/0'/0/1"; receive[1].Path != want {
+			t.Errorf("path %s, want %s", receive[1].Path, want)
+		}
+	}
+}
+
+// TestDeriveFromAccountKey checks that deriving from the account xpub
+// gives the same addresses as deriving from the master key.
+func TestDeriveFromAccountKey(t *testing.T) {
+	master, err := hdkey.NewMaster(bip39.NewSeed(abandonMnemonic, ""), address.Mainnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, _ := purposeByNumber(84)
+	acct, err := deriveAccount(master, p, 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	xpub, err := hdkey.Parse(acct.ExtendedPublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromXpub, err := deriveAccount(xpub, p, 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addrs, err := fromXpub.deriveChain(0, 0, 1, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addrs[0].Address != "bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu" || addrs[0].WIF != "" {
+		t.Errorf("from the xpub: %+v", addrs[0])
+	}
+
+	if _, err := deriveAccount(xpub, p, 0, 4); err == nil {
+		t.Error("an account key was used for four purposes")
+	}
+	if _, err := deriveAccount(master.Neuter(), p, 0, 1); err == nil {
+		t.Error("a master xpub derived a hardened account")
+	}
+	if _, err := purposeByNumber(45); err == nil {
+		t.Error("purpose 45 is not supported")
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// The key tool is offline by default: it never touches the network and
+// never starts other programs, so it can run on an air-gapped machine.
+// Looking up a new address on a block explorer is opt-in with -explorer,
+// which leaks the address to the explorer.
+var explorer = flag.String("explorer", "", "Open new addresses in a block explorer, e.g. https://mempool.space/address/{address}. "+
+	"{address} and {network} (mainnet or testnet) are replaced. (optional, offline by default)")
+
+// explorerURL returns the explorer page of addr, or "" when no explorer
+// is configured.
+func explorerURL(addr string) string {
+	if *explorer == "" {
+		return ""
+	}
+	tmpl := *explorer
+	if !strings.Contains(tmpl, "{address}") {
+		tmpl += "{address}"
+	}
+	return strings.NewReplacer("{address}", addr, "{network}", network().String()).Replace(tmpl)
+}
+
+// lookupAddress opens addr in the configured block explorer, if any.
+// Failing to start a browser, e.g. on a headless server, is not an error:
+// the URL is printed instead.
+func lookupAddress(addr string) {
+	url := explorerURL(addr)
+	if url == "" {
+		return
+	}
+	if err := openbrowser(url); err != nil {
+		fmt.Fprintf(os.Stderr, "Cannot open a browser (%v), see %s\n", err, url)
+	}
+}
+
+func openbrowser(url string) error {
+	switch runtime.GOOS {
+	case "linux":
+		return exec.Command("xdg-open", url).Start()
+	case "windows":
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", url).Start()
+	case "darwin":
+		return exec.Command("open", url).Start()
+	default:
+		return fmt.Errorf("unsupported platform %s", runtime.GOOS)
+	}
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/smallnest/bitcoin/wallet/address"
+	"github.com/smallnest/bitcoin/wallet/ecc"
+	"github.com/smallnest/bitcoin/wallet/hdkey"
+	"github.com/smallnest/bitcoin/wallet/wif"
+)
+
+type inspectedKey struct {
+	// Kind is what the input was: wif, private key, public key or
+	// extended key.
+	Kind string `json:"kind"`
+	// Network is the network encoded in a WIF or an extended key.
+	Network    string `json:"network,omitempty"`
+	Compressed bool   `json:"compressed"`
+	PrivateKey string `json:"private_key,omitempty"`
+	PublicKey  string `json:"public_key"`
+	// XOnlyKey is the BIP340 x-only public key, the taproot internal key.
+	XOnlyKey string           `json:"x_only_public_key"`
+	Extended *inspectedHDKey  `json:"extended_key,omitempty"`
+	Networks []inspectedChain `json:"networks"`
+}
+
+type inspectedHDKey struct {
+	Depth             byte   `json:"depth"`
+	ParentFingerprint string `json:"parent_fingerprint"`
+	ChildNumber       uint32 `json:"child_number"`
+	Hardened          bool   `json:"hardened"`
+	ChainCode         string `json:"chain_code"`
+	Fingerprint       string `json:"fingerprint"`
+	XPub              string `json:"xpub"`
+}
+
+// inspectedChain lists the addresses of the key on one network.
+type inspectedChain struct {
+	Network string `json:"network"`
+	WIF     string `json:"wif,omitempty"`
+	// Addresses maps each address type to the address of the key.
+	// Uncompressed keys only have a P2PKH address.
+	Addresses map[string]string `json:"addresses"`
+}
+
+// runInspect validates an existing key and lists the addresses it controls
+// on every network. The key is a WIF, a hex private key (32 bytes) or
+// public key (33 or 65 bytes), or an extended key. As 32 bytes of hex may
+// as well be an x-only public key, hex private keys need -private. They
+// are compressed unless -compressed=false.
+//
+//	key [-compressed=false] inspect [-json] [-private] <WIF|hex|xpub|xprv>
+func runInspect(args []string) error {
+	fs := flag.NewFlagSet("inspect", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "Print JSON instead of text.")
+	private := fs.Bool("private", false, "Read 32 bytes of hex as a private key.")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return errors.New("usage: key inspect [-json] [-private] <WIF|hex|xpub|xprv>")
+	}
+
+	out, err := inspectKey(strings.TrimSpace(fs.Arg(0)), *private, *compressed)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+	printInspectedKey(out)
+	return nil
+}
+
+// inspectKey reads s as described at runInspect. Hex is only read as a
+// private key if private is set, and compressed applies to hex private
+// keys only.
+func inspectKey(s string, private, compressed bool) (*inspectedKey, error) {
+	out := &inspectedKey{}
+	var privateKey, publicKey []byte
+
+	raw, hexErr := hex.DecodeString(s)
+	switch {
+	case hexErr == nil && private:
+		if len(raw) != 32 {
+			return nil, fmt.Errorf("hex private key of %d bytes, want 32", len(raw))
+		}
+		if !ecc.ValidScalar(raw) {
+			return nil, errors.New("invalid private key: out of range")
+		}
+		out.Kind, out.Compressed, privateKey = "private key", compressed, raw
+	case hexErr == nil && len(raw) == 32:
+		return nil, errors.New("32 bytes of hex are a private key or an x-only public key, give -private for a private key")
+	case hexErr == nil && (len(raw) == 33 || len(raw) == 65):
+		if _, err := ecc.ParsePoint(raw); err != nil {
+			return nil, fmt.Errorf("invalid public key: %v", err)
+		}
+		out.Kind, out.Compressed, publicKey = "public key", len(raw) == 33, raw
+	case hexErr == nil:
+		return nil, fmt.Errorf("hex key of %d bytes, want a 33 or 65 byte public key, or a 32 byte private key with -private", len(raw))
+	case strings.HasPrefix(s, "xp") || strings.HasPrefix(s, "tp"):
+		k, err := hdkey.Parse(s)
+		if err != nil {
+			return nil, err
+		}
+		out.Kind, out.Network, out.Compressed = "extended public key", k.Network.String(), true
+		if k.Private {
+			out.Kind, privateKey = "extended private key", k.Key
+		}
+		publicKey = k.PublicKey()
+		fingerprint := k.Fingerprint()
+		out.Extended = &inspectedHDKey{
+			Depth:             k.Depth,
+			ParentFingerprint: hex.EncodeToString(k.ParentFingerprint[:]),
+			ChildNumber:       k.ChildNumber &^ hdkey.HardenedOffset,
+			Hardened:          k.IsHardened(),
+			ChainCode:         hex.EncodeToString(k.ChainCode[:]),
+			Fingerprint:       hex.EncodeToString(fingerprint[:]),
+			XPub:              k.Neuter().String(),
+		}
+	default:
+		w, err := wif.Decode(s)
+		if err != nil {
+			return nil, fmt.Errorf("not a WIF, hex or extended key: %v", err)
+		}
+		out.Kind, out.Network, out.Compressed = "wif", w.Network.String(), w.Compressed
+		privateKey = w.PrivateKey[:]
+	}
+
+	if privateKey != nil {
+		var err error
+		if publicKey, err = ecc.Default.PublicKey(privateKey, out.Compressed); err != nil {
+			return nil, err
+		}
+		out.PrivateKey = hex.EncodeToString(privateKey)
+	}
+	out.PublicKey = hex.EncodeToString(publicKey)
+	point, err := ecc.ParsePoint(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	compressedKey := point.Serialize(true)
+	out.XOnlyKey = hex.EncodeToString(compressedKey[1:])
+
+	for _, net := range address.Networks {
+		chain := inspectedChain{Network: net.String(), Addresses: make(map[string]string)}
+		if privateKey != nil {
+			w, err := wif.New(privateKey, net, out.Compressed)
+			if err != nil {
+				return nil, err
+			}
+			chain.WIF = w.String()
+		}
+		for _, p := range purposes {
+			// segwit only allows compressed keys
+			if p.number != 44 && !out.Compressed {
+				continue
+			}
+			addr, err := p.address(net, publicKey)
+			if err != nil {
+				return nil, err
+			}
+			chain.Addresses[p.name] = addr.String()
+		}
+		out.Networks = append(out.Networks, chain)
+	}
+	return out, nil
+}
+
+func printInspectedKey(k *inspectedKey) {
+	fmt.Printf("%-20s %s\n", "Kind:", k.Kind)
+	if k.Network != "" {
+		fmt.Printf("%-20s %s\n", "Network:", k.Network)
+	}
+	fmt.Printf("%-20s %v\n", "Compressed:", k.Compressed)
+	if k.PrivateKey != "" {
+		fmt.Printf("%-20s %s\n", "Private key:", k.PrivateKey)
+	}
+	fmt.Printf("%-20s %s\n", "Public key:", k.PublicKey)
+	fmt.Printf("%-20s %s\n", "X-only public key:", k.XOnlyKey)
+	if e := k.Extended; e != nil {
+		fmt.Printf("%-20s %d\n", "Depth:", e.Depth)
+		fmt.Printf("%-20s %s\n", "Parent fingerprint:", e.ParentFingerprint)
+		child := fmt.Sprint(e.ChildNumber)
+		if e.Hardened {
+			child += "'"
+		}
+		fmt.Printf("%-20s %s\n", "Child number:", child)
+		fmt.Printf("%-20s %s\n", "Fingerprint:", e.Fingerprint)
+		fmt.Printf("%-20s %s\n", "Chain code:", e.ChainCode)
+		fmt.Printf("%-20s %s\n", "Extended public key:", e.XPub)
+	}
+	for _, chain := range k.Networks {
+		fmt.Printf("\n%s\n", chain.Network)
+		if chain.WIF != "" {
+			fmt.Printf("  %-12s %s\n", "wif", chain.WIF)
+		}
+		for _, p := range purposes {
+			if addr, ok := chain.Addresses[p.name]; ok {
+				fmt.Printf("  %-12s %s\n", p.name, addr)
+			}
+		}
+	}
+}
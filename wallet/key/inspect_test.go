@@ -0,0 +1,111 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const (
+	// The BIP32 test vector 1 master key.
+	vector1XPub = "xpub661MyMwAqRbcFtXgS5sYJABqqG9YLmC4Q1Rdap9gSE8NqtwybGhePY2gZ29ESFjqJoCu1Rupje8YtGqsefD265TMg7usUDFdp6W1EGMcet8"
+	vector1XPrv = "xprv9s21ZrQH143K3QTDL4LXw2F7HEK3wJUD2nW2nRk4stbPy6cq3jPPqjiChkVvvNKmPGJxWUtg6LnF5kejMRNNU3TGtRBeJgk33yuGBxrMPHi"
+)
+
+func TestInspectKey(t *testing.T) {
+	const (
+		one            = "0000000000000000000000000000000000000000000000000000000000000001"
+		onePub         = "0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"
+		onePubFull     = "0479be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798483ada7726a3c4655da4fbfc0e1108a8fd17b448a68554199c47d08ffb10d4b8"
+		oneP2PKH       = "1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH"
+		oneP2PKHFull   = "1EHNa6Q4Jz2uvNExL497mE43ikXhwF6kZm"
+		oneP2WPKH      = "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"
+		oneWIF         = "KwDiBf89QgGbjEhKnhXJuH7LrciVrZi3qYjgd9M7rFU73sVHnoWn"
+		oneWIFFull     = "5HpHagT65TZzG1PH3CSu63k8DbpvD8s5ip4nEB3kEsreAnchuDf"
+		vector1Pub     = "0339a36013301597daef41fbe593a02cc513d0b55527ec2df1050e2e8ff49c85c2"
+		vector1Address = "15mKKb2eos1hWa6tisdPwwDC1a5J1y9nma"
+	)
+
+	for _, v := range []struct {
+		input      string
+		private    bool
+		compressed bool
+		kind       string
+		network    string
+		wantCompr  bool
+		privateKey string
+		publicKey  string
+		wif        string
+		p2pkh      string
+		p2wpkh     string
+	}{
+		{oneWIF, false, true, "wif", "mainnet", true, one, onePub, oneWIF, oneP2PKH, oneP2WPKH},
+		// The WIF says whether the key is compressed, not -compressed.
+		{oneWIFFull, false, true, "wif", "mainnet", false, one, onePubFull, oneWIFFull, oneP2PKHFull, ""},
+		{one, true, true, "private key", "", true, one, onePub, oneWIF, oneP2PKH, oneP2WPKH},
+		{one, true, false, "private key", "", false, one, onePubFull, oneWIFFull, oneP2PKHFull, ""},
+		{onePub, false, false, "public key", "", true, "", onePub, "", oneP2PKH, oneP2WPKH},
+		{onePubFull, false, true, "public key", "", false, "", onePubFull, "", oneP2PKHFull, ""},
+		{vector1XPub, false, true, "extended public key", "mainnet", true, "", vector1Pub, "", vector1Address, ""},
+		{vector1XPrv, false, true, "extended private key", "mainnet", true,
+			"e8f32e723decf4051aefac8e2c93c9c5b214313817cdb01a1494b917c8436b35", vector1Pub, "", vector1Address, ""},
+	} {
+		k, err := inspectKey(v.input, v.private, v.compressed)
+		if err != nil {
+			t.Errorf("%s: %v", v.input, err)
+			continue
+		}
+		if k.Kind != v.kind || k.Network != v.network || k.Compressed != v.wantCompr {
+			t.Errorf("%s: got %s on %q compressed %v, want %s on %q compressed %v",
+				v.input, k.Kind, k.Network, k.Compressed, v.kind, v.network, v.wantCompr)
+		}
+		if k.PrivateKey != v.privateKey || k.PublicKey != v.publicKey {
+			t.Errorf("%s: got keys %q and %s, want %q and %s", v.input, k.PrivateKey, k.PublicKey, v.privateKey, v.publicKey)
+		}
+		if k.XOnlyKey != v.publicKey[2:66] {
+			t.Errorf("%s: x-only key %s, want %s", v.input, k.XOnlyKey, v.publicKey[2:66])
+		}
+		mainnet := k.Networks[0]
+		if mainnet.Network != "mainnet" {
+			t.Fatalf("%s: first network %s, want mainnet", v.input, mainnet.Network)
+		}
+		if v.wif != "" && mainnet.WIF != v.wif {
+			t.Errorf("%s: WIF %s, want %s", v.input, mainnet.WIF, v.wif)
+		}
+		if v.privateKey == "" && mainnet.WIF != "" {
+			t.Errorf("%s: WIF %s for a public key", v.input, mainnet.WIF)
+		}
+		if got := mainnet.Addresses["p2pkh"]; got != v.p2pkh {
+			t.Errorf("%s: p2pkh %s, want %s", v.input, got, v.p2pkh)
+		}
+		if got, ok := mainnet.Addresses["p2wpkh"]; ok != v.wantCompr || v.p2wpkh != "" && got != v.p2wpkh {
+			t.Errorf("%s: p2wpkh %q, want %q", v.input, got, v.p2wpkh)
+		}
+		if e := k.Extended; (e != nil) != strings.HasPrefix(v.input, "xp") {
+			t.Errorf("%s: extended key %+v", v.input, e)
+		} else if e != nil && (e.XPub != vector1XPub || e.Fingerprint != "3442193e" || e.Depth != 0) {
+			t.Errorf("%s: got %+v", v.input, *e)
+		}
+	}
+}
+
+func TestInspectKeyInvalid(t *testing.T) {
+	for _, v := range []struct {
+		input   string
+		private bool
+		want    string
+	}{
+		// Without -private 32 bytes may as well be an x-only public key.
+		{"0000000000000000000000000000000000000000000000000000000000000001", false, "-private"},
+		{"0000000000000000000000000000000000000000000000000000000000000000", true, "out of range"},
+		{"0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798", true, "want 32"},
+		{"0579be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798", false, "invalid public key"},
+		{"00112233", false, "4 bytes"},
+		{"KwDiBf89QgGbjEhKnhXJuH7LrciVrZi3qYjgd9M7rFU73sVHnoWo", false, "not a WIF"},
+		{vector1XPrv[:len(vector1XPrv)-1] + "j", false, ""},
+	} {
+		_, err := inspectKey(v.input, v.private, true)
+		if err == nil || !strings.Contains(err.Error(), v.want) {
+			t.Errorf("%s: got %v, want an error with %q", v.input, err, v.want)
+		}
+	}
+}
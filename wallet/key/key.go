@@ -2,40 +2,73 @@ package main
 
 import (
 	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
-	"math"
-	"math/rand"
-	"os/exec"
-	"runtime"
-	"time"
+	"os"
 
 	qrcode "github.com/skip2/go-qrcode"
+	"github.com/smallnest/bitcoin/wallet/address"
 	"github.com/smallnest/bitcoin/wallet/base58check"
-	secp256k1 "github.com/toxeus/go-secp256k1"
+	"github.com/smallnest/bitcoin/wallet/bip21"
+	"github.com/smallnest/bitcoin/wallet/ecc"
+	"github.com/smallnest/bitcoin/wallet/entropy"
+	"github.com/smallnest/bitcoin/wallet/wif"
 	"golang.org/x/crypto/ripemd160"
 )
 
 var (
-	testnet = flag.Bool("testnet", false, "Whether or not to use the bitcoin testnet. (optional, defaults false)")
+	testnet    = flag.Bool("testnet", false, "Whether or not to use the bitcoin testnet. (optional, defaults false)")
+	compressed = flag.Bool("compressed", true, "Whether or not to use a compressed public key, which gives smaller transactions. (optional, defaults true)")
+	dice       = flag.String("dice", "", "Six sided dice rolls (digits 1-6) to mix into the system entropy. (optional)")
+	coins      = flag.String("coins", "", "Coin flips (H/T or 1/0) to mix into the system entropy. (optional)")
 )
 
 // A Bitcoin wallet can refer to either a wallet program or a wallet file.
 // Wallet programs create public keys to receive satoshis and use the corresponding private keys to spend those satoshis. // Wallet files store private keys and (optionally) other information related to transactions for the wallet program.
 //
 // This program is a part of wallet program: it generates private keys, derives the corresponding public keys.
+//
+// Without a command it creates a new HD wallet and lists its addresses, see derive.go.
+// See commands.go for the other commands.
 func main() {
+	flag.Usage = usage
 	flag.Parse()
 
-	var privateKeyPrefix string
+	if flag.NArg() > 0 {
+		runCommand(flag.Arg(0), flag.Args()[1:])
+		return
+	}
+	runCommand("derive", nil)
+}
+
+// newKey is the JSON output of runNew.
+type newKey struct {
+	Network    string `json:"network"`
+	WIF        string `json:"wif"`
+	PublicKey  string `json:"public_key"`
+	Compressed bool   `json:"compressed"`
+	// Addresses maps each address type to the address of the key. Segwit
+	// types are only listed for compressed keys.
+	Addresses map[string]string `json:"addresses"`
+}
+
+// runNew generates a single private key and its P2PKH address, or with
+// -json the addresses of every type.
+//
+//	key [-testnet] [-explorer URL] new [-json]
+func runNew(args []string) error {
+	fs := flag.NewFlagSet("new", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "Print the key, public key and all address types as JSON.")
+	fs.Parse(args)
+
 	var publicKeyPrefix string
 
 	if *testnet {
-		privateKeyPrefix = "EF"
 		publicKeyPrefix = "6F"
 	} else {
-		privateKeyPrefix = "80"
 		publicKeyPrefix = "00"
 	}
 
@@ -45,7 +78,10 @@ func main() {
 	// 0x01 and 0xFFFF FFFF FFFF FFFF FFFF FFFF FFFF FFFE BAAE DCE6 AF48 A03B BFD2 5E8C D036 4140,
 	// representing nearly the entire range of 2256-1 values.
 	// The range is governed by the secp256k1 ECDSA encryption standard used by Bitcoin.
-	privateKey := generatePrivateKey()
+	privateKey, err := generatePrivateKey(entropySource())
+	if err != nil {
+		return err
+	}
 
 	// 1. Take a private key.
 	// 2. Add a 0x80 byte in front of it for mainnet addresses or 0xef for testnet addresses.
@@ -55,19 +91,31 @@ func main() {
 	// 6. Take the first four bytes of the second SHA-256 hash; this is the checksum.
 	// 7. Add the four checksum bytes from point 5 at the end of the extended key from point 2.
 	// 8. Convert the result from a byte string into a Base58 string using Base58Check encoding.
-	privateKeyWif := base58check.Encode(privateKeyPrefix, privateKey)
+	privateKeyWif, err := wif.New(privateKey, network(), *compressed)
+	if err != nil {
+		return err
+	}
 
 	// Bitcoin addresses, which are base58-encoded strings containing an address version number, the hash,
 	// and an error-detection checksum to catch typos.
 	// A 20-byte hash formatted using base58check to produce either a P2PKH or P2SH Bitcoin address.
 	// Currently the most common way users exchange payment information.
 
-	publicKey := generatePublicKey(privateKey)
+	// The address depends on the public key encoding, so it must match the
+	// compression flag of the WIF.
+	publicKey := generatePublicKey(privateKey, *compressed)
 	//There is also a prefix on the public key
 	//This is known as the Network ID Byte, or the version byte
 	//6f is the testnet prefix
 	//00 is the mainnet prefix
-	publicKeyEncoded := base58check.Encode(publicKeyPrefix, publicKey)
+	publicKeyEncoded, err := base58check.Encode(publicKeyPrefix, publicKey)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		return printNewKey(privateKeyWif, privateKey)
+	}
 
 	//Print the keys
 	fmt.Println("Your private key is")
@@ -76,28 +124,47 @@ func main() {
 	fmt.Println("Your address is")
 	fmt.Println(publicKeyEncoded)
 
-	// Display address info
-	openbrowser("https://blockchain.info/address/" + publicKeyEncoded)
+	// Display address info, only if asked to as it leaks the address
+	lookupAddress(publicKeyEncoded)
 
 	// Print QRCode
-	qrInTerminal("bitcoin:" + publicKeyEncoded)
+	qrInTerminal((&bip21.URI{Address: publicKeyEncoded}).String())
+	return nil
 }
 
-func openbrowser(url string) {
-	var err error
-	switch runtime.GOOS {
-	case "linux":
-		err = exec.Command("xdg-open", url).Start()
-	case "windows":
-		err = exec.Command("rundll32", "url.dll,FileProtocolHandler", url).Start()
-	case "darwin":
-		err = exec.Command("open", url).Start()
-	default:
-		err = fmt.Errorf("unsupported platform")
-	}
+func printNewKey(privateKeyWif *wif.WIF, privateKey []byte) error {
+	publicKey, err := ecc.Default.PublicKey(privateKey, privateKeyWif.Compressed)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
+	out := newKey{
+		Network:    privateKeyWif.Network.String(),
+		WIF:        privateKeyWif.String(),
+		PublicKey:  hex.EncodeToString(publicKey),
+		Compressed: privateKeyWif.Compressed,
+		Addresses:  make(map[string]string),
+	}
+	for _, p := range purposes {
+		if p.number != 44 && !privateKeyWif.Compressed {
+			continue
+		}
+		addr, err := p.address(privateKeyWif.Network, publicKey)
+		if err != nil {
+			return err
+		}
+		out.Addresses[p.name] = addr.String()
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// network returns the network selected by the -testnet flag.
+func network() address.Network {
+	if *testnet {
+		return address.Testnet
+	}
+	return address.Mainnet
 }
 
 func qrInTerminal(content string) {
@@ -128,21 +195,17 @@ func qrInTerminal(content string) {
 		fmt.Println()
 	}
 }
-func generatePublicKey(privateKeyBytes []byte) []byte {
+func generatePublicKey(privateKeyBytes []byte, compressed bool) []byte {
 	//Generate the public key from the private key.
 	//Unfortunately golang ecdsa package does not include a
-	//secp256k1 curve as this is fairly specific to bitcoin so this uses wrapped the official bitcoin/c-secp256k1 with cgo.
-	var privateKeyBytes32 [32]byte
-	copy(privateKeyBytes32[:], privateKeyBytes)
-
-	secp256k1.Start()
-	publicKeyBytes, success := secp256k1.Pubkey_create(privateKeyBytes32, false)
-	if !success {
+	//secp256k1 curve as this is fairly specific to bitcoin, so ecc.Default is
+	//either a pure Go implementation or, built with the secp256k1_cgo tag,
+	//the official bitcoin/c-secp256k1 wrapped with cgo.
+	publicKeyBytes, err := ecc.Default.PublicKey(privateKeyBytes, compressed)
+	if err != nil {
 		log.Fatal("Failed to create public key.")
 	}
 
-	secp256k1.Stop()
-
 	//Next we get a sha256 hash of the public key generated
 	//via ECDSA, and then get a ripemd160 hash of the sha256 hash.
 	shaHash := sha256.New()
@@ -156,17 +219,38 @@ func generatePublicKey(privateKeyBytes []byte) []byte {
 	return ripeHashedBytes
 }
 
-// generate 256 bits of "random" data BTC for private key.
-func generatePrivateKey() []byte {
-	bytes := make([]byte, 32)
-	for i := 0; i < 32; i++ {
-		//This is not "cryptographically random"
-		bytes[i] = byte(randInt(0, math.MaxUint8))
+// generate 256 bits of cryptographically secure random data for private key.
+// The result is always a valid secp256k1 private key.
+func generatePrivateKey(src entropy.Source) ([]byte, error) {
+	key, err := entropy.Scalar(src)
+	if err != nil {
+		return nil, err
 	}
-	return bytes
+	return key[:], nil
 }
 
-func randInt(min int, max int) uint8 {
-	rand.Seed(time.Now().UTC().UnixNano())
-	return uint8(min + rand.Intn(max-min))
+// entropySource returns crypto/rand, mixed with the dice rolls or coin flips
+// given on the command line if any.
+func entropySource() entropy.Source {
+	var user *entropy.UserEntropy
+	var err error
+	switch {
+	case *dice != "":
+		user, err = entropy.ParseDice(*dice)
+	case *coins != "":
+		user, err = entropy.ParseCoins(*coins)
+	default:
+		return entropy.Default
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	stats := user.Stats()
+	fmt.Fprintf(os.Stderr, "Mixing %d %s outcomes: %.1f bits (%.1f estimated), chi-square %.2f, counts %v\n",
+		stats.Count, user.Kind, stats.MaxBits, stats.EstimatedBits, stats.ChiSquare, stats.Counts)
+	if stats.Biased {
+		fmt.Fprintln(os.Stderr, "Warning: the outcomes look biased, they add less entropy than expected.")
+	}
+	return entropy.Mix(entropy.Default, user.Bytes())
 }
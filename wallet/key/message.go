@@ -0,0 +1,156 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/smallnest/bitcoin/wallet/address"
+	"github.com/smallnest/bitcoin/wallet/bip322"
+	"github.com/smallnest/bitcoin/wallet/ecc"
+	"github.com/smallnest/bitcoin/wallet/message"
+	"github.com/smallnest/bitcoin/wallet/wif"
+)
+
+// runSignMessage signs a message with the key of an address. P2PKH
+// addresses get a signature in the Bitcoin Core signed message format,
+// segwit and taproot addresses a BIP322 simple signature, unless -format
+// says otherwise. The message is signed byte for byte as given: one quoted
+// argument, or the contents of -file.
+//
+//	key signmessage -wif WIF [-address ADDR] [-format legacy|simple|full] <message>|-file FILE
+//	key signmessage -wallet wallet.json -address ADDR [-format legacy|simple|full] <message>|-file FILE
+func runSignMessage(args []string) error {
+	fs := flag.NewFlagSet("signmessage", flag.ExitOnError)
+	key := fs.String("wif", "", "Private key to sign with.")
+	walletPath := fs.String("wallet", "", "Wallet file holding the key of -address, instead of -wif.")
+	addr := fs.String("address", "", "Address whose key signs. With -wif it defaults to the P2PKH address of the key.")
+	format := fs.String("format", "", "Signature format: legacy, simple or full (BIP322). Defaults to legacy for p2pkh and simple otherwise.")
+	file := fs.String("file", "", "File holding the message, - for stdin, instead of the argument.")
+	fs.Parse(args)
+
+	msg, err := readMessage(fs, *file)
+	if err != nil {
+		return err
+	}
+
+	var k *wif.WIF
+	switch {
+	case *key != "":
+		k, err = wif.Decode(*key)
+	case *walletPath != "" && *addr != "":
+		k, err = walletKey(*walletPath, *addr)
+	default:
+		return errors.New("signmessage needs -wif, or -wallet and -address")
+	}
+	if err != nil {
+		return err
+	}
+
+	pubKey, err := ecc.Default.PublicKey(k.PrivateKey[:], k.Compressed)
+	if err != nil {
+		return err
+	}
+	a := address.NewP2PKHFromKey(k.Network, pubKey)
+	if *addr != "" {
+		if a, err = address.Parse(*addr); err != nil {
+			return err
+		}
+	}
+	if *format == "" {
+		*format = "simple"
+		if a.Kind == address.P2PKH {
+			*format = "legacy"
+		}
+	}
+
+	var signature string
+	switch *format {
+	case "legacy":
+		if a.Kind != address.P2PKH {
+			return fmt.Errorf("legacy signatures are for p2pkh addresses, not %s", a.Kind)
+		}
+		// Like BIP322, refuse to sign for an address of another key.
+		if !address.NewP2PKHFromKey(a.Network, pubKey).Equal(a) {
+			return bip322.ErrKeyMismatch
+		}
+		signature, err = message.Sign(k, msg)
+	case "simple":
+		signature, err = bip322.SignSimple(k, a, msg)
+	case "full":
+		signature, err = bip322.SignFull(k, a, msg)
+	default:
+		return fmt.Errorf("unknown signature format %q", *format)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Println(signature)
+	return nil
+}
+
+// walletKey looks up the private key of an address in a wallet file.
+func walletKey(path, addr string) (*wif.WIF, error) {
+	a, err := address.Parse(addr)
+	if err != nil {
+		return nil, err
+	}
+	w, err := unlockWallet(path)
+	if err != nil {
+		return nil, err
+	}
+	defer w.Lock()
+	return w.FindKey(a, 20)
+}
+
+// runVerifyMessage verifies a signed message against an address: a
+// Bitcoin Core signed message for P2PKH addresses, or a BIP322 simple or
+// full signature for P2PKH, P2WPKH, P2SH-P2WPKH and P2TR addresses.
+//
+//	key verifymessage -address ADDR -signature BASE64 <message>|-file FILE
+func runVerifyMessage(args []string) error {
+	fs := flag.NewFlagSet("verifymessage", flag.ExitOnError)
+	addr := fs.String("address", "", "Address that signed the message.")
+	signature := fs.String("signature", "", "Base64 signature.")
+	file := fs.String("file", "", "File holding the message, - for stdin, instead of the argument.")
+	fs.Parse(args)
+
+	if *addr == "" || *signature == "" {
+		return errors.New("verifymessage needs -address, -signature and the message")
+	}
+	msg, err := readMessage(fs, *file)
+	if err != nil {
+		return err
+	}
+	if err := bip322.Verify(*addr, msg, *signature); err != nil {
+		return err
+	}
+	fmt.Println("The signature is valid")
+	return nil
+}
+
+// readMessage returns the message of signmessage and verifymessage
+// unchanged: the only argument left after the flags, or the contents of
+// file, or of stdin if file is "-". Joining several arguments would lose
+// the original spacing, so a message with spaces has to be quoted.
+func readMessage(fs *flag.FlagSet, file string) (string, error) {
+	if file == "" {
+		if fs.NArg() != 1 {
+			return "", fmt.Errorf("%s needs the message as one quoted argument, or -file", fs.Name())
+		}
+		return fs.Arg(0), nil
+	}
+	if fs.NArg() != 0 {
+		return "", fmt.Errorf("%s takes the message from -file or an argument, not both", fs.Name())
+	}
+	var b []byte
+	var err error
+	if file == "-" {
+		b, err = io.ReadAll(os.Stdin)
+	} else {
+		b, err = os.ReadFile(file)
+	}
+	return string(b), err
+}
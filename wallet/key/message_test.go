@@ -0,0 +1,58 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/smallnest/bitcoin/wallet/bip322"
+)
+
+func TestReadMessage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "message.txt")
+	const contents = "  two  spaces\nand a newline\n"
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, v := range []struct {
+		args []string
+		want string
+		ok   bool
+	}{
+		{[]string{"Hello  World"}, "Hello  World", true},
+		{[]string{" padded\t"}, " padded\t", true},
+		{[]string{""}, "", true},
+		{[]string{"-file", path}, contents, true},
+		{nil, "", false},
+		{[]string{"Hello", "World"}, "", false},
+		{[]string{"-file", path, "Hello"}, "", false},
+		{[]string{"-file", path + ".missing"}, "", false},
+	} {
+		fs := flag.NewFlagSet("signmessage", flag.ContinueOnError)
+		file := fs.String("file", "", "")
+		if err := fs.Parse(v.args); err != nil {
+			t.Fatal(err)
+		}
+		got, err := readMessage(fs, *file)
+		if (err == nil) != v.ok || got != v.want {
+			t.Errorf("%q: got %q, %v", v.args, got, err)
+		}
+	}
+}
+
+func TestSignMessageKeyMismatch(t *testing.T) {
+	// The address is the P2PKH address of another key.
+	const key = "L4rK1yDtCWekvXuE6oXD9jCYfFNV2cWRpVuPLBcCU2z8TrisoyY1"
+	for _, format := range []string{"legacy", "full"} {
+		err := runSignMessage([]string{"-wif", key, "-address", "1HZwkjkeaoZfTSaJxDw6aKkxp45agDiEzN", "-format", format, "Hello World"})
+		if err != bip322.ErrKeyMismatch {
+			t.Errorf("%s: %v, want ErrKeyMismatch", format, err)
+		}
+	}
+	// Its own address signs.
+	if err := runSignMessage([]string{"-wif", key, "-address", "1F3sAm6ZtwLAUnj7d38pGFxtP3RVEvtsbV", "Hello World"}); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/smallnest/bitcoin/wallet/bip39"
+	"github.com/smallnest/bitcoin/wallet/hdkey"
+)
+
+// runMnemonic dispatches the BIP39 commands:
+//
+//	key mnemonic new [-words 24] [-language english] [-passphrase ...]
+//	key mnemonic restore [-language english] [-passphrase ...] <words...>
+func runMnemonic(args []string) error {
+	if len(args) == 0 {
+		return errors.New("mnemonic needs a sub command: new or restore")
+	}
+	switch args[0] {
+	case "new":
+		return runMnemonicNew(args[1:])
+	case "restore":
+		return runMnemonicRestore(args[1:])
+	}
+	return fmt.Errorf("unknown mnemonic command %q", args[0])
+}
+
+func runMnemonicNew(args []string) error {
+	fs := flag.NewFlagSet("mnemonic new", flag.ExitOnError)
+	words := fs.Int("words", 24, "Number of words: 12, 15, 18, 21 or 24.")
+	language := fs.String("language", "english", "Wordlist: "+wordlistNames()+".")
+	passphrase := fs.String("passphrase", "", "Optional BIP39 passphrase, it is needed again to restore the seed.")
+	fs.Parse(args)
+
+	wl, err := bip39.WordlistByName(*language)
+	if err != nil {
+		return err
+	}
+	bits, err := bip39.WordsToBits(*words)
+	if err != nil {
+		return err
+	}
+
+	ent, err := bip39.NewEntropy(entropySource(), bits)
+	if err != nil {
+		return err
+	}
+	mnemonic, err := bip39.NewMnemonic(ent, wl)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Your mnemonic is")
+	fmt.Println(mnemonic)
+	return printSeed(bip39.NewSeed(mnemonic, *passphrase))
+}
+
+func runMnemonicRestore(args []string) error {
+	fs := flag.NewFlagSet("mnemonic restore", flag.ExitOnError)
+	language := fs.String("language", "", "Wordlist: "+wordlistNames()+". (optional, detected from the words)")
+	passphrase := fs.String("passphrase", "", "The BIP39 passphrase used when the mnemonic was created.")
+	fs.Parse(args)
+
+	mnemonic := strings.Join(fs.Args(), " ")
+	if mnemonic == "" {
+		return errors.New("mnemonic restore needs the words of the mnemonic")
+	}
+
+	var candidates []*bip39.Wordlist
+	if *language != "" {
+		wl, err := bip39.WordlistByName(*language)
+		if err != nil {
+			return err
+		}
+		candidates = []*bip39.Wordlist{wl}
+	} else {
+		candidates = bip39.DetectWordlist(mnemonic)
+		if len(candidates) == 0 {
+			candidates = []*bip39.Wordlist{bip39.English}
+		}
+	}
+
+	// Report the error of the first candidate, usually the only one.
+	var firstErr error
+	for _, wl := range candidates {
+		if err := bip39.Validate(mnemonic, wl); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		fmt.Printf("Your mnemonic is valid (%s)\n", wl.Name)
+		return printSeed(bip39.NewSeed(mnemonic, *passphrase))
+	}
+	return firstErr
+}
+
+// validateMnemonic checks the mnemonic against every wordlist it may be
+// written in.
+func validateMnemonic(mnemonic string) error {
+	candidates := bip39.DetectWordlist(mnemonic)
+	if len(candidates) == 0 {
+		return errors.New("the mnemonic words are not in any BIP39 wordlist")
+	}
+	var err error
+	for _, wl := range candidates {
+		if err = bip39.Validate(mnemonic, wl); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// printSeed prints the seed and the BIP32 master key derived from it.
+func printSeed(seed []byte) error {
+	master, err := hdkey.NewMaster(seed, network())
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Your seed is")
+	fmt.Println(hex.EncodeToString(seed))
+	fmt.Println("Your master key is")
+	fmt.Println(master)
+	return nil
+}
+
+func wordlistNames() string {
+	names := make([]string, len(bip39.Wordlists))
+	for i, wl := range bip39.Wordlists {
+		names[i] = wl.Name
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/smallnest/bitcoin/wallet/address"
+	"github.com/smallnest/bitcoin/wallet/ecc"
+	"github.com/smallnest/bitcoin/wallet/tx"
+)
+
+type multisig struct {
+	Network  string `json:"network"`
+	Required int    `json:"required"`
+	// PublicKeys are in BIP67 order, the order of the script.
+	PublicKeys []string `json:"public_keys"`
+	// Script is the redeem script of the P2SH address and the witness
+	// script of the P2SH-P2WSH and P2WSH addresses.
+	Script    string `json:"script"`
+	P2SH      string `json:"p2sh"`
+	P2SHP2WSH string `json:"p2sh_p2wsh"`
+	P2WSH     string `json:"p2wsh"`
+}
+
+// runMultisig builds the BIP67 sorted m-of-n multisig script of compressed
+// public keys and prints its P2SH, P2SH-P2WSH and P2WSH addresses. The
+// script is needed again to spend from any of them.
+//
+//	key [-testnet] multisig -m 2 [-json] <pubkey hex> <pubkey hex> ...
+func runMultisig(args []string) error {
+	fs := flag.NewFlagSet("multisig", flag.ExitOnError)
+	required := fs.Int("m", 0, "Number of signatures required to spend.")
+	asJSON := fs.Bool("json", false, "Print JSON instead of text.")
+	fs.Parse(args)
+
+	if fs.NArg() == 0 || *required == 0 {
+		return errors.New("multisig needs -m and the public keys")
+	}
+	pubKeys := make([][]byte, fs.NArg())
+	for i, s := range fs.Args() {
+		k, err := hex.DecodeString(s)
+		if err != nil {
+			return fmt.Errorf("invalid public key %q: %v", s, err)
+		}
+		if _, err := ecc.ParsePoint(k); err != nil {
+			return fmt.Errorf("invalid public key %q: %v", s, err)
+		}
+		pubKeys[i] = k
+	}
+
+	script, err := tx.SortedMultisigScript(*required, pubKeys)
+	if err != nil {
+		return err
+	}
+	// List the keys in the order of the script.
+	pubKeys, _ = tx.SortPubKeys(pubKeys)
+	net := network()
+	out := multisig{
+		Network:   net.String(),
+		Required:  *required,
+		Script:    hex.EncodeToString(script),
+		P2SH:      address.NewP2SHFromScript(net, script).String(),
+		P2SHP2WSH: address.NewP2SHP2WSHFromScript(net, script).String(),
+		P2WSH:     address.NewP2WSHFromScript(net, script).String(),
+	}
+	for _, k := range pubKeys {
+		out.PublicKeys = append(out.PublicKeys, hex.EncodeToString(k))
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+	fmt.Printf("%d-of-%d multisig on %s\n\n", out.Required, len(out.PublicKeys), out.Network)
+	fmt.Printf("P2SH:        %s\n", out.P2SH)
+	fmt.Printf("P2SH-P2WSH:  %s\n", out.P2SHP2WSH)
+	fmt.Printf("P2WSH:       %s\n", out.P2WSH)
+	fmt.Printf("\nRedeem/witness script, keep it to spend:\n%s\n", out.Script)
+	return nil
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/smallnest/bitcoin/wallet/bip38"
+	"github.com/smallnest/bitcoin/wallet/ecc"
+	"github.com/smallnest/bitcoin/wallet/paper"
+	"github.com/smallnest/bitcoin/wallet/wif"
+)
+
+// runPaper generates keys and renders them as printable paper wallets,
+// several per A4 sheet. The files are written with owner-only permissions
+// as they hold the private keys; the addresses are printed once every file
+// is written, so they can be watched without the sheets.
+//
+//	key [-testnet] paper [-count 1] [-per-sheet 4] [-format pdf] [-type p2pkh] [-passphrase ...] [-out paper-wallet]
+func runPaper(args []string) error {
+	fs := flag.NewFlagSet("paper", flag.ExitOnError)
+	count := fs.Int("count", 1, "Number of wallets to generate.")
+	perSheet := fs.Int("per-sheet", 4, "Number of wallets on each sheet.")
+	format := fs.String("format", "pdf", "Output format: pdf, svg or png. SVG and PNG write one file per sheet.")
+	addrType := fs.String("type", "p2pkh", "Address type: p2pkh, p2sh-p2wpkh, p2wpkh or p2tr.")
+	passphrase := fs.String("passphrase", "", "Print BIP38 encrypted keys protected by this passphrase. (optional)")
+	out := fs.String("out", "paper-wallet", "Output file name, without the extension.")
+	fs.Parse(args)
+
+	if *count < 1 || *perSheet < 1 {
+		return errors.New("-count and -per-sheet must be at least 1")
+	}
+	if !paper.Fits(*perSheet) {
+		return fmt.Errorf("%d wallets do not fit on a sheet with scannable QR codes, lower -per-sheet", *perSheet)
+	}
+	var write func(io.Writer, []paper.Wallet) error
+	switch *format {
+	case "pdf":
+	case "svg":
+		write = paper.WriteSVG
+	case "png":
+		write = paper.WritePNG
+	default:
+		return fmt.Errorf("unsupported format %q", *format)
+	}
+	p, err := purposeByName(*addrType)
+	if err != nil {
+		return err
+	}
+	// segwit addresses always use compressed keys
+	compressed := *compressed || p.number != 44
+
+	var sheets [][]paper.Wallet
+	for i := 0; i < *count; i++ {
+		w, err := newPaperWallet(p, compressed, *passphrase)
+		if err != nil {
+			return err
+		}
+		if i%*perSheet == 0 {
+			sheets = append(sheets, nil)
+		}
+		sheets[len(sheets)-1] = append(sheets[len(sheets)-1], w)
+	}
+
+	// Render everything before writing, so that a failure leaves no
+	// partial set of sheets behind.
+	var files []*paperFile
+	if write == nil {
+		f := &paperFile{name: *out + ".pdf"}
+		if err := paper.WritePDF(&f.data, sheets); err != nil {
+			return err
+		}
+		files = append(files, f)
+	} else {
+		for i, sheet := range sheets {
+			f := &paperFile{name: *out + "." + *format}
+			if len(sheets) > 1 {
+				f.name = fmt.Sprintf("%s-%d.%s", *out, i+1, *format)
+			}
+			if err := write(&f.data, sheet); err != nil {
+				return err
+			}
+			files = append(files, f)
+		}
+	}
+	for _, f := range files {
+		if err := f.write(); err != nil {
+			return err
+		}
+	}
+
+	for _, sheet := range sheets {
+		for _, w := range sheet {
+			fmt.Println(w.Address)
+		}
+	}
+	return nil
+}
+
+func newPaperWallet(p purpose, compressed bool, passphrase string) (paper.Wallet, error) {
+	privateKey, err := generatePrivateKey(entropySource())
+	if err != nil {
+		return paper.Wallet{}, err
+	}
+	privateKeyWif, err := wif.New(privateKey, network(), compressed)
+	if err != nil {
+		return paper.Wallet{}, err
+	}
+	publicKey, err := ecc.Default.PublicKey(privateKey, compressed)
+	if err != nil {
+		return paper.Wallet{}, err
+	}
+	addr, err := p.address(network(), publicKey)
+	if err != nil {
+		return paper.Wallet{}, err
+	}
+
+	w := paper.Wallet{Address: addr.String(), Secret: privateKeyWif.String()}
+	if passphrase != "" {
+		if w.Secret, err = bip38.Encrypt(privateKeyWif, passphrase); err != nil {
+			return paper.Wallet{}, err
+		}
+		w.Encrypted = true
+	}
+	return w, nil
+}
+
+// paperFile is a rendered file, written once every file is rendered.
+type paperFile struct {
+	name string
+	data bytes.Buffer
+}
+
+// write creates the file readable only by its owner.
+func (f *paperFile) write() error {
+	out, err := os.OpenFile(f.name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.data.WriteTo(out); err != nil {
+		out.Close()
+		os.Remove(f.name)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %s\n", f.name)
+	return nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"runtime"
+
+	"github.com/smallnest/bitcoin/wallet/base58check"
+)
+
+// runRecover searches for the WIF or address the user meant to type.
+//
+//	key recover [-max-edits 2] [-workers N] <WIF|address>
+func runRecover(args []string) error {
+	fs := flag.NewFlagSet("recover", flag.ExitOnError)
+	maxEdits := fs.Int("max-edits", 2, "Maximum number of substitutions and transpositions to try (1 or 2).")
+	workers := fs.Int("workers", runtime.NumCPU(), "Number of CPU cores to search with.")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return errors.New("recover needs exactly one WIF or address")
+	}
+
+	candidates := base58check.Recover(fs.Arg(0), base58check.RecoverOptions{
+		MaxEdits: *maxEdits,
+		Workers:  *workers,
+		Filter:   plausible,
+	})
+	if len(candidates) == 0 {
+		return fmt.Errorf("no valid candidate within %d edits", *maxEdits)
+	}
+
+	for _, c := range candidates {
+		fmt.Printf("%s\tedits: %d\n", c.Value, c.Edits)
+	}
+	return nil
+}
+
+// plausible accepts only well-formed WIF private keys and P2PKH/P2SH
+// addresses, which rules out most accidental checksum matches.
+func plausible(version byte, payload []byte) bool {
+	switch version {
+	case 0x00, 0x05, 0x6f, 0xc4:
+		return len(payload) == 20
+	case 0x80, 0xef:
+		return len(payload) == 32 || (len(payload) == 33 && payload[32] == 0x01)
+	}
+	return false
+}
@@ -0,0 +1,41 @@
+package main
+
+import "testing"
+
+func TestPlausible(t *testing.T) {
+	for _, v := range []struct {
+		version byte
+		payload []byte
+		want    bool
+	}{
+		{0x00, make([]byte, 20), true},
+		{0x05, make([]byte, 20), true},
+		{0x6f, make([]byte, 20), true},
+		{0xc4, make([]byte, 20), true},
+		{0x00, make([]byte, 32), false},
+		{0x80, make([]byte, 32), true},
+		{0xef, append(make([]byte, 32), 0x01), true},
+		{0x80, append(make([]byte, 32), 0x02), false},
+		{0x80, make([]byte, 20), false},
+		{0x42, make([]byte, 20), false},
+	} {
+		if got := plausible(v.version, v.payload); got != v.want {
+			t.Errorf("version %02x with %d bytes: %v, want %v", v.version, len(v.payload), got, v.want)
+		}
+	}
+}
+
+func TestRunRecover(t *testing.T) {
+	// The compressed WIF of private key 1 with its last character changed.
+	typo := "KwDiBf89QgGbjEhKnhXJuH7LrciVrZi3qYjgd9M7rFU73sVHnoWo"
+	if err := runRecover([]string{"-max-edits", "1", typo}); err != nil {
+		t.Error(err)
+	}
+	// Two substitutions with a single edit allowed.
+	if err := runRecover([]string{"-max-edits", "1", "KwDiBf89QgGbjEhKnhXJuH7LrciVrZi3qYjgd9M7rFU73sVHnoXo"}); err == nil {
+		t.Error("recovered a key two edits away with -max-edits 1")
+	}
+	if err := runRecover([]string{"a", "b"}); err == nil {
+		t.Error("accepted two arguments")
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/smallnest/bitcoin/wallet/address"
+	"github.com/smallnest/bitcoin/wallet/bip21"
+)
+
+// paramsFlag collects repeated -param name=value flags.
+type paramsFlag map[string]string
+
+func (p paramsFlag) String() string { return "" }
+
+func (p paramsFlag) Set(s string) error {
+	eq := strings.IndexByte(s, '=')
+	if eq <= 0 {
+		return fmt.Errorf("%q is not name=value", s)
+	}
+	p[s[:eq]] = s[eq+1:]
+	return nil
+}
+
+// runRequest prints a BIP21 payment request URI for an address and shows
+// it as a QR code.
+//
+//	key [-testnet] request [-amount BTC] [-label ...] [-message ...] [-lightning INVOICE] [-param name=value]... [address]
+func runRequest(args []string) error {
+	fs := flag.NewFlagSet("request", flag.ExitOnError)
+	amount := fs.String("amount", "", "Requested amount in bitcoins, e.g. 0.001. (optional)")
+	label := fs.String("label", "", "Name of the recipient. (optional)")
+	message := fs.String("message", "", "Note about the payment. (optional)")
+	lightning := fs.String("lightning", "", "BOLT11 invoice for the same payment, the address may be omitted then. (optional)")
+	params := paramsFlag{}
+	fs.Var(params, "param", "Extra name=value parameter, e.g. req-somethingyoumustunderstand=1. (repeatable)")
+	fs.Parse(args)
+
+	if fs.NArg() > 1 {
+		return errors.New("usage: key request [flags] [address]")
+	}
+	u := &bip21.URI{
+		Address:   fs.Arg(0),
+		Label:     *label,
+		Message:   *message,
+		Lightning: *lightning,
+		Params:    params,
+	}
+	if u.Address == "" && u.Lightning == "" {
+		return errors.New("an address or a -lightning invoice is required")
+	}
+	if u.Address != "" {
+		if _, err := address.ParseFor(u.Address, network()); err != nil {
+			return err
+		}
+	}
+	if *amount != "" {
+		var err error
+		if u.Amount, err = bip21.ParseAmount(*amount); err != nil {
+			return err
+		}
+	}
+
+	uri := u.String()
+	fmt.Println(uri)
+	qrInTerminal(uri)
+	return nil
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+
+	"github.com/smallnest/bitcoin/wallet/ecc"
+	"github.com/smallnest/bitcoin/wallet/entropy"
+	"github.com/smallnest/bitcoin/wallet/schnorr"
+)
+
+// runSelftest checks the secp256k1 backend in use against the pure Go one
+// on random keys: public keys, signatures with the same nonce, verification
+// of each other's signatures, public key recovery and key tweaks. Built
+// with the secp256k1_cgo tag it compares libsecp256k1 with the Go code.
+// It also checks the Schnorr signatures against the BIP340 test vectors.
+//
+//	key selftest [-rounds 100]
+func runSelftest(args []string) error {
+	fs := flag.NewFlagSet("selftest", flag.ExitOnError)
+	rounds := fs.Int("rounds", 100, "Number of random keys to check.")
+	fs.Parse(args)
+
+	if err := selftestBIP340(); err != nil {
+		return err
+	}
+
+	backend := ecc.Default
+	for i := 0; i < *rounds; i++ {
+		if err := selftestRound(backend, ecc.PureGo); err != nil {
+			return fmt.Errorf("round %d: %v", i, err)
+		}
+	}
+	fmt.Printf("ok: %d BIP340 vectors\n", len(bip340Vectors))
+	fmt.Printf("ok: %d rounds, %s backend agrees with %s\n", *rounds, backend.Name(), ecc.PureGo.Name())
+	return nil
+}
+
+func selftestRound(a, b ecc.Curve) error {
+	privateKey, err := generatePrivateKey(entropy.Default)
+	if err != nil {
+		return err
+	}
+	nonce, err := entropy.Scalar(entropy.Default)
+	if err != nil {
+		return err
+	}
+	tweak, err := entropy.Scalar(entropy.Default)
+	if err != nil {
+		return err
+	}
+	hash := sha256.Sum256(nonce[:])
+
+	for _, compressed := range []bool{true, false} {
+		pubA, err := a.PublicKey(privateKey, compressed)
+		if err != nil {
+			return err
+		}
+		pubB, err := b.PublicKey(privateKey, compressed)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(pubA, pubB) {
+			return fmt.Errorf("public keys differ: %x != %x", pubA, pubB)
+		}
+	}
+	pubKey, _ := b.PublicKey(privateKey, true)
+
+	sigA, err := a.Sign(hash[:], privateKey, nonce[:])
+	if err != nil {
+		return err
+	}
+	sigB, err := b.Sign(hash[:], privateKey, nonce[:])
+	if err != nil {
+		return err
+	}
+	// Only one of the implementations may normalize s.
+	normA, err := ecc.NormalizeSignature(sigA)
+	if err != nil {
+		return err
+	}
+	normB, err := ecc.NormalizeSignature(sigB)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(normA, normB) {
+		return fmt.Errorf("signatures differ: %x != %x", sigA, sigB)
+	}
+	if !a.Verify(hash[:], sigB, pubKey) || !b.Verify(hash[:], sigA, pubKey) {
+		return fmt.Errorf("signatures do not verify across backends")
+	}
+	hash[0] ^= 1
+	if a.Verify(hash[:], sigB, pubKey) || b.Verify(hash[:], sigA, pubKey) {
+		return fmt.Errorf("signature verifies for another hash")
+	}
+	hash[0] ^= 1
+
+	compact, err := ecc.SignCompact(hash[:], privateKey, nonce[:], true)
+	if err != nil {
+		return err
+	}
+	for _, c := range []ecc.Curve{a, b} {
+		recovered, compressed, err := c.RecoverCompact(hash[:], compact)
+		if err != nil {
+			return fmt.Errorf("%s: %v", c.Name(), err)
+		}
+		if !compressed || !bytes.Equal(recovered, pubKey) {
+			return fmt.Errorf("%s recovered %x instead of %x", c.Name(), recovered, pubKey)
+		}
+	}
+	hash[0] ^= 1
+	if recovered, _, err := a.RecoverCompact(hash[:], compact); err == nil && bytes.Equal(recovered, pubKey) {
+		return fmt.Errorf("%s recovered the key from the signature of another hash", a.Name())
+	}
+	hash[0] ^= 1
+
+	tweakedA, err := a.TweakPrivateKey(privateKey, tweak[:])
+	if err != nil {
+		return err
+	}
+	tweakedB, err := b.TweakPrivateKey(privateKey, tweak[:])
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(tweakedA, tweakedB) {
+		return fmt.Errorf("tweaked private keys differ: %x != %x", tweakedA, tweakedB)
+	}
+	expected, err := a.PublicKey(tweakedA, true)
+	if err != nil {
+		return err
+	}
+	for _, c := range []ecc.Curve{a, b} {
+		tweakedPublic, err := c.TweakPublicKey(pubKey, tweak[:])
+		if err != nil {
+			return fmt.Errorf("%s: %v", c.Name(), err)
+		}
+		if !bytes.Equal(tweakedPublic, expected) {
+			return fmt.Errorf("%s tweaked public key %x does not match the tweaked private key", c.Name(), tweakedPublic)
+		}
+	}
+	return nil
+}
+
+// bip340Vectors are a subset of schnorr/testdata/test-vectors.csv, which
+// the schnorr tests run in full. The selftest checks the binary on the
+// machine it runs on, without the source tree, so it carries its own copy:
+// the signing vectors, a verification without a secret key, and a public
+// key and two signatures that must be rejected. Vectors with a secret key
+// are signed and verified, the others only verified.
+var bip340Vectors = []struct {
+	secretKey, publicKey, aux, msg, signature string
+	valid                                     bool
+}{
+	{
+		"0000000000000000000000000000000000000000000000000000000000000003",
+		"F9308A019258C31049344F85F89D5229B531C845836F99B08601F113BCE036F9",
+		"0000000000000000000000000000000000000000000000000000000000000000",
+		"0000000000000000000000000000000000000000000000000000000000000000",
+		"E907831F80848D1069A5371B402410364BDF1C5F8307B0084C55F1CE2DCA821525F66A4A85EA8B71E482A74F382D2CE5EBEEE8FDB2172F477DF4900D310536C0",
+		true,
+	},
+	{
+		"B7E151628AED2A6ABF7158809CF4F3C762E7160F38B4DA56A784D9045190CFEF",
+		"DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659",
+		"0000000000000000000000000000000000000000000000000000000000000001",
+		"243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89",
+		"6896BD60EEAE296DB48A229FF71DFE071BDE413E6D43F917DC8DCF8C78DE33418906D11AC976ABCCB20B091292BFF4EA897EFCB639EA871CFA95F6DE339E4B0A",
+		true,
+	},
+	{
+		"C90FDAA22168C234C4C6628B80DC1CD129024E088A67CC74020BBEA63B14E5C9",
+		"DD308AFEC5777E13121FA72B9CC1B7CC0139715309B086C960E18FD969774EB8",
+		"C87AA53824B4D7AE2EB035A2B5BBBCCC080E76CDC6D1692C4B0B62D798E6D906",
+		"7E2D58D8B3BCDF1ABADEC7829054F90DDA9805AAB56C77333024B9D0A508B75C",
+		"5831AAEED7B44BB74E5EAB94BA9D4294C49BCF2A60728D8B4C200F50DD313C1BAB745879A5AD954A72C45A91C3A51D3C7ADEA98D82F8481E0E1E03674A6F3FB7",
+		true,
+	},
+	{
+		"0B432B2677937381AEF05BB02A66ECD012773062CF3FA2549E44F58ED2401710",
+		"25D1DFF95105F5253C4022F628A996AD3A0D95FBF21D468A1B33F8C160D8F517",
+		"FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF",
+		"FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF",
+		"7EB0509757E246F19449885651611CB965ECC1A187DD51B64FDA1EDC9637D5EC97582B9CB13DB3933705B32BA982AF5AF25FD78881EBB32771FC5922EFC66EA3",
+		true,
+	},
+	{
+		"",
+		"D69C3509BB99E412E68B0FE8544E72837DFA30746D8BE2AA65975F29D22DC7B9",
+		"",
+		"4DF3C3F68FCC83B27E9D42C90431A72499F17875C81A599B566C9889B9696703",
+		"00000000000000000000003B78CE563F89A0ED9414F5AA28AD0D96D6795F9C6376AFB1548AF603B3EB45C9F8207DEE1060CB71C04E80F593060B07D28308D7F4",
+		true,
+	},
+	// public key not on the curve
+	{
+		"",
+		"EEFDEA4CDB677750A420FEE807EACF21EB9898AE79B9768766E4FAA04A2D4A34",
+		"",
+		"243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89",
+		"6CFF5C3BA86C69EA4B7376F31A9BCB4F74C1976089B2D9963DA2E5543E17776969E89B4C5564D00349106B8497785DD7D1D713A8AE82B32FA79D5F7FC407D39B",
+		false,
+	},
+	// sig[0:32] is not an x coordinate on the curve
+	{
+		"",
+		"DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659",
+		"",
+		"243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89",
+		"4A298DACAE57395A15D0795DDBFD1DCB564DA82B0F269BC70A74F8220429BA1D69E89B4C5564D00349106B8497785DD7D1D713A8AE82B32FA79D5F7FC407D39B",
+		false,
+	},
+	// sig[32:64] is equal to the curve order
+	{
+		"",
+		"DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659",
+		"",
+		"243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89",
+		"6CFF5C3BA86C69EA4B7376F31A9BCB4F74C1976089B2D9963DA2E5543E177769FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141",
+		false,
+	},
+}
+
+func selftestBIP340() error {
+	for i, v := range bip340Vectors {
+		publicKey, _ := hex.DecodeString(v.publicKey)
+		msg, _ := hex.DecodeString(v.msg)
+		signature, _ := hex.DecodeString(v.signature)
+
+		if v.secretKey != "" {
+			secretKey, _ := hex.DecodeString(v.secretKey)
+			aux, _ := hex.DecodeString(v.aux)
+			pub, err := schnorr.PublicKey(secretKey)
+			if err != nil {
+				return fmt.Errorf("BIP340 vector %d: %v", i, err)
+			}
+			if !bytes.Equal(pub, publicKey) {
+				return fmt.Errorf("BIP340 vector %d: public key %X", i, pub)
+			}
+			sig, err := schnorr.Sign(msg, secretKey, aux)
+			if err != nil {
+				return fmt.Errorf("BIP340 vector %d: %v", i, err)
+			}
+			if !bytes.Equal(sig, signature) {
+				return fmt.Errorf("BIP340 vector %d: signature %X", i, sig)
+			}
+		}
+		if schnorr.Verify(msg, signature, publicKey) != v.valid {
+			return fmt.Errorf("BIP340 vector %d: verification does not give %v", i, v.valid)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/smallnest/bitcoin/wallet/bip39"
+	"github.com/smallnest/bitcoin/wallet/slip39"
+)
+
+// runSplit splits a master secret into SLIP-39 mnemonic shares. Without
+// -secret or -mnemonic a new master secret is generated. The master secret
+// is used as the BIP32 seed. With -mnemonic it is the 64-byte BIP39 seed,
+// which key combine recovers, but hardware wallets only accept 16 and 32
+// byte master secrets and will not restore those shares, so other lengths
+// need -allow-nonstandard.
+//
+//	key split [-secret HEX | -mnemonic words [-allow-nonstandard] | -bits 128] [-groups 2of3] [-threshold 1] [-passphrase ...]
+func runSplit(args []string) error {
+	fs := flag.NewFlagSet("split", flag.ExitOnError)
+	secretHex := fs.String("secret", "", "Hex master secret to split, 16 to 64 bytes. (optional)")
+	mnemonic := fs.String("mnemonic", "", "BIP39 mnemonic whose seed is split, instead of -secret. (optional)")
+	bip39Passphrase := fs.String("bip39-passphrase", "", "BIP39 passphrase of -mnemonic. (optional)")
+	bits := fs.Int("bits", 256, "Size of a new master secret: 128 or 256 bits.")
+	groupList := fs.String("groups", "2of3", "Comma separated groups of shares, each m-of-n: m of its n shares are needed.")
+	threshold := fs.Int("threshold", 1, "Number of groups needed to recover the secret.")
+	passphrase := fs.String("passphrase", "", "SLIP-39 passphrase encrypting the secret, printable ASCII. (optional)")
+	iterationExponent := fs.Uint("iteration-exponent", 1, "Encryption cost, 10000·2^e PBKDF2 iterations.")
+	allowNonstandard := fs.Bool("allow-nonstandard", false, "Split secrets other than 16 or 32 bytes, which hardware wallets cannot restore.")
+	fs.Parse(args)
+
+	groups, err := parseGroups(*groupList)
+	if err != nil {
+		return err
+	}
+	if *iterationExponent > 15 {
+		return errors.New("iteration exponent must be below 16")
+	}
+
+	var secret []byte
+	switch {
+	case *secretHex != "":
+		if secret, err = hex.DecodeString(*secretHex); err != nil {
+			return fmt.Errorf("invalid secret: %v", err)
+		}
+		if len(secret) > 64 {
+			return errors.New("the secret is a BIP32 seed, at most 64 bytes")
+		}
+	case *mnemonic != "":
+		if err := validateMnemonic(*mnemonic); err != nil {
+			return err
+		}
+		secret = bip39.NewSeed(*mnemonic, *bip39Passphrase)
+	default:
+		if *bits != 128 && *bits != 256 {
+			return errors.New("bits must be 128 or 256")
+		}
+		secret = make([]byte, *bits/8)
+		if _, err := entropySource().Read(secret); err != nil {
+			return err
+		}
+	}
+
+	if len(secret) != 16 && len(secret) != 32 {
+		if !*allowNonstandard {
+			return fmt.Errorf("hardware wallets only restore 16 or 32 byte master secrets, not %d bytes, split it with -allow-nonstandard", len(secret))
+		}
+		fmt.Fprintf(os.Stderr, "Warning: hardware wallets only restore 16 or 32 byte master secrets, not %d bytes.\n", len(secret))
+	}
+
+	shares, err := slip39.Split(entropySource(), secret, *passphrase, *threshold, groups, byte(*iterationExponent))
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Any %d of the %d groups recover the secret.\n", *threshold, len(groups))
+	for i, g := range shares {
+		fmt.Printf("\nGroup %d, %d of these %d shares are needed:\n", i+1, groups[i].Threshold, groups[i].Count)
+		for j, share := range g {
+			fmt.Printf("%2d. %s\n", j+1, share)
+		}
+	}
+	fmt.Println()
+	return printSeed(secret)
+}
+
+// parseGroups parses a list like "1of1,2of3".
+func parseGroups(s string) ([]slip39.Group, error) {
+	var groups []slip39.Group
+	for _, g := range strings.Split(s, ",") {
+		var group slip39.Group
+		if _, err := fmt.Sscanf(strings.TrimSpace(g), "%dof%d", &group.Threshold, &group.Count); err != nil {
+			return nil, fmt.Errorf("invalid group %q, use m-of-n like 2of3", g)
+		}
+		groups = append(groups, group)
+	}
+	return groups, nil
+}
+
+// runCombine recovers a master secret from SLIP-39 mnemonic shares, given
+// as one quoted argument each or one per line on stdin.
+//
+//	key combine [-passphrase ...] ["share words..." ...]
+func runCombine(args []string) error {
+	fs := flag.NewFlagSet("combine", flag.ExitOnError)
+	passphrase := fs.String("passphrase", "", "SLIP-39 passphrase used when splitting. A wrong one gives another secret.")
+	fs.Parse(args)
+
+	shares := fs.Args()
+	if len(shares) == 0 {
+		fmt.Fprintln(os.Stderr, "Enter the shares, one per line, and an empty line to finish:")
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" {
+				break
+			}
+			shares = append(shares, line)
+		}
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+	}
+
+	secret, err := slip39.Combine(shares, *passphrase)
+	if err != nil {
+		return err
+	}
+	return printSeed(secret)
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/smallnest/bitcoin/wallet/address"
+	"github.com/smallnest/bitcoin/wallet/schnorr"
+	"github.com/smallnest/bitcoin/wallet/taproot"
+	"github.com/smallnest/bitcoin/wallet/wif"
+)
+
+type taprootKey struct {
+	Network string `json:"network"`
+	WIF     string `json:"wif"`
+	// InternalKey is the x-only public key of the private key, OutputKey
+	// the BIP341 tweaked key the address pays to.
+	InternalKey string `json:"internal_key"`
+	MerkleRoot  string `json:"merkle_root,omitempty"`
+	OutputKey   string `json:"output_key"`
+	OddY        bool   `json:"output_key_odd_y"`
+	Address     string `json:"address"`
+}
+
+// runTaproot generates a private key, or takes one with -wif, and prints
+// its BIP340 x-only public key, the BIP341 output key tweaked with an
+// optional script tree merkle root, and the bech32m P2TR address.
+//
+//	key [-testnet] taproot [-wif WIF] [-merkle-root HEX] [-json]
+func runTaproot(args []string) error {
+	fs := flag.NewFlagSet("taproot", flag.ExitOnError)
+	key := fs.String("wif", "", "Private key to use. (optional, a new key is generated by default)")
+	root := fs.String("merkle-root", "", "Hex merkle root of the script tree. (optional, key path only by default)")
+	asJSON := fs.Bool("json", false, "Print JSON instead of text.")
+	fs.Parse(args)
+
+	var k *wif.WIF
+	var err error
+	if *key != "" {
+		k, err = wif.Decode(*key)
+	} else {
+		var privateKey []byte
+		if privateKey, err = generatePrivateKey(entropySource()); err != nil {
+			return err
+		}
+		// Taproot keys are always used compressed.
+		k, err = wif.New(privateKey, network(), true)
+	}
+	if err != nil {
+		return err
+	}
+
+	var merkleRoot []byte
+	if *root != "" {
+		if merkleRoot, err = hex.DecodeString(*root); err != nil {
+			return fmt.Errorf("invalid merkle root: %v", err)
+		}
+	}
+
+	internalKey, err := schnorr.PublicKey(k.PrivateKey[:])
+	if err != nil {
+		return err
+	}
+	outputKey, oddY, err := taproot.OutputKey(internalKey, merkleRoot)
+	if err != nil {
+		return err
+	}
+	addr, err := address.New(k.Network, address.P2TR, outputKey)
+	if err != nil {
+		return err
+	}
+
+	out := taprootKey{
+		Network:     k.Network.String(),
+		WIF:         k.String(),
+		InternalKey: hex.EncodeToString(internalKey),
+		MerkleRoot:  *root,
+		OutputKey:   hex.EncodeToString(outputKey),
+		OddY:        oddY,
+		Address:     addr.String(),
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+	fmt.Println("Your private key is")
+	fmt.Println(out.WIF)
+	fmt.Println("Your x-only internal key is")
+	fmt.Println(out.InternalKey)
+	fmt.Println("Your tweaked output key is")
+	fmt.Println(out.OutputKey)
+	fmt.Println("Your taproot address is")
+	fmt.Println(out.Address)
+	lookupAddress(out.Address)
+	return nil
+}
@@ -0,0 +1,418 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"math/big"
+	"os"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/smallnest/bitcoin/wallet/address"
+	"github.com/smallnest/bitcoin/wallet/base58check/base58"
+	"github.com/smallnest/bitcoin/wallet/ecc"
+	"github.com/smallnest/bitcoin/wallet/wif"
+)
+
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// runVanity searches for a key whose address matches a prefix, a suffix or
+// a regular expression.
+//
+//	key vanity [-type p2pkh] [-prefix 1Bob | -suffix xyz | -regex ...] [-i] [-workers N]
+func runVanity(args []string) error {
+	fs := flag.NewFlagSet("vanity", flag.ExitOnError)
+	addrType := fs.String("type", "p2pkh", "Address type: p2pkh, p2sh-p2wpkh, p2wpkh or p2tr.")
+	prefix := fs.String("prefix", "", "Required address prefix, including the leading 1, 3, bc1q, ...")
+	suffix := fs.String("suffix", "", "Required address suffix.")
+	expr := fs.String("regex", "", "Regular expression the address must match.")
+	fold := fs.Bool("i", false, "Case-insensitive matching, for bech32 addresses only with -regex.")
+	workers := fs.Int("workers", runtime.NumCPU(), "Number of CPU cores to search with.")
+	fs.Parse(args)
+
+	p, err := purposeByName(*addrType)
+	if err != nil {
+		return err
+	}
+	m, err := newMatcher(network(), p, *prefix, *suffix, *expr, *fold)
+	if err != nil {
+		return err
+	}
+	if *workers < 1 {
+		*workers = 1
+	}
+
+	difficulty := m.difficulty()
+	if difficulty > 0 {
+		fmt.Fprintf(os.Stderr, "Difficulty: 1 in %.0f keys\n", difficulty)
+	}
+
+	// segwit addresses always use compressed keys
+	s := &vanitySearch{matcher: m, purpose: p, net: network(), compressed: *compressed || p.number != 44}
+	start := time.Now()
+	done := make(chan struct{})
+	go s.report(done, start, difficulty)
+
+	var wg sync.WaitGroup
+	for i := 0; i < *workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.work(); err != nil {
+				s.fail(err)
+			}
+		}()
+	}
+	wg.Wait()
+	close(done)
+
+	if s.err != nil {
+		return s.err
+	}
+
+	privateKeyWif, err := wif.New(s.key, s.net, s.compressed)
+	if err != nil {
+		return err
+	}
+	elapsed := time.Since(start)
+	tried := atomic.LoadUint64(&s.tried)
+	fmt.Fprintf(os.Stderr, "Found after %d keys in %s (%.0f keys/s)\n",
+		tried, elapsed.Round(time.Millisecond), float64(tried)/elapsed.Seconds())
+	fmt.Println("Your private key is")
+	fmt.Println(privateKeyWif)
+	fmt.Println("Your address is")
+	fmt.Println(s.address)
+	lookupAddress(s.address)
+	return nil
+}
+
+func purposeByName(name string) (purpose, error) {
+	for _, p := range purposes {
+		if p.name == name {
+			return p, nil
+		}
+	}
+	return purpose{}, fmt.Errorf("unsupported address type %q", name)
+}
+
+// vanitySearch is the state shared by the workers.
+type vanitySearch struct {
+	matcher    *matcher
+	purpose    purpose
+	net        address.Network
+	compressed bool
+
+	tried uint64 // updated atomically
+	found int32  // set atomically once a worker has a result
+
+	mu      sync.Mutex
+	key     []byte
+	address string
+	err     error
+}
+
+// vanityWorker is owned by a single goroutine. It keeps its own private key
+// and point and moves to the next key with one point addition, instead of
+// setting up the curve and doing a full scalar multiplication per key.
+type vanityWorker struct {
+	key   *big.Int
+	point *ecc.Point
+}
+
+func (s *vanitySearch) newWorker() (*vanityWorker, error) {
+	k, err := generatePrivateKey(entropySource())
+	if err != nil {
+		return nil, err
+	}
+	return &vanityWorker{key: new(big.Int).SetBytes(k), point: ecc.ScalarBaseMult(k)}, nil
+}
+
+// next advances to key+1 and point+G, starting over from a fresh random
+// key in the unlikely case the key reaches N.
+func (w *vanityWorker) next(s *vanitySearch) error {
+	w.key.Add(w.key, big.NewInt(1))
+	if w.key.Cmp(ecc.N) >= 0 {
+		nw, err := s.newWorker()
+		if err != nil {
+			return err
+		}
+		*w = *nw
+		return nil
+	}
+	w.point = ecc.Add(w.point, ecc.G)
+	return nil
+}
+
+func (s *vanitySearch) work() error {
+	w, err := s.newWorker()
+	if err != nil {
+		return err
+	}
+
+	const batch = 256
+	for atomic.LoadInt32(&s.found) == 0 {
+		for i := 0; i < batch; i++ {
+			addr, err := s.purpose.address(s.net, w.point.Serialize(s.compressed))
+			if err != nil {
+				return err
+			}
+			if encoded := addr.String(); s.matcher.match(encoded) {
+				// Count the keys of the partial batch too.
+				atomic.AddUint64(&s.tried, uint64(i+1))
+				s.setResult(w.key.FillBytes(make([]byte, 32)), encoded)
+				return nil
+			}
+			if err := w.next(s); err != nil {
+				return err
+			}
+		}
+		atomic.AddUint64(&s.tried, batch)
+	}
+	return nil
+}
+
+func (s *vanitySearch) setResult(key []byte, addr string) {
+	if !atomic.CompareAndSwapInt32(&s.found, 0, 1) {
+		return
+	}
+	s.mu.Lock()
+	s.key, s.address = key, addr
+	s.mu.Unlock()
+}
+
+func (s *vanitySearch) fail(err error) {
+	if !atomic.CompareAndSwapInt32(&s.found, 0, 1) {
+		return
+	}
+	s.mu.Lock()
+	s.err = err
+	s.mu.Unlock()
+}
+
+// report prints the search speed and the expected time every few seconds.
+func (s *vanitySearch) report(done chan struct{}, start time.Time, difficulty float64) {
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			tried := atomic.LoadUint64(&s.tried)
+			rate := float64(tried) / time.Since(start).Seconds()
+			if difficulty > 0 && rate > 0 {
+				expected := time.Duration(difficulty / rate * float64(time.Second))
+				fmt.Fprintf(os.Stderr, "%d keys, %.0f keys/s, expected time %s\n", tried, rate, expected.Round(time.Second))
+			} else {
+				fmt.Fprintf(os.Stderr, "%d keys, %.0f keys/s\n", tried, rate)
+			}
+		}
+	}
+}
+
+// matcher tests addresses against the requested pattern.
+type matcher struct {
+	prefix, suffix string
+	re             *regexp.Regexp
+	fold           bool
+	// alphabet is the character set of the address encoding.
+	alphabet string
+	// lead is the part every address of the type starts with, and
+	// leadChoices the characters it may be made of, e.g. "m" and "mn" for
+	// testnet p2pkh addresses.
+	lead        string
+	leadChoices []string
+}
+
+func newMatcher(net address.Network, p purpose, prefix, suffix, expr string, fold bool) (*matcher, error) {
+	if prefix == "" && suffix == "" && expr == "" {
+		return nil, errors.New("vanity needs -prefix, -suffix or -regex")
+	}
+
+	m := &matcher{prefix: prefix, suffix: suffix, fold: fold}
+	var version byte
+	switch p.number {
+	case 44:
+		m.alphabet = base58.Alphabet
+		version = net.PubKeyHashID()
+		if net == address.Mainnet {
+			m.leadChoices = []string{"1"}
+		} else {
+			m.leadChoices = []string{"m", "n"}
+		}
+	case 49:
+		m.alphabet = base58.Alphabet
+		version = net.ScriptHashID()
+		if net == address.Mainnet {
+			m.leadChoices = []string{"3"}
+		} else {
+			m.leadChoices = []string{"2"}
+		}
+	default:
+		if fold && (prefix != "" || suffix != "") {
+			return nil, errors.New("bech32 addresses are lower case, -i only applies to -regex")
+		}
+		m.alphabet = bech32Charset
+		witnessVersion := "q"
+		if p.number == 86 {
+			witnessVersion = "p"
+		}
+		m.leadChoices = []string{net.HRP() + "1" + witnessVersion}
+	}
+	m.lead = m.leadChoices[0]
+
+	if expr != "" {
+		if fold {
+			expr = "(?i)" + expr
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, err
+		}
+		m.re = re
+	}
+
+	if prefix != "" {
+		if len(prefix) < len(m.lead) && strings.HasPrefix(m.lead, prefix) {
+			return nil, fmt.Errorf("every %s address starts with %s, make the prefix longer", p.name, m.lead)
+		}
+		ok := false
+		for _, lead := range m.leadChoices {
+			if strings.HasPrefix(prefix, lead) || (fold && strings.HasPrefix(strings.ToLower(prefix), lead)) {
+				ok = true
+			}
+		}
+		if !ok {
+			return nil, fmt.Errorf("%s addresses on %s start with %s, prefix %q is impossible",
+				p.name, net, strings.Join(m.leadChoices, " or "), prefix)
+		}
+		if err := m.checkChars(prefix[len(m.lead):]); err != nil {
+			return nil, err
+		}
+		if m.alphabet == base58.Alphabet {
+			first, last, sameLength := base58Range(version, 20)
+			if len(prefix) > len(last) {
+				return nil, fmt.Errorf("%s addresses have at most %d characters, prefix %q is too long", p.name, len(last), prefix)
+			}
+			if sameLength && !m.inRange(prefix, first, last) {
+				n := len(prefix)
+				return nil, fmt.Errorf("%s addresses on %s run from %s… to %s…, prefix %q is impossible",
+					p.name, net, first[:n], last[:n], prefix)
+			}
+		}
+	}
+	if err := m.checkChars(suffix); err != nil {
+		return nil, err
+	}
+
+	if fold {
+		m.prefix = strings.ToLower(m.prefix)
+		m.suffix = strings.ToLower(m.suffix)
+	}
+	return m, nil
+}
+
+// checkChars rejects characters that never appear in the address encoding,
+// such as 0, O, I and l in base58.
+func (m *matcher) checkChars(s string) error {
+	for i := 0; i < len(s); i++ {
+		if m.choices(s[i]) == 0 {
+			return fmt.Errorf("%q never appears in these addresses, the pattern is impossible", s[i])
+		}
+	}
+	return nil
+}
+
+// base58Range returns the smallest and the largest base58check encoding of
+// version followed by a payload of n bytes. ok is false when they differ
+// in length, as for version 0, whose leading zero bytes become extra 1s so
+// that any character may follow the lead.
+func base58Range(version byte, n int) (first, last string, ok bool) {
+	data := make([]byte, 1+n+4)
+	data[0] = version
+	first = string(base58.Encode(nil, data))
+	for i := 1; i < len(data); i++ {
+		data[i] = 0xff
+	}
+	last = string(base58.Encode(nil, data))
+	return first, last, len(first) == len(last)
+}
+
+// inRange reports whether an encoding between first and last, which have
+// the same length, starts with prefix. Encodings of the same length sort
+// like the numbers they encode, so it compares prefix with the leading
+// characters of both, trying both cases of each character with -i.
+func (m *matcher) inRange(prefix, first, last string) bool {
+	var fits func(i int, aboveFirst, belowLast bool) bool
+	fits = func(i int, aboveFirst, belowLast bool) bool {
+		if i == len(prefix) || aboveFirst && belowLast {
+			return true
+		}
+		lo := strings.IndexByte(base58.Alphabet, first[i])
+		hi := strings.IndexByte(base58.Alphabet, last[i])
+		for d := 0; d < len(base58.Alphabet); d++ {
+			c := base58.Alphabet[d]
+			if c != prefix[i] && !(m.fold && strings.EqualFold(string(c), string(prefix[i]))) {
+				continue
+			}
+			if (!aboveFirst && d < lo) || (!belowLast && d > hi) {
+				continue
+			}
+			if fits(i+1, aboveFirst || d > lo, belowLast || d < hi) {
+				return true
+			}
+		}
+		return false
+	}
+	return fits(0, false, false)
+}
+
+// choices returns how many characters of the alphabet match c.
+func (m *matcher) choices(c byte) int {
+	n := 0
+	for i := 0; i < len(m.alphabet); i++ {
+		a := m.alphabet[i]
+		if a == c || (m.fold && strings.EqualFold(string(a), string(c))) {
+			n++
+		}
+	}
+	return n
+}
+
+// difficulty estimates the number of keys to try for one match, or 0 when
+// it cannot be estimated, as for regular expressions. Every character
+// after the lead is taken as uniformly distributed, which is close enough
+// except for long runs of 1 right after the lead of p2pkh addresses.
+func (m *matcher) difficulty() float64 {
+	if m.re != nil {
+		return 0
+	}
+	d := 1.0
+	base := float64(len(m.alphabet))
+	if m.prefix != "" {
+		d *= float64(len(m.leadChoices))
+		for i := len(m.lead); i < len(m.prefix); i++ {
+			d *= base / float64(m.choices(m.prefix[i]))
+		}
+	}
+	for i := 0; i < len(m.suffix); i++ {
+		d *= base / float64(m.choices(m.suffix[i]))
+	}
+	return math.Round(d)
+}
+
+func (m *matcher) match(addr string) bool {
+	if m.re != nil && !m.re.MatchString(addr) {
+		return false
+	}
+	if m.fold && (m.prefix != "" || m.suffix != "") {
+		addr = strings.ToLower(addr)
+	}
+	return strings.HasPrefix(addr, m.prefix) && strings.HasSuffix(addr, m.suffix)
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/smallnest/bitcoin/wallet/address"
+)
+
+func TestVanityPrefix(t *testing.T) {
+	for _, v := range []struct {
+		net      address.Network
+		addrType string
+		prefix   string
+		fold     bool
+		possible bool
+	}{
+		{address.Mainnet, "p2pkh", "1zz", false, true},
+		{address.Mainnet, "p2pkh", "1111", false, true},
+		{address.Mainnet, "p2pkh", strings.Repeat("1", 35), false, false},
+		{address.Mainnet, "p2pkh", "3abc", false, false},
+		{address.Mainnet, "p2sh-p2wpkh", "31h", false, true},
+		{address.Mainnet, "p2sh-p2wpkh", "3R2", false, true},
+		{address.Mainnet, "p2sh-p2wpkh", "3R3", false, false},
+		{address.Mainnet, "p2sh-p2wpkh", "3zz", false, false},
+		{address.Mainnet, "p2sh-p2wpkh", "31g", false, false},
+		{address.Mainnet, "p2sh-p2wpkh", "3q", false, false},
+		{address.Mainnet, "p2sh-p2wpkh", "3q", true, true},
+		{address.Testnet, "p2sh-p2wpkh", "2N", false, true},
+		{address.Testnet, "p2sh-p2wpkh", "2z", false, false},
+		{address.Testnet, "p2pkh", "mz", false, true},
+		{address.Testnet, "p2pkh", "n4", false, true},
+		{address.Testnet, "p2pkh", "n5", false, false},
+		{address.Testnet, "p2pkh", "m1", false, false},
+	} {
+		p, err := purposeByName(v.addrType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = newMatcher(v.net, p, v.prefix, "", "", v.fold)
+		if (err == nil) != v.possible {
+			t.Errorf("%s %s prefix %q (fold %v): %v", v.net, v.addrType, v.prefix, v.fold, err)
+		}
+	}
+}
+
+func TestVanityFoldBech32(t *testing.T) {
+	p, _ := purposeByName("p2wpkh")
+	if _, err := newMatcher(address.Mainnet, p, "bc1qxyz", "", "", true); err == nil {
+		t.Error("-i accepted with a bech32 prefix")
+	}
+	if _, err := newMatcher(address.Mainnet, p, "", "xyz", "", true); err == nil {
+		t.Error("-i accepted with a bech32 suffix")
+	}
+	m, err := newMatcher(address.Mainnet, p, "", "", "XYZ$", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.match("bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kxyz") {
+		t.Error("-i -regex XYZ$ does not match a lower case address")
+	}
+}
+
+// TestVanityTried checks that the keys of a partial batch are counted.
+func TestVanityTried(t *testing.T) {
+	p, _ := purposeByName("p2pkh")
+	m, err := newMatcher(address.Mainnet, p, "", "", "^1", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &vanitySearch{matcher: m, purpose: p, net: address.Mainnet, compressed: true}
+	if err := s.work(); err != nil {
+		t.Fatal(err)
+	}
+	if s.tried != 1 || s.address == "" {
+		t.Errorf("tried %d keys for %q, want 1", s.tried, s.address)
+	}
+}
+
+// TestBase58Range checks the ranges against real addresses.
+func TestBase58Range(t *testing.T) {
+	first, last, ok := base58Range(0x05, 20)
+	if !ok || first[:3] != "31h" || last[:3] != "3R2" {
+		t.Errorf("p2sh range %s to %s, %v", first, last, ok)
+	}
+	if _, _, ok := base58Range(0x00, 20); ok {
+		t.Error("p2pkh addresses have a fixed length")
+	}
+	for _, s := range []string{"3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy", "2MzQwSSnBHWHqSAqtTVQ6v47XtaisrJa1Vc"} {
+		a, err := address.Parse(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		first, last, _ := base58Range(a.Network.ScriptHashID(), 20)
+		if s < first || s > last {
+			t.Errorf("%s is outside %s to %s", s, first, last)
+		}
+	}
+}
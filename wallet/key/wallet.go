@@ -0,0 +1,312 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/smallnest/bitcoin/wallet/address"
+	"github.com/smallnest/bitcoin/wallet/bip39"
+	"github.com/smallnest/bitcoin/wallet/ecc"
+	"github.com/smallnest/bitcoin/wallet/hdkey"
+	"github.com/smallnest/bitcoin/wallet/walletfile"
+	"github.com/smallnest/bitcoin/wallet/wif"
+)
+
+// runWallet dispatches the wallet file commands:
+//
+//	key wallet create [-file wallet.json] [-kdf scrypt|argon2id]
+//	key wallet add -label L [-wif WIF | -seed [-mnemonic words] [-passphrase ...] [-purpose 84] [-account 0]]
+//	key wallet list
+//	key wallet next -label L [-change]
+//	key wallet export -label L [-private]
+//	key wallet remove -label L
+//
+// The password is read from $WALLET_PASSWORD or prompted for.
+func runWallet(args []string) error {
+	if len(args) == 0 {
+		return errors.New("wallet needs a sub command: create, add, list, next, export or remove")
+	}
+	switch args[0] {
+	case "create":
+		return runWalletCreate(args[1:])
+	case "add":
+		return runWalletAdd(args[1:])
+	case "list":
+		return runWalletList(args[1:])
+	case "next":
+		return runWalletNext(args[1:])
+	case "export":
+		return runWalletExport(args[1:])
+	case "remove":
+		return runWalletRemove(args[1:])
+	}
+	return fmt.Errorf("unknown wallet command %q", args[0])
+}
+
+func walletFileFlag(fs *flag.FlagSet) *string {
+	return fs.String("file", "wallet.json", "Path of the wallet file.")
+}
+
+// unlockWallet opens a wallet file and unlocks it. Callers must Lock it.
+func unlockWallet(path string) (*walletfile.Wallet, error) {
+	w, err := walletfile.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	password, err := walletfile.ReadPassword("Wallet password: ")
+	if err != nil {
+		return nil, err
+	}
+	if err := w.Unlock(password); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func runWalletCreate(args []string) error {
+	fs := flag.NewFlagSet("wallet create", flag.ExitOnError)
+	path := walletFileFlag(fs)
+	kdf := fs.String("kdf", walletfile.Scrypt, "Password key derivation: scrypt or argon2id.")
+	fs.Parse(args)
+
+	password, err := walletfile.ReadPassword("New wallet password: ")
+	if err != nil {
+		return err
+	}
+	repeated, err := walletfile.ReadPassword("Repeat the password: ")
+	if err != nil {
+		return err
+	}
+	if password != repeated {
+		return errors.New("the passwords do not match")
+	}
+
+	w, err := walletfile.Create(*path, password, *kdf)
+	if err != nil {
+		return err
+	}
+	w.Lock()
+	fmt.Println("Created", *path)
+	return nil
+}
+
+func runWalletAdd(args []string) error {
+	fs := flag.NewFlagSet("wallet add", flag.ExitOnError)
+	path := walletFileFlag(fs)
+	label := fs.String("label", "", "Label of the new entry.")
+	key := fs.String("wif", "", "Private key to add. (optional, a new key is generated by default)")
+	seed := fs.Bool("seed", false, "Add an HD seed instead of a single key.")
+	mnemonic := fs.String("mnemonic", "", "BIP39 mnemonic of the seed. (optional, a new one is generated by default)")
+	passphrase := fs.String("passphrase", "", "BIP39 passphrase of the seed. (optional)")
+	purposeNumber := fs.Uint("purpose", 84, "Purpose of the seed account: 44, 49, 84 or 86.")
+	account := fs.Uint("account", 0, "Account number of the seed.")
+	fs.Parse(args)
+
+	w, err := unlockWallet(*path)
+	if err != nil {
+		return err
+	}
+	defer w.Lock()
+
+	if *seed {
+		if *mnemonic == "" {
+			ent, err := bip39.NewEntropy(entropySource(), 256)
+			if err != nil {
+				return err
+			}
+			if *mnemonic, err = bip39.NewMnemonic(ent, bip39.English); err != nil {
+				return err
+			}
+			fmt.Println("Your new mnemonic is")
+			fmt.Println(*mnemonic)
+		} else if err := validateMnemonic(*mnemonic); err != nil {
+			return err
+		}
+		if _, err := w.AddSeed(*label, *mnemonic, *passphrase, network(), uint32(*purposeNumber), uint32(*account)); err != nil {
+			return err
+		}
+	} else {
+		var k *wif.WIF
+		if *key != "" {
+			if k, err = wif.Decode(*key); err == nil && (k.Network == address.Mainnet) == *testnet {
+				return fmt.Errorf("the key is for %s, not %s, check -testnet", k.Network, network())
+			}
+		} else {
+			var privateKey []byte
+			if privateKey, err = generatePrivateKey(entropySource()); err != nil {
+				return err
+			}
+			k, err = wif.New(privateKey, network(), *compressed)
+		}
+		if err != nil {
+			return err
+		}
+		e, err := w.AddKey(*label, k)
+		if err != nil {
+			return err
+		}
+		addr, err := entryAddress(e)
+		if err != nil {
+			return err
+		}
+		fmt.Println("Your address is")
+		fmt.Println(addr)
+	}
+
+	if err := w.Save(); err != nil {
+		return err
+	}
+	fmt.Printf("Added %q to %s\n", *label, *path)
+	return nil
+}
+
+// entryAddress returns the P2PKH address of a single key entry.
+func entryAddress(e *walletfile.Entry) (*address.Address, error) {
+	k, err := e.PrivateKey()
+	if err != nil {
+		return nil, err
+	}
+	pubKey, err := ecc.PublicKey(k.PrivateKey[:], k.Compressed)
+	if err != nil {
+		return nil, err
+	}
+	return address.NewP2PKHFromKey(k.Network, pubKey), nil
+}
+
+func runWalletList(args []string) error {
+	fs := flag.NewFlagSet("wallet list", flag.ExitOnError)
+	path := walletFileFlag(fs)
+	fs.Parse(args)
+
+	w, err := unlockWallet(*path)
+	if err != nil {
+		return err
+	}
+	defer w.Lock()
+
+	entries, err := w.Entries()
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "LABEL\tTYPE\tNETWORK\tCREATED\tDETAILS")
+	for _, e := range entries {
+		var details string
+		switch e.Type {
+		case walletfile.KeyEntry:
+			addr, err := entryAddress(e)
+			if err != nil {
+				return err
+			}
+			details = addr.String()
+		case walletfile.SeedEntry:
+			p, err := purposeByNumber(e.Purpose)
+			if err != nil {
+				return err
+			}
+			details = fmt.Sprintf("%s %s, next receive %d, next change %d",
+				hdkey.FormatPath(e.AccountPath()), p.name, e.NextReceive, e.NextChange)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", e.Label, e.Type, e.Network, e.Created.Local().Format(time.RFC3339), details)
+	}
+	return tw.Flush()
+}
+
+func runWalletNext(args []string) error {
+	fs := flag.NewFlagSet("wallet next", flag.ExitOnError)
+	path := walletFileFlag(fs)
+	label := fs.String("label", "", "Label of the HD seed entry.")
+	change := fs.Bool("change", false, "Hand out a change address instead of a receive address.")
+	fs.Parse(args)
+
+	w, err := unlockWallet(*path)
+	if err != nil {
+		return err
+	}
+	defer w.Lock()
+
+	e, err := w.Entry(*label)
+	if err != nil {
+		return err
+	}
+	addr, addrPath, err := e.NextAddress(*change)
+	if err != nil {
+		return err
+	}
+	// Save the derivation state before handing the address out, so it is
+	// never given out twice.
+	if err := w.Save(); err != nil {
+		return err
+	}
+	fmt.Println(addrPath)
+	fmt.Println(addr)
+	return nil
+}
+
+func runWalletExport(args []string) error {
+	fs := flag.NewFlagSet("wallet export", flag.ExitOnError)
+	path := walletFileFlag(fs)
+	label := fs.String("label", "", "Label of the entry.")
+	private := fs.Bool("private", false, "Export the private key or mnemonic instead of the address or account xpub.")
+	fs.Parse(args)
+
+	w, err := unlockWallet(*path)
+	if err != nil {
+		return err
+	}
+	defer w.Lock()
+
+	e, err := w.Entry(*label)
+	if err != nil {
+		return err
+	}
+	switch {
+	case e.Type == walletfile.KeyEntry && *private:
+		fmt.Println(e.WIF)
+	case e.Type == walletfile.KeyEntry:
+		addr, err := entryAddress(e)
+		if err != nil {
+			return err
+		}
+		fmt.Println(addr)
+	case *private:
+		fmt.Println(e.Mnemonic)
+		if e.Passphrase != "" {
+			fmt.Println("passphrase:", e.Passphrase)
+		}
+	default:
+		acct, err := e.AccountKey()
+		if err != nil {
+			return err
+		}
+		fmt.Println(hdkey.FormatPath(e.AccountPath()))
+		fmt.Println(acct.Neuter())
+	}
+	return nil
+}
+
+func runWalletRemove(args []string) error {
+	fs := flag.NewFlagSet("wallet remove", flag.ExitOnError)
+	path := walletFileFlag(fs)
+	label := fs.String("label", "", "Label of the entry to remove.")
+	fs.Parse(args)
+
+	w, err := unlockWallet(*path)
+	if err != nil {
+		return err
+	}
+	defer w.Lock()
+
+	if err := w.Remove(*label); err != nil {
+		return err
+	}
+	if err := w.Save(); err != nil {
+		return err
+	}
+	fmt.Printf("Removed %q from %s\n", *label, *path)
+	return nil
+}
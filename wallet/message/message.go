@@ -0,0 +1,106 @@
+// Package message signs and verifies messages in the Bitcoin Core signed
+// message format, which proves ownership of a P2PKH address.
+//
+// The message is prefixed with "\x18Bitcoin Signed Message:\n" and its
+// length, hashed twice with SHA256 and signed with a 65-byte compact
+// recoverable signature, encoded in base64. The verifier recovers the public
+// key from the signature and compares its address with the claimed one.
+package message
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/smallnest/bitcoin/wallet/address"
+	"github.com/smallnest/bitcoin/wallet/ecc"
+	"github.com/smallnest/bitcoin/wallet/wif"
+)
+
+// magic is prefixed to every signed message, so that a signature can never
+// be mistaken for a transaction signature.
+const magic = "Bitcoin Signed Message:\n"
+
+// ErrMismatch is returned when a valid signature was made by another key
+// than the one of the address.
+var ErrMismatch = errors.New("message: signature does not match the address")
+
+// Hash returns the double SHA256 of the serialized magic and message.
+func Hash(msg string) []byte {
+	var buf bytes.Buffer
+	writeVarString(&buf, magic)
+	writeVarString(&buf, msg)
+	first := sha256.Sum256(buf.Bytes())
+	second := sha256.Sum256(first[:])
+	return second[:]
+}
+
+// writeVarString writes a string prefixed with its length as a compact size
+// integer.
+func writeVarString(buf *bytes.Buffer, s string) {
+	n := uint64(len(s))
+	switch {
+	case n < 0xfd:
+		buf.WriteByte(byte(n))
+	case n <= 0xffff:
+		buf.WriteByte(0xfd)
+		binary.Write(buf, binary.LittleEndian, uint16(n))
+	case n <= 0xffffffff:
+		buf.WriteByte(0xfe)
+		binary.Write(buf, binary.LittleEndian, uint32(n))
+	default:
+		buf.WriteByte(0xff)
+		binary.Write(buf, binary.LittleEndian, n)
+	}
+	buf.WriteString(s)
+}
+
+// Sign signs a message with a private key and returns the base64 encoded
+// signature. The nonce is derived with RFC 6979 like Bitcoin Core does, so
+// both produce the same signature. The signature is for the P2PKH address
+// of the key, compressed or not as the WIF says.
+func Sign(key *wif.WIF, msg string) (string, error) {
+	hash := Hash(msg)
+	nonce := ecc.NonceRFC6979(key.PrivateKey[:], hash)
+	sig, err := ecc.SignCompact(hash, key.PrivateKey[:], nonce, key.Compressed)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(sig), nil
+}
+
+// Verify checks that signature is a valid signature of msg by the key of
+// the P2PKH address addr. It returns ErrMismatch when the signature is
+// valid but for another key.
+func Verify(addr, signature, msg string) error {
+	a, err := address.Parse(addr)
+	if err != nil {
+		return err
+	}
+	if a.Kind != address.P2PKH {
+		return fmt.Errorf("message: %s addresses cannot sign messages, only p2pkh", a.Kind)
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("message: invalid base64 signature: %v", err)
+	}
+	pubKey, _, err := RecoverPublicKey(sig, msg)
+	if err != nil {
+		return err
+	}
+	if !address.NewP2PKHFromKey(a.Network, pubKey).Equal(a) {
+		return ErrMismatch
+	}
+	return nil
+}
+
+// RecoverPublicKey returns the public key that made a decoded compact
+// signature of msg, serialized compressed or not as the signature header
+// says.
+func RecoverPublicKey(sig []byte, msg string) (pubKey []byte, compressed bool, err error) {
+	return ecc.Default.RecoverCompact(Hash(msg), sig)
+}
@@ -0,0 +1,103 @@
+package message
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+
+	"github.com/smallnest/bitcoin/wallet/wif"
+)
+
+const coreMessage = "This is just a test message"
+
+// vectors are signed with RFC 6979 nonces, so the signatures are
+// deterministic. The compressed one is the signmessagewithprivkey vector of
+// Bitcoin Core's rpc_signmessage.py. The uncompressed one is the same key
+// and nonce, only the header byte tells the verifier to recover the
+// uncompressed public key.
+var vectors = []struct {
+	wif       string
+	address   string
+	pubKey    string
+	signature string
+}{
+	{
+		wif:       "cUeKHd5orzT3mz8P9pxyREHfsWtVfgsfDjiZZBcjUBAaGk1BTj7N",
+		address:   "mpLQjfK79b7CCV4VMJWEWAj5Mpx8Up5zxB",
+		pubKey:    "03c150061989643d77162902b725409087959f15914649d4f06b6cc3f8c87bb238",
+		signature: "INbVnW4e6PeRmsv2Qgu8NuopvrVjkcxob+sX8OcZG0SALhWybUjzMLPdAsXI46YZGb0KQTRii+wWIQzRpG/U+S0=",
+	},
+	{
+		wif:       "93BikeB9ofqx1DwgukVYMtwyqbsBaEZmujJaCpidMf6o47cGNWj",
+		address:   "msJVBymhvvo8QpnCbEUaKUN2wkA3SRdxFQ",
+		pubKey:    "04c150061989643d77162902b725409087959f15914649d4f06b6cc3f8c87bb2386abca96335972d53de4dbf431e7e1825f6377bea700948a802a46383193a95bb",
+		signature: "HNbVnW4e6PeRmsv2Qgu8NuopvrVjkcxob+sX8OcZG0SALhWybUjzMLPdAsXI46YZGb0KQTRii+wWIQzRpG/U+S0=",
+	},
+}
+
+func TestSign(t *testing.T) {
+	for _, v := range vectors {
+		key, err := wif.Decode(v.wif)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig, err := Sign(key, coreMessage)
+		if err != nil {
+			t.Errorf("%s: %v", v.address, err)
+			continue
+		}
+		if sig != v.signature {
+			t.Errorf("%s: got %s, want %s", v.address, sig, v.signature)
+		}
+		if err := Verify(v.address, v.signature, coreMessage); err != nil {
+			t.Errorf("%s: %v", v.address, err)
+		}
+	}
+}
+
+func TestRecoverPublicKey(t *testing.T) {
+	for _, v := range vectors {
+		sig, _ := base64.StdEncoding.DecodeString(v.signature)
+		pubKey, compressed, err := RecoverPublicKey(sig, coreMessage)
+		if err != nil {
+			t.Errorf("%s: %v", v.address, err)
+			continue
+		}
+		if got := hex.EncodeToString(pubKey); got != v.pubKey {
+			t.Errorf("%s: got %s, want %s", v.address, got, v.pubKey)
+		}
+		if compressed != (len(pubKey) == 33) {
+			t.Errorf("%s: compressed %v for a %d byte key", v.address, compressed, len(pubKey))
+		}
+	}
+}
+
+func TestVerifyMismatch(t *testing.T) {
+	compressed, uncompressed := vectors[0], vectors[1]
+	for _, v := range []struct {
+		name, address, signature, message string
+	}{
+		// The address of the same key, but the header asks for the other
+		// encoding of the public key.
+		{"other encoding", uncompressed.address, compressed.signature, coreMessage},
+		{"other key", "1HZwkjkeaoZfTSaJxDw6aKkxp45agDiEzN", compressed.signature, coreMessage},
+		{"other message", compressed.address, compressed.signature, coreMessage + "."},
+	} {
+		if err := Verify(v.address, v.signature, v.message); err != ErrMismatch {
+			t.Errorf("%s: %v, want ErrMismatch", v.name, err)
+		}
+	}
+
+	for _, v := range []struct {
+		name, address, signature string
+	}{
+		{"p2wpkh address", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", compressed.signature},
+		{"invalid base64", compressed.address, "not base64!"},
+		{"short signature", compressed.address, base64.StdEncoding.EncodeToString(make([]byte, 64))},
+		{"bad header", compressed.address, "A" + compressed.signature[1:]},
+	} {
+		if err := Verify(v.address, v.signature, coreMessage); err == nil || err == ErrMismatch {
+			t.Errorf("%s: %v, want an error", v.name, err)
+		}
+	}
+}
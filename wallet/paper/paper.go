@@ -0,0 +1,190 @@
+// Package paper renders paper wallets: sheets of address and private key
+// QR codes that can be printed, folded and stored offline.
+//
+// A sheet is an A4 page holding one or more wallets, each a horizontal
+// strip with the address on the left and the secret on the right of a fold
+// line, so that the secret half can be folded away while the address is
+// shown. Sheets are rendered as SVG, PNG or PDF without any dependency but
+// the QR encoder.
+package paper
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// Page size and margin of a sheet in PostScript points, A4 portrait.
+const (
+	PageWidth  = 595
+	PageHeight = 842
+	margin     = 36
+	padding    = 12
+
+	titleSize  = 10
+	textSize   = 8
+	lineHeight = 10
+	// charWidth is the advance of a monospaced font, as a fraction of its
+	// size. It is exact for Courier and the built-in PNG font.
+	charWidth = 0.6
+
+	// minQRSize is the smallest QR code that still scans reliably once
+	// printed, about 17mm.
+	minQRSize = 48
+)
+
+// ErrTooSmall is returned when the wallets don't fit on a sheet with QR
+// codes that can be scanned.
+var ErrTooSmall = errors.New("paper: too many wallets per sheet")
+
+// The longest contents of a wallet: a bech32 address of a 32-byte witness
+// program and a BIP38 encrypted key.
+const (
+	maxAddressLength = 62
+	maxSecretLength  = 58
+)
+
+// Wallet is a single paper wallet.
+type Wallet struct {
+	// Address is the address to fund, shown on the public half.
+	Address string
+	// Secret is the WIF or the BIP38 encrypted private key that spends
+	// from Address, shown on the folded half.
+	Secret string
+	// Encrypted reports whether Secret is a BIP38 encrypted key.
+	Encrypted bool
+}
+
+// canvas is what the sheet layout draws on. Coordinates are in points
+// from the top left corner of the page.
+type canvas interface {
+	// rect fills a black rectangle.
+	rect(x, y, w, h float64)
+	// dashed draws a thin gray dashed line, used for the cut and fold
+	// lines.
+	dashed(x1, y1, x2, y2 float64)
+	// text draws s in a monospaced font with its baseline at y.
+	text(x, y, size float64, s string)
+}
+
+// Fits reports whether perSheet wallets fit on a sheet, so that the
+// layout can be checked before any key is generated. It lays out wallets
+// with the longest addresses and secrets.
+func Fits(perSheet int) bool {
+	if perSheet < 1 {
+		return false
+	}
+	wallets := make([]Wallet, perSheet)
+	for i := range wallets {
+		wallets[i] = Wallet{
+			Address:   strings.Repeat("q", maxAddressLength),
+			Secret:    strings.Repeat("6", maxSecretLength),
+			Encrypted: true,
+		}
+	}
+	return draw(nopCanvas{}, wallets) == nil
+}
+
+// nopCanvas discards the drawing, for layout checks.
+type nopCanvas struct{}
+
+func (nopCanvas) rect(x, y, w, h float64)           {}
+func (nopCanvas) dashed(x1, y1, x2, y2 float64)     {}
+func (nopCanvas) text(x, y, size float64, s string) {}
+
+// draw lays out the wallets of a sheet on c.
+func draw(c canvas, wallets []Wallet) error {
+	if len(wallets) == 0 {
+		return errors.New("paper: no wallets")
+	}
+
+	width := float64(PageWidth - 2*margin)
+	height := float64(PageHeight-2*margin) / float64(len(wallets))
+	half := width / 2
+
+	for i, w := range wallets {
+		x := float64(margin)
+		y := float64(margin) + float64(i)*height
+
+		// cut lines around the strip, shared with the neighbouring strips
+		if i == 0 {
+			c.dashed(x, y, x+width, y)
+		}
+		c.dashed(x, y+height, x+width, y+height)
+		c.dashed(x, y, x, y+height)
+		c.dashed(x+width, y, x+width, y+height)
+		// fold line
+		c.dashed(x+half, y, x+half, y+height)
+
+		secretTitle := "PRIVATE KEY (WIF) - KEEP SECRET"
+		if w.Encrypted {
+			secretTitle = "BIP38 ENCRYPTED KEY - KEEP SECRET"
+		}
+		if err := drawHalf(c, x, y, half, height, "BITCOIN ADDRESS - LOAD & VERIFY", w.Address); err != nil {
+			return err
+		}
+		if err := drawHalf(c, x+half, y, half, height, secretTitle, w.Secret); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// drawHalf draws a titled QR code of content in the box at x, y, with the
+// content written out below it.
+func drawHalf(c canvas, x, y, w, h float64, title, content string) error {
+	inner := w - 2*padding
+	lines := wrap(content, int(inner/(charWidth*textSize)))
+	textHeight := float64(len(lines)) * lineHeight
+
+	size := math.Min(inner, h-2*padding-titleSize-textHeight-2*4)
+	if size < minQRSize {
+		return ErrTooSmall
+	}
+
+	c.text(x+center(inner, titleSize, title)+padding, y+padding+titleSize, titleSize, title)
+
+	qr, err := qrcode.New(content, qrcode.Medium)
+	if err != nil {
+		return fmt.Errorf("paper: %v", err)
+	}
+	bitmap := qr.Bitmap()
+	module := size / float64(len(bitmap))
+	qx := x + (w-size)/2
+	qy := y + padding + titleSize + 4
+	for r, row := range bitmap {
+		for col, black := range row {
+			if black {
+				c.rect(qx+float64(col)*module, qy+float64(r)*module, module, module)
+			}
+		}
+	}
+
+	ty := qy + size + 4
+	for _, line := range lines {
+		ty += lineHeight
+		c.text(x+center(inner, textSize, line)+padding, ty, textSize, line)
+	}
+	return nil
+}
+
+// center returns the offset that centers s in width.
+func center(width, size float64, s string) float64 {
+	return math.Max(0, (width-float64(len(s))*charWidth*size)/2)
+}
+
+// wrap splits s into lines of at most n characters.
+func wrap(s string, n int) []string {
+	if n < 1 {
+		n = 1
+	}
+	var lines []string
+	for len(s) > n {
+		lines = append(lines, s[:n])
+		s = s[n:]
+	}
+	return append(lines, s)
+}
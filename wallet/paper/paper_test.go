@@ -0,0 +1,207 @@
+package paper
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/xml"
+	"fmt"
+	"image/png"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// wallets returns n wallets of the longest contents, each with its own
+// address and secret.
+func wallets(n int) []Wallet {
+	ws := make([]Wallet, n)
+	for i := range ws {
+		ws[i] = Wallet{
+			Address:   fmt.Sprintf("bc1p%058d", i),
+			Secret:    fmt.Sprintf("6P%056d", i),
+			Encrypted: true,
+		}
+	}
+	return ws
+}
+
+func TestFits(t *testing.T) {
+	if Fits(0) {
+		t.Error("0 wallets fit")
+	}
+	max := 0
+	for n := 1; n <= 20 && Fits(n); n++ {
+		max = n
+	}
+	if max < 4 || max == 20 {
+		t.Fatalf("up to %d wallets fit, want at least the default 4 and not 20", max)
+	}
+
+	// Fits agrees with rendering.
+	for _, n := range []int{max, max + 1} {
+		err := WriteSVG(io.Discard, wallets(n))
+		if n == max && err != nil {
+			t.Errorf("%d wallets: %v", n, err)
+		}
+		if n > max && err != ErrTooSmall {
+			t.Errorf("%d wallets: %v, want ErrTooSmall", n, err)
+		}
+		if _, err := writePDF(t, [][]Wallet{wallets(n)}); n > max && err != ErrTooSmall {
+			t.Errorf("%d wallets in a PDF: %v, want ErrTooSmall", n, err)
+		}
+	}
+}
+
+func writePDF(t *testing.T, sheets [][]Wallet) ([]byte, error) {
+	t.Helper()
+	var buf bytes.Buffer
+	err := WritePDF(&buf, sheets)
+	return buf.Bytes(), err
+}
+
+func TestWriteSVG(t *testing.T) {
+	ws := wallets(3)
+	ws[1].Encrypted = false
+	ws[1].Secret = "KwDiBf89QgGbjEhKnhXJuH7LrciVrZi3qYjgd9M7rFU73s<&>"
+	var buf bytes.Buffer
+	if err := WriteSVG(&buf, ws); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc struct {
+		XMLName xml.Name   `xml:"svg"`
+		ViewBox string     `xml:"viewBox,attr"`
+		Rects   []struct{} `xml:"rect"`
+		Lines   []struct{} `xml:"line"`
+		Texts   []string   `xml:"text"`
+	}
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if want := fmt.Sprintf("0 0 %d %d", PageWidth, PageHeight); doc.ViewBox != want {
+		t.Errorf("viewBox %q, want %q", doc.ViewBox, want)
+	}
+	// the background, then the QR modules
+	if len(doc.Rects) < 1+6*21*21/3 {
+		t.Errorf("%d rects, too few for 6 QR codes", len(doc.Rects))
+	}
+	// one cut line on top, then per strip: bottom, left, right and fold
+	if len(doc.Lines) != 1+4*len(ws) {
+		t.Errorf("%d lines, want %d", len(doc.Lines), 1+4*len(ws))
+	}
+
+	text := strings.Join(doc.Texts, "")
+	for _, w := range ws {
+		for _, s := range []string{w.Address, w.Secret} {
+			if !strings.Contains(text, s) {
+				t.Errorf("%q is not written out", s)
+			}
+		}
+	}
+	if !strings.Contains(text, "BIP38 ENCRYPTED KEY") || !strings.Contains(text, "PRIVATE KEY (WIF)") {
+		t.Error("missing secret titles")
+	}
+}
+
+func TestWritePNG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WritePNG(&buf, wallets(2)); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A4 at DPI: 8.27 by 11.69 inches.
+	b := img.Bounds()
+	if b.Dx() != 1653 || b.Dy() != 2339 {
+		t.Errorf("size %dx%d, want 1653x2339", b.Dx(), b.Dy())
+	}
+
+	black, white := 0, 0
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if r, _, _, _ := img.At(x, y).RGBA(); r == 0 {
+				black++
+			} else if r == 0xffff {
+				white++
+			}
+		}
+	}
+	// The margins are white, QR codes are about half black.
+	if white < b.Dx()*b.Dy()/2 || black < 4*100*100/2 {
+		t.Errorf("%d black and %d white pixels", black, white)
+	}
+}
+
+func TestWritePDF(t *testing.T) {
+	sheets := [][]Wallet{wallets(2), wallets(1)}
+	sheets[1][0].Address = "1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH"
+	b, err := writePDF(t, sheets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(b, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(b, []byte("%%EOF\n")) {
+		t.Fatal("not a PDF file")
+	}
+
+	// startxref points at the xref table, whose entries point at the
+	// objects.
+	m := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(b)
+	if m == nil {
+		t.Fatal("no startxref")
+	}
+	xref, _ := strconv.Atoi(string(m[1]))
+	if !bytes.HasPrefix(b[xref:], []byte("xref\n0 ")) {
+		t.Fatalf("startxref %d does not point at the xref table", xref)
+	}
+	offsets := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(b[xref:], -1)
+	// catalog, pages, font, and a page and its contents per sheet
+	if len(offsets) != 3+2*len(sheets) {
+		t.Fatalf("%d objects, want %d", len(offsets), 3+2*len(sheets))
+	}
+	for i, o := range offsets {
+		off, _ := strconv.Atoi(string(o[1]))
+		if want := fmt.Sprintf("%d 0 obj\n", i+1); !bytes.HasPrefix(b[off:], []byte(want)) {
+			t.Errorf("object %d: offset %d does not point at it", i+1, off)
+		}
+	}
+	if !bytes.Contains(b, []byte("/Count 2 ")) {
+		t.Error("the page tree does not count 2 pages")
+	}
+
+	// The content streams inflate to the drawing of the sheets.
+	streams := regexp.MustCompile(`(?s)/Length (\d+) /Filter /FlateDecode >>\nstream\n`).FindAllSubmatchIndex(b, -1)
+	if len(streams) != len(sheets) {
+		t.Fatalf("%d content streams, want %d", len(streams), len(sheets))
+	}
+	for i, s := range streams {
+		n, _ := strconv.Atoi(string(b[s[2]:s[3]]))
+		zr, err := zlib.NewReader(bytes.NewReader(b[s[1] : s[1]+n]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(zr)
+		if err != nil {
+			t.Fatalf("page %d: %v", i+1, err)
+		}
+		if !bytes.HasPrefix(b[s[1]+n:], []byte("\nendstream")) {
+			t.Errorf("page %d: stream length %d is wrong", i+1, n)
+		}
+		// Long contents are wrapped over several text runs.
+		var text []byte
+		for _, m := range regexp.MustCompile(`\((.*)\) Tj`).FindAllSubmatch(content, -1) {
+			text = append(text, m[1]...)
+		}
+		for _, w := range sheets[i] {
+			if !bytes.Contains(text, []byte(w.Address)) || !bytes.Contains(text, []byte(w.Secret)) {
+				t.Errorf("page %d: %s is not written out", i+1, w.Address)
+			}
+		}
+		if !bytes.Contains(content, []byte(" re f\n")) {
+			t.Errorf("page %d: no QR modules", i+1)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package paper
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WritePDF writes the sheets as a PDF document with one A4 page per
+// sheet. Text uses the standard Courier font, which every PDF reader has,
+// so no font is embedded.
+func WritePDF(w io.Writer, sheets [][]Wallet) error {
+	// Objects 1 to 3 are the catalog, the page tree and the font, then
+	// each page is followed by its content stream.
+	var objects [][]byte
+	kids := make([]string, len(sheets))
+	objects = append(objects,
+		[]byte("<< /Type /Catalog /Pages 2 0 R >>"),
+		nil, // the page tree is filled in below
+		[]byte("<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>"))
+
+	for i, wallets := range sheets {
+		c := &pdfCanvas{}
+		if err := draw(c, wallets); err != nil {
+			return err
+		}
+		content, err := deflate(c.buf.Bytes())
+		if err != nil {
+			return err
+		}
+
+		page := len(objects) + 1
+		kids[i] = fmt.Sprintf("%d 0 R", page)
+		objects = append(objects,
+			[]byte(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", page+1)),
+			append([]byte(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n", len(content))),
+				append(content, "\nendstream"...)...))
+	}
+	objects[1] = []byte(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d /MediaBox [0 0 %d %d] >>",
+		strings.Join(kids, " "), len(sheets), PageWidth, PageHeight))
+
+	bw := bufio.NewWriter(w)
+	cw := &countingWriter{w: bw}
+	// the binary comment marks the file as binary for transfer programs
+	io.WriteString(cw, "%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int64, len(objects))
+	for i, obj := range objects {
+		offsets[i] = cw.n
+		fmt.Fprintf(cw, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := cw.n
+	fmt.Fprintf(cw, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(cw, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(cw, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return bw.Flush()
+}
+
+// pdfCanvas writes a page content stream. PDF coordinates start at the
+// bottom left, so y is flipped.
+type pdfCanvas struct {
+	buf bytes.Buffer
+}
+
+func (c *pdfCanvas) rect(x, y, w, h float64) {
+	fmt.Fprintf(&c.buf, "%.2f %.2f %.2f %.2f re f\n", x, PageHeight-y-h, w, h)
+}
+
+func (c *pdfCanvas) dashed(x1, y1, x2, y2 float64) {
+	fmt.Fprintf(&c.buf, "q 0.5 G 0.5 w [4 3] 0 d %.2f %.2f m %.2f %.2f l S Q\n",
+		x1, PageHeight-y1, x2, PageHeight-y2)
+}
+
+func (c *pdfCanvas) text(x, y, size float64, s string) {
+	fmt.Fprintf(&c.buf, "BT /F1 %g Tf %.2f %.2f Td (%s) Tj ET\n", size, x, PageHeight-y, pdfEscape(s))
+}
+
+// pdfEscape escapes s for a PDF literal string.
+func pdfEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`).Replace(s)
+}
+
+func deflate(b []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	if _, err := zw.Write(b); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// countingWriter tracks the byte offsets needed by the xref table.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,86 @@
+package paper
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+)
+
+// DPI is the resolution of PNG sheets, enough for QR codes and the small
+// text to print sharply.
+const DPI = 200
+
+// WritePNG writes a sheet of wallets as a grayscale A4 PNG image at DPI.
+func WritePNG(w io.Writer, wallets []Wallet) error {
+	scale := float64(DPI) / 72
+	img := image.NewGray(image.Rect(0, 0, int(math.Round(PageWidth*scale)), int(math.Round(PageHeight*scale))))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	c := &pngCanvas{img: img, scale: scale}
+	if err := draw(c, wallets); err != nil {
+		return err
+	}
+	return png.Encode(w, img)
+}
+
+type pngCanvas struct {
+	img   *image.Gray
+	scale float64
+}
+
+// fill sets the pixels of the rectangle. Edges are rounded to the pixel
+// grid rather than anti-aliased, which keeps adjacent QR modules
+// touching.
+func (c *pngCanvas) fill(x, y, w, h float64, g color.Gray) {
+	r := image.Rect(
+		int(math.Round(x*c.scale)), int(math.Round(y*c.scale)),
+		int(math.Round((x+w)*c.scale)), int(math.Round((y+h)*c.scale)))
+	r = r.Intersect(c.img.Rect)
+	for py := r.Min.Y; py < r.Max.Y; py++ {
+		for px := r.Min.X; px < r.Max.X; px++ {
+			c.img.SetGray(px, py, g)
+		}
+	}
+}
+
+func (c *pngCanvas) rect(x, y, w, h float64) {
+	c.fill(x, y, w, h, color.Gray{})
+}
+
+func (c *pngCanvas) dashed(x1, y1, x2, y2 float64) {
+	const dash, gap, width = 4, 3, 0.5
+	length := math.Hypot(x2-x1, y2-y1)
+	if length == 0 {
+		return
+	}
+	dx, dy := (x2-x1)/length, (y2-y1)/length
+	for d := 0.0; d < length; d += dash + gap {
+		end := math.Min(d+dash, length)
+		// the layout only has horizontal and vertical lines
+		sx, sy := x1+dx*d, y1+dy*d
+		ex, ey := x1+dx*end, y1+dy*end
+		c.fill(math.Min(sx, ex)-width/2, math.Min(sy, ey)-width/2,
+			math.Abs(ex-sx)+width, math.Abs(ey-sy)+width, color.Gray{Y: 0x88})
+	}
+}
+
+// text draws s with the 5x7 bitmap font in cells of 6x8 font pixels, so
+// that the advance is charWidth of size like Courier.
+func (c *pngCanvas) text(x, y, size float64, s string) {
+	p := charWidth * size / 6
+	top := y - 7*p
+	for i := 0; i < len(s); i++ {
+		rows := glyph(s[i])
+		left := x + float64(i)*6*p
+		for r, bits := range rows {
+			for col := 0; col < 5; col++ {
+				if bits&(0x10>>col) != 0 {
+					c.fill(left+float64(col)*p, top+float64(r)*p, p, p, color.Gray{})
+				}
+			}
+		}
+	}
+}
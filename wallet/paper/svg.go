@@ -0,0 +1,42 @@
+package paper
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// WriteSVG writes a sheet of wallets as an SVG document sized to A4.
+func WriteSVG(w io.Writer, wallets []Wallet) error {
+	c := &svgCanvas{}
+	fmt.Fprintf(&c.buf, `<?xml version="1.0" encoding="UTF-8"?>
+<svg xmlns="http://www.w3.org/2000/svg" width="210mm" height="297mm" viewBox="0 0 %d %d">
+<rect width="100%%" height="100%%" fill="#fff"/>
+`, PageWidth, PageHeight)
+	if err := draw(c, wallets); err != nil {
+		return err
+	}
+	c.buf.WriteString("</svg>\n")
+	_, err := c.buf.WriteTo(w)
+	return err
+}
+
+type svgCanvas struct {
+	buf bytes.Buffer
+}
+
+func (c *svgCanvas) rect(x, y, w, h float64) {
+	// crispEdges keeps adjacent QR modules from showing hairline gaps
+	fmt.Fprintf(&c.buf, `<rect x="%.2f" y="%.2f" width="%.2f" height="%.2f" shape-rendering="crispEdges"/>`+"\n", x, y, w, h)
+}
+
+func (c *svgCanvas) dashed(x1, y1, x2, y2 float64) {
+	fmt.Fprintf(&c.buf, `<line x1="%.2f" y1="%.2f" x2="%.2f" y2="%.2f" stroke="#888" stroke-width="0.5" stroke-dasharray="4 3"/>`+"\n", x1, y1, x2, y2)
+}
+
+func (c *svgCanvas) text(x, y, size float64, s string) {
+	fmt.Fprintf(&c.buf, `<text x="%.2f" y="%.2f" font-family="Courier, monospace" font-size="%g" xml:space="preserve">`, x, y, size)
+	xml.EscapeText(&c.buf, []byte(s))
+	c.buf.WriteString("</text>\n")
+}
@@ -0,0 +1,131 @@
+// Package schnorr implements BIP340 Schnorr signatures over secp256k1 with
+// x-only public keys, as used by taproot key path spends.
+//
+// https://github.com/bitcoin/bips/blob/master/bip-0340.mediawiki
+package schnorr
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/smallnest/bitcoin/wallet/ecc"
+	"github.com/smallnest/bitcoin/wallet/taproot"
+)
+
+var (
+	// ErrInvalidSignature is returned for signatures that are not 64 bytes
+	// or whose r or s are out of range.
+	ErrInvalidSignature = errors.New("schnorr: invalid signature")
+	// ErrInvalidPublicKey is returned for x-only public keys that are not
+	// on the curve.
+	ErrInvalidPublicKey = errors.New("schnorr: invalid public key")
+)
+
+// PublicKey returns the 32-byte x-only public key of a private key.
+func PublicKey(privateKey []byte) ([]byte, error) {
+	if !ecc.ValidScalar(privateKey) {
+		return nil, ecc.ErrInvalidScalar
+	}
+	return ecc.ScalarBaseMult(privateKey).Serialize(true)[1:], nil
+}
+
+// Sign signs a message with a private key and 32 bytes of fresh auxiliary
+// randomness, and returns the 64-byte signature. aux may be all zeros, the
+// nonce is still derived from the key and the message. Taproot signs
+// 32-byte hashes, but BIP340 allows messages of any length.
+func Sign(msg, privateKey, aux []byte) ([]byte, error) {
+	if len(aux) != 32 {
+		return nil, errors.New("schnorr: auxiliary randomness must be 32 bytes")
+	}
+	if !ecc.ValidScalar(privateKey) {
+		return nil, ecc.ErrInvalidScalar
+	}
+
+	// Use the key whose public key has an even y.
+	d := new(big.Int).SetBytes(privateKey)
+	p := ecc.ScalarBaseMult(privateKey)
+	if p.Y.Bit(0) == 1 {
+		d.Sub(ecc.N, d)
+	}
+	px := p.Serialize(true)[1:]
+
+	t := taproot.TaggedHash("BIP0340/aux", aux)
+	db := scalarBytes(d)
+	for i := range t {
+		t[i] ^= db[i]
+	}
+	rand := taproot.TaggedHash("BIP0340/nonce", t[:], px, msg)
+	k := new(big.Int).Mod(new(big.Int).SetBytes(rand[:]), ecc.N)
+	if k.Sign() == 0 {
+		return nil, errors.New("schnorr: nonce is zero")
+	}
+	r := ecc.ScalarBaseMult(scalarBytes(k))
+	if r.Y.Bit(0) == 1 {
+		k.Sub(ecc.N, k)
+	}
+	rx := r.Serialize(true)[1:]
+
+	e := challenge(rx, px, msg)
+	s := new(big.Int).Mul(e, d)
+	s.Add(s, k)
+	s.Mod(s, ecc.N)
+
+	sig := append(append([]byte(nil), rx...), scalarBytes(s)...)
+	// Do not hand out a signature that would not verify, it could leak
+	// the key after a fault.
+	if !Verify(msg, sig, px) {
+		return nil, errors.New("schnorr: signature does not verify")
+	}
+	return sig, nil
+}
+
+// Verify reports whether a 64-byte signature of the message is valid for
+// the x-only public key.
+func Verify(msg, signature, pubKey []byte) bool {
+	if len(signature) != 64 || len(pubKey) != 32 {
+		return false
+	}
+	p, err := LiftX(pubKey)
+	if err != nil {
+		return false
+	}
+	r := new(big.Int).SetBytes(signature[:32])
+	s := new(big.Int).SetBytes(signature[32:])
+	if r.Cmp(ecc.P) >= 0 || s.Cmp(ecc.N) >= 0 {
+		return false
+	}
+
+	// R = s·G - e·P
+	e := challenge(signature[:32], pubKey, msg)
+	e.Sub(ecc.N, e)
+	R := ecc.Add(ecc.ScalarBaseMult(scalarBytes(s)), ecc.ScalarMult(p, scalarBytes(e)))
+	if R.IsInfinity() || R.Y.Bit(0) == 1 {
+		return false
+	}
+	return R.X.Cmp(r) == 0
+}
+
+// LiftX returns the point with the x-only public key as x coordinate and
+// an even y.
+func LiftX(pubKey []byte) (*ecc.Point, error) {
+	if len(pubKey) != 32 {
+		return nil, ErrInvalidPublicKey
+	}
+	p, err := ecc.ParsePoint(append([]byte{0x02}, pubKey...))
+	if err != nil {
+		return nil, ErrInvalidPublicKey
+	}
+	return p, nil
+}
+
+// challenge returns int(H_BIP0340/challenge(r || P || m)) mod N.
+func challenge(rx, px, msg []byte) *big.Int {
+	h := taproot.TaggedHash("BIP0340/challenge", rx, px, msg)
+	e := new(big.Int).SetBytes(h[:])
+	return e.Mod(e, ecc.N)
+}
+
+// scalarBytes returns n as 32 big-endian bytes.
+func scalarBytes(n *big.Int) []byte {
+	return n.FillBytes(make([]byte, 32))
+}
@@ -0,0 +1,104 @@
+package schnorr
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/hex"
+	"os"
+	"testing"
+)
+
+// TestVectors runs testdata/test-vectors.csv from BIP340. Vectors with a
+// secret key are signed and verified, the others only verified.
+func TestVectors(t *testing.T) {
+	f, err := os.Open("testdata/test-vectors.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, r := range records[1:] {
+		index, comment := r[0], r[7]
+		secretKey := decodeHex(t, r[1])
+		publicKey := decodeHex(t, r[2])
+		aux := decodeHex(t, r[3])
+		msg := decodeHex(t, r[4])
+		signature := decodeHex(t, r[5])
+		valid := r[6] == "TRUE"
+
+		if len(secretKey) > 0 {
+			pub, err := PublicKey(secretKey)
+			if err != nil {
+				t.Errorf("vector %s: PublicKey: %v", index, err)
+				continue
+			}
+			if !bytes.Equal(pub, publicKey) {
+				t.Errorf("vector %s: public key %X, want %X", index, pub, publicKey)
+			}
+			sig, err := Sign(msg, secretKey, aux)
+			if err != nil {
+				t.Errorf("vector %s: Sign: %v", index, err)
+				continue
+			}
+			if !bytes.Equal(sig, signature) {
+				t.Errorf("vector %s: signature %X, want %X", index, sig, signature)
+			}
+		}
+		if Verify(msg, signature, publicKey) != valid {
+			t.Errorf("vector %s: Verify is not %v (%s)", index, valid, comment)
+		}
+	}
+}
+
+// TestBIP341KeyPathSpend signs the sighash of input 0 of the
+// keyPathSpending vector of BIP341 with its tweaked private key and all
+// zero auxiliary randomness.
+func TestBIP341KeyPathSpend(t *testing.T) {
+	tweakedKey := decodeHex(t, "2405b971772ad26915c8dcdf10f238753a9b837e5f8e6a86fd7c0cce5b7296d9")
+	outputKey := decodeHex(t, "53a1f6e454df1aa2776a2814a721372d6258050de330b3c6d10ee8f4e0dda343")
+	sigHash := decodeHex(t, "2514a6272f85cfa0f45eb907fcb0d121b808ed37c6ea160a5a9046ed5526d555")
+	// The vector appends the hash type 0x03, SIGHASH_SINGLE.
+	signature := decodeHex(t, "ed7c1647cb97379e76892be0cacff57ec4a7102aa24296ca39af7541246d8ff14d38958d4cc1e2e478e4d4a764bbfd835b16d4e314b72937b29833060b87276c")
+
+	if pub, err := PublicKey(tweakedKey); err != nil || !bytes.Equal(pub, outputKey) {
+		t.Errorf("public key %x, %v, want %x", pub, err, outputKey)
+	}
+	sig, err := Sign(sigHash, tweakedKey, make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(sig, signature) {
+		t.Errorf("signature %x, want %x", sig, signature)
+	}
+	if !Verify(sigHash, signature, outputKey) {
+		t.Error("signature does not verify")
+	}
+}
+
+func TestLiftX(t *testing.T) {
+	for _, s := range []string{
+		// not on the curve
+		"EEFDEA4CDB677750A420FEE807EACF21EB9898AE79B9768766E4FAA04A2D4A34",
+		// exceeds the field size
+		"FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEFFFFFC30",
+		// too short
+		"DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA6",
+	} {
+		if _, err := LiftX(decodeHex(t, s)); err != ErrInvalidPublicKey {
+			t.Errorf("LiftX(%s) = %v, want ErrInvalidPublicKey", s, err)
+		}
+	}
+}
+
+func decodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
@@ -0,0 +1,63 @@
+package slip39
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	baseIterationCount = 10000
+	roundCount         = 4
+)
+
+// encrypt encrypts the master secret with the passphrase in a 4-round
+// Feistel network whose round function is PBKDF2-HMAC-SHA256.
+func encrypt(masterSecret []byte, passphrase string, iterationExponent byte, identifier uint16, extendable bool) []byte {
+	half := len(masterSecret) / 2
+	l, r := masterSecret[:half], masterSecret[half:]
+	salt := cipherSalt(identifier, extendable)
+	for i := 0; i < roundCount; i++ {
+		l, r = r, xor(l, roundFunction(byte(i), passphrase, iterationExponent, salt, r))
+	}
+	return append(append([]byte(nil), r...), l...)
+}
+
+// decrypt is encrypt with the rounds in reverse order.
+func decrypt(encrypted []byte, passphrase string, iterationExponent byte, identifier uint16, extendable bool) []byte {
+	half := len(encrypted) / 2
+	l, r := encrypted[:half], encrypted[half:]
+	salt := cipherSalt(identifier, extendable)
+	for i := roundCount - 1; i >= 0; i-- {
+		l, r = r, xor(l, roundFunction(byte(i), passphrase, iterationExponent, salt, r))
+	}
+	return append(append([]byte(nil), r...), l...)
+}
+
+func roundFunction(i byte, passphrase string, iterationExponent byte, salt, r []byte) []byte {
+	iterations := (baseIterationCount << iterationExponent) / roundCount
+	password := append([]byte{i}, passphrase...)
+	return pbkdf2.Key(password, append(append([]byte(nil), salt...), r...), iterations, len(r), sha256.New)
+}
+
+// cipherSalt is "shamir" and the identifier for shares that are not
+// extendable, empty otherwise so that new shares with another identifier
+// can be added later.
+func cipherSalt(identifier uint16, extendable bool) []byte {
+	if extendable {
+		return nil
+	}
+	salt := make([]byte, len(customizationNonExtendable)+2)
+	copy(salt, customizationNonExtendable)
+	binary.BigEndian.PutUint16(salt[len(customizationNonExtendable):], identifier)
+	return salt
+}
+
+func xor(a, b []byte) []byte {
+	out := make([]byte, len(a))
+	for i := range a {
+		out[i] = a[i] ^ b[i]
+	}
+	return out
+}
@@ -0,0 +1,146 @@
+package slip39
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"io"
+)
+
+const (
+	// secretIndex and digestIndex are the x coordinates of the shared
+	// secret and of its digest, out of the range of share indexes.
+	secretIndex  = 255
+	digestIndex  = 254
+	digestLength = 4
+)
+
+// exp and log are the tables of GF(256) with the Rijndael polynomial
+// x⁸ + x⁴ + x³ + x + 1 and the generator x + 1.
+var exp, log [256]byte
+
+func init() {
+	x := 1
+	for i := 0; i < 255; i++ {
+		exp[i] = byte(x)
+		log[x] = byte(i)
+		// x·(x + 1) = x·x ⊕ x
+		x = x<<1 ^ x
+		if x&0x100 != 0 {
+			x ^= 0x11b
+		}
+	}
+}
+
+// point is a share of a secret: the value at x of a polynomial for each
+// byte.
+type point struct {
+	x     byte
+	value []byte
+}
+
+// interpolate returns the value at x of the polynomials through the
+// points, using Lagrange interpolation in GF(256).
+func interpolate(points []point, x byte) ([]byte, error) {
+	for _, p := range points {
+		if p.x == x {
+			return p.value, nil
+		}
+	}
+
+	// log of the product of (x_i - x) for all i
+	logProd := 0
+	for _, p := range points {
+		logProd += int(log[p.x^x])
+	}
+
+	result := make([]byte, len(points[0].value))
+	for _, p := range points {
+		if len(p.value) != len(result) {
+			return nil, errors.New("slip39: shares have different lengths")
+		}
+		// log of the Lagrange basis polynomial at x:
+		// prod(x_j - x) / (x_i - x) / prod(x_j - x_i) for j != i
+		logBasis := logProd - int(log[p.x^x])
+		for _, q := range points {
+			if q.x != p.x {
+				logBasis -= int(log[p.x^q.x])
+			}
+		}
+		logBasis = (logBasis%255 + 255) % 255
+		for i, b := range p.value {
+			if b != 0 {
+				result[i] ^= exp[(int(log[b])+logBasis)%255]
+			}
+		}
+	}
+	return result, nil
+}
+
+// splitSecret returns count shares of secret, any threshold of which
+// recover it. The polynomial goes through threshold - 2 random shares, the
+// secret at secretIndex and a digest of the secret at digestIndex, so that
+// a wrong combination of shares is detected.
+func splitSecret(src io.Reader, threshold, count int, secret []byte) ([]point, error) {
+	if threshold == 1 {
+		points := make([]point, count)
+		for i := range points {
+			points[i] = point{byte(i), secret}
+		}
+		return points, nil
+	}
+
+	randomCount := threshold - 2
+	points := make([]point, 0, count)
+	for i := 0; i < randomCount; i++ {
+		value := make([]byte, len(secret))
+		if _, err := io.ReadFull(src, value); err != nil {
+			return nil, err
+		}
+		points = append(points, point{byte(i), value})
+	}
+
+	randomPart := make([]byte, len(secret)-digestLength)
+	if _, err := io.ReadFull(src, randomPart); err != nil {
+		return nil, err
+	}
+	base := append([]point(nil), points...)
+	base = append(base,
+		point{digestIndex, append(digest(randomPart, secret), randomPart...)},
+		point{secretIndex, secret})
+
+	for i := randomCount; i < count; i++ {
+		value, err := interpolate(base, byte(i))
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, point{byte(i), value})
+	}
+	return points, nil
+}
+
+// recoverSecret recovers the secret from threshold shares and checks its
+// digest.
+func recoverSecret(threshold int, points []point) ([]byte, error) {
+	if threshold == 1 {
+		return points[0].value, nil
+	}
+	secret, err := interpolate(points, secretIndex)
+	if err != nil {
+		return nil, err
+	}
+	digestShare, err := interpolate(points, digestIndex)
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal(digestShare[:digestLength], digest(digestShare[digestLength:], secret)) {
+		return nil, ErrDigest
+	}
+	return secret, nil
+}
+
+func digest(randomPart, secret []byte) []byte {
+	mac := hmac.New(sha256.New, randomPart)
+	mac.Write(secret)
+	return mac.Sum(nil)[:digestLength]
+}
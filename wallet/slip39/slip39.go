@@ -0,0 +1,352 @@
+// Package slip39 implements SLIP-39 Shamir backups: a master secret is
+// encrypted with a passphrase and split into groups of mnemonic shares, a
+// threshold of groups each with a threshold of its shares recovers it.
+//
+// https://github.com/satoshilabs/slips/blob/master/slip-0039.md
+package slip39
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+)
+
+const (
+	radixBits = 10
+	// idLengthBits, the extendable flag and iterationExponentBits make
+	// the first 2 words, the group and member parameters the next 2.
+	idLengthBits          = 15
+	iterationExponentBits = 4
+	checksumWords         = 3
+	metadataWords         = 2 + 2 + checksumWords
+	// MinSecretLength is the shortest master secret, 128 bits.
+	MinSecretLength = 16
+	// MaxShares is the largest number of groups, or of members in a
+	// group.
+	MaxShares = 16
+
+	customizationNonExtendable = "shamir"
+	customizationExtendable    = "shamir_extendable"
+)
+
+var (
+	// ErrChecksum is returned for a share whose checksum words do not
+	// match.
+	ErrChecksum = errors.New("slip39: invalid share checksum")
+	// ErrDigest is returned when shares of different secrets, or too few
+	// shares, are combined.
+	ErrDigest = errors.New("slip39: invalid digest of the shared secret, the shares do not belong together")
+	// ErrPassphrase is returned for passphrases with other characters than
+	// printable ASCII.
+	ErrPassphrase = errors.New("slip39: the passphrase must be printable ASCII")
+)
+
+// InvalidWordError is returned for a word that is not in the wordlist.
+type InvalidWordError struct {
+	Word string
+}
+
+func (e InvalidWordError) Error() string {
+	return fmt.Sprintf("slip39: %q is not a SLIP-39 word", e.Word)
+}
+
+var wordIndex = func() map[string]int {
+	if len(Wordlist) != 1024 {
+		panic(fmt.Sprintf("slip39: wordlist has %d words", len(Wordlist)))
+	}
+	m := make(map[string]int, len(Wordlist))
+	for i, w := range Wordlist {
+		m[w] = i
+	}
+	return m
+}()
+
+// Share is one decoded mnemonic share.
+type Share struct {
+	// Identifier is a random number that all the shares of a backup
+	// share.
+	Identifier uint16
+	// Extendable shares can be recombined with new share sets of the same
+	// master secret.
+	Extendable bool
+	// IterationExponent sets the PBKDF2 iterations of the encryption to
+	// 10000·2^e.
+	IterationExponent byte
+	GroupIndex        int
+	GroupThreshold    int
+	GroupCount        int
+	MemberIndex       int
+	MemberThreshold   int
+	Value             []byte
+}
+
+// Group is the member threshold and count of a group of shares.
+type Group struct {
+	Threshold, Count int
+}
+
+// ParseShare decodes a mnemonic share and checks its checksum.
+func ParseShare(mnemonic string) (*Share, error) {
+	words := strings.Fields(strings.ToLower(mnemonic))
+	if len(words) < metadataWords+(MinSecretLength*8+radixBits-1)/radixBits {
+		return nil, fmt.Errorf("slip39: a share needs at least %d words", metadataWords+(MinSecretLength*8+radixBits-1)/radixBits)
+	}
+	data := make([]int, len(words))
+	for i, w := range words {
+		idx, ok := wordIndex[w]
+		if !ok {
+			return nil, InvalidWordError{w}
+		}
+		data[i] = idx
+	}
+
+	// id (15 bits) | extendable (1) | iteration exponent (4)
+	prefix := data[0]<<radixBits | data[1]
+	s := &Share{
+		Identifier:        uint16(prefix >> (iterationExponentBits + 1)),
+		Extendable:        prefix>>iterationExponentBits&1 == 1,
+		IterationExponent: byte(prefix & (1<<iterationExponentBits - 1)),
+	}
+	if !verifyChecksum(data, s.Extendable) {
+		return nil, ErrChecksum
+	}
+
+	// group index | group threshold - 1 | group count - 1 | member index | member threshold - 1, 4 bits each
+	params := data[2]<<radixBits | data[3]
+	s.GroupIndex = params >> 16 & 0xf
+	s.GroupThreshold = params>>12&0xf + 1
+	s.GroupCount = params>>8&0xf + 1
+	s.MemberIndex = params >> 4 & 0xf
+	s.MemberThreshold = params&0xf + 1
+	if s.GroupThreshold > s.GroupCount {
+		return nil, errors.New("slip39: group threshold greater than the group count")
+	}
+
+	// The value is padded with up to 8 zero bits to whole words and is a
+	// whole number of 16-bit words.
+	valueWords := data[4 : len(data)-checksumWords]
+	padding := radixBits * len(valueWords) % 16
+	if padding > 8 {
+		return nil, errors.New("slip39: invalid share length")
+	}
+	if valueWords[0] >= 1<<(radixBits-padding) {
+		return nil, errors.New("slip39: invalid share padding")
+	}
+	v := new(big.Int)
+	for _, w := range valueWords {
+		v.Lsh(v, radixBits).Or(v, big.NewInt(int64(w)))
+	}
+	s.Value = v.FillBytes(make([]byte, (radixBits*len(valueWords)-padding)/8))
+	return s, nil
+}
+
+// Mnemonic encodes the share.
+func (s *Share) Mnemonic() string {
+	var data []int
+	prefix := int(s.Identifier)<<(iterationExponentBits+1) | int(s.IterationExponent)
+	if s.Extendable {
+		prefix |= 1 << iterationExponentBits
+	}
+	params := s.GroupIndex<<16 | (s.GroupThreshold-1)<<12 | (s.GroupCount-1)<<8 | s.MemberIndex<<4 | (s.MemberThreshold - 1)
+	data = append(data, prefix>>radixBits, prefix&(1<<radixBits-1), params>>radixBits, params&(1<<radixBits-1))
+
+	// Split the value in 10-bit words, the padding goes in the first word.
+	v := new(big.Int).SetBytes(s.Value)
+	mask := big.NewInt(1<<radixBits - 1)
+	for i := (len(s.Value)*8+radixBits-1)/radixBits - 1; i >= 0; i-- {
+		w := new(big.Int).Rsh(v, uint(i*radixBits))
+		data = append(data, int(w.And(w, mask).Int64()))
+	}
+	data = append(data, createChecksum(data, s.Extendable)...)
+
+	words := make([]string, len(data))
+	for i, idx := range data {
+		words[i] = Wordlist[idx]
+	}
+	return strings.Join(words, " ")
+}
+
+// Split encrypts the master secret with the passphrase and splits it into
+// groups of shares: any groupThreshold of the groups, each with the
+// threshold of its shares, recover it. The shares are extendable, like
+// those of current wallets. iterationExponent sets the cost of the
+// encryption, 1 is the usual value.
+func Split(src io.Reader, masterSecret []byte, passphrase string, groupThreshold int, groups []Group, iterationExponent byte) ([][]string, error) {
+	if len(masterSecret) < MinSecretLength || len(masterSecret)%2 != 0 {
+		return nil, fmt.Errorf("slip39: the master secret must be an even number of bytes, at least %d", MinSecretLength)
+	}
+	if !validPassphrase(passphrase) {
+		return nil, ErrPassphrase
+	}
+	if iterationExponent >= 1<<iterationExponentBits {
+		return nil, fmt.Errorf("slip39: iteration exponent must be below %d", 1<<iterationExponentBits)
+	}
+	if len(groups) == 0 || len(groups) > MaxShares {
+		return nil, fmt.Errorf("slip39: there must be 1 to %d groups", MaxShares)
+	}
+	if groupThreshold < 1 || groupThreshold > len(groups) {
+		return nil, fmt.Errorf("slip39: group threshold must be between 1 and %d", len(groups))
+	}
+	for _, g := range groups {
+		if g.Count < 1 || g.Count > MaxShares || g.Threshold < 1 || g.Threshold > g.Count {
+			return nil, fmt.Errorf("slip39: invalid group %d-of-%d", g.Threshold, g.Count)
+		}
+		if g.Threshold == 1 && g.Count > 1 {
+			return nil, errors.New("slip39: a 1-of-n group is the same secret n times, use 1-of-1 instead")
+		}
+	}
+
+	var id [2]byte
+	if _, err := io.ReadFull(src, id[:]); err != nil {
+		return nil, err
+	}
+	identifier := (uint16(id[0])<<8 | uint16(id[1])) & (1<<idLengthBits - 1)
+	encrypted := encrypt(masterSecret, passphrase, iterationExponent, identifier, true)
+
+	groupShares, err := splitSecret(src, groupThreshold, len(groups), encrypted)
+	if err != nil {
+		return nil, err
+	}
+	mnemonics := make([][]string, len(groups))
+	for i, g := range groups {
+		memberShares, err := splitSecret(src, g.Threshold, g.Count, groupShares[i].value)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range memberShares {
+			s := &Share{
+				Identifier:        identifier,
+				Extendable:        true,
+				IterationExponent: iterationExponent,
+				GroupIndex:        i,
+				GroupThreshold:    groupThreshold,
+				GroupCount:        len(groups),
+				MemberIndex:       int(m.x),
+				MemberThreshold:   g.Threshold,
+				Value:             m.value,
+			}
+			mnemonics[i] = append(mnemonics[i], s.Mnemonic())
+		}
+	}
+	return mnemonics, nil
+}
+
+// Combine recovers the master secret from enough mnemonic shares and
+// decrypts it with the passphrase. A wrong passphrase is not detected, it
+// gives another master secret.
+func Combine(mnemonics []string, passphrase string) ([]byte, error) {
+	if len(mnemonics) == 0 {
+		return nil, errors.New("slip39: no shares")
+	}
+	if !validPassphrase(passphrase) {
+		return nil, ErrPassphrase
+	}
+
+	var first *Share
+	groups := map[int][]*Share{}
+	for _, m := range mnemonics {
+		s, err := ParseShare(m)
+		if err != nil {
+			return nil, err
+		}
+		if first == nil {
+			first = s
+		} else if s.Identifier != first.Identifier || s.Extendable != first.Extendable ||
+			s.IterationExponent != first.IterationExponent || s.GroupThreshold != first.GroupThreshold ||
+			s.GroupCount != first.GroupCount || len(s.Value) != len(first.Value) {
+			return nil, errors.New("slip39: the shares belong to different backups")
+		}
+		for _, o := range groups[s.GroupIndex] {
+			if o.MemberIndex == s.MemberIndex {
+				return nil, fmt.Errorf("slip39: share %d of group %d given twice", s.MemberIndex+1, s.GroupIndex+1)
+			}
+		}
+		groups[s.GroupIndex] = append(groups[s.GroupIndex], s)
+	}
+
+	if len(groups) < first.GroupThreshold {
+		return nil, fmt.Errorf("slip39: shares of %d groups are needed, got %d", first.GroupThreshold, len(groups))
+	}
+	if len(groups) > first.GroupThreshold {
+		return nil, fmt.Errorf("slip39: shares of exactly %d groups are needed, got %d", first.GroupThreshold, len(groups))
+	}
+
+	var groupPoints []point
+	for index, shares := range groups {
+		threshold := shares[0].MemberThreshold
+		var points []point
+		for _, s := range shares {
+			if s.MemberThreshold != threshold {
+				return nil, fmt.Errorf("slip39: the shares of group %d have different thresholds", index+1)
+			}
+			points = append(points, point{byte(s.MemberIndex), s.Value})
+		}
+		if len(points) != threshold {
+			return nil, fmt.Errorf("slip39: group %d needs %d shares, got %d", index+1, threshold, len(points))
+		}
+		secret, err := recoverSecret(threshold, points)
+		if err != nil {
+			return nil, err
+		}
+		groupPoints = append(groupPoints, point{byte(index), secret})
+	}
+
+	encrypted, err := recoverSecret(first.GroupThreshold, groupPoints)
+	if err != nil {
+		return nil, err
+	}
+	return decrypt(encrypted, passphrase, first.IterationExponent, first.Identifier, first.Extendable), nil
+}
+
+func validPassphrase(passphrase string) bool {
+	for i := 0; i < len(passphrase); i++ {
+		if passphrase[i] < 32 || passphrase[i] > 126 {
+			return false
+		}
+	}
+	return true
+}
+
+// gen is the generator of the RS1024 checksum code.
+var gen = [10]int{
+	0xE0E040, 0x1C1C080, 0x3838100, 0x7070200, 0xE0E0009,
+	0x1C0C2412, 0x38086C24, 0x3090FC48, 0x21B1F890, 0x3F3F120,
+}
+
+func polymod(values []int) int {
+	chk := 1
+	for _, v := range values {
+		b := chk >> 20
+		chk = (chk&0xFFFFF)<<10 ^ v
+		for i := 0; i < 10; i++ {
+			if b>>i&1 == 1 {
+				chk ^= gen[i]
+			}
+		}
+	}
+	return chk
+}
+
+func customization(extendable bool) []int {
+	s := customizationNonExtendable
+	if extendable {
+		s = customizationExtendable
+	}
+	values := make([]int, len(s))
+	for i := range s {
+		values[i] = int(s[i])
+	}
+	return values
+}
+
+func createChecksum(data []int, extendable bool) []int {
+	values := append(customization(extendable), data...)
+	pm := polymod(append(values, 0, 0, 0)) ^ 1
+	return []int{pm >> 20 & 1023, pm >> 10 & 1023, pm & 1023}
+}
+
+func verifyChecksum(data []int, extendable bool) bool {
+	return polymod(append(customization(extendable), data...)) == 1
+}
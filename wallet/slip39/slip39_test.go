@@ -0,0 +1,213 @@
+package slip39
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"math/rand"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/smallnest/bitcoin/wallet/address"
+	"github.com/smallnest/bitcoin/wallet/hdkey"
+)
+
+// vector is an entry of testdata/vectors.json, in the format of the
+// SLIP-39 reference implementation: a description, the mnemonics, the
+// master secret and its BIP32 master key, both empty when the mnemonics
+// are invalid. The passphrase is always "TREZOR".
+//
+// https://github.com/trezor/python-shamir-mnemonic/blob/master/vectors.json
+type vector struct {
+	description string
+	mnemonics   []string
+	secret      string
+	xprv        string
+}
+
+func (v *vector) UnmarshalJSON(b []byte) error {
+	return json.Unmarshal(b, &[]interface{}{&v.description, &v.mnemonics, &v.secret, &v.xprv})
+}
+
+func readVectors(t *testing.T) []vector {
+	b, err := os.ReadFile("testdata/vectors.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var vectors []vector
+	if err := json.Unmarshal(b, &vectors); err != nil {
+		t.Fatal(err)
+	}
+	return vectors
+}
+
+func TestVectors(t *testing.T) {
+	for _, v := range readVectors(t) {
+		secret, err := Combine(v.mnemonics, "TREZOR")
+		if v.secret == "" {
+			if err == nil {
+				t.Errorf("%s: got %x, want an error", v.description, secret)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", v.description, err)
+			continue
+		}
+		if got := hex.EncodeToString(secret); got != v.secret {
+			t.Errorf("%s: secret %s, want %s", v.description, got, v.secret)
+		}
+		master, err := hdkey.NewMaster(secret, address.Mainnet)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := master.String(); got != v.xprv {
+			t.Errorf("%s: xprv %s, want %s", v.description, got, v.xprv)
+		}
+
+		// Every share encodes back to its mnemonic.
+		for _, m := range v.mnemonics {
+			s, err := ParseShare(m)
+			if err != nil {
+				t.Fatalf("%s: %v", v.description, err)
+			}
+			if got := s.Mnemonic(); got != m {
+				t.Errorf("%s: mnemonic %q, want %q", v.description, got, m)
+			}
+		}
+	}
+}
+
+// TestCombineInvalid makes the invalid combinations of the reference
+// vectors from the valid 2-of-3 shares of vectors 4 and 24: the second
+// share is changed and encoded again with a valid checksum, so only the
+// combination is wrong.
+func TestCombineInvalid(t *testing.T) {
+	var shares [][]string
+	for _, v := range readVectors(t) {
+		if strings.HasPrefix(v.description, "4. ") || strings.HasPrefix(v.description, "24. ") {
+			shares = append(shares, v.mnemonics)
+		}
+	}
+	if len(shares) != 2 {
+		t.Fatalf("found %d 2-of-3 vectors, want 2", len(shares))
+	}
+
+	for _, c := range []struct {
+		name string
+		// change changes s, the other share is first.
+		change func(s, first *Share)
+	}{
+		{"different identifiers", func(s, _ *Share) { s.Identifier ^= 1 }},
+		{"different extendable flags", func(s, _ *Share) { s.Extendable = !s.Extendable }},
+		{"different iteration exponents", func(s, _ *Share) { s.IterationExponent++ }},
+		{"different group thresholds", func(s, _ *Share) { s.GroupThreshold, s.GroupCount = 2, 2 }},
+		{"different group counts", func(s, _ *Share) { s.GroupCount = 2 }},
+		{"different member thresholds", func(s, _ *Share) { s.MemberThreshold = 3 }},
+		{"different share lengths", func(s, _ *Share) { s.Value = append(s.Value, 0, 0) }},
+		{"same member twice", func(s, first *Share) { s.MemberIndex = first.MemberIndex }},
+		{"invalid digest", func(s, _ *Share) { s.Value[len(s.Value)-1] ^= 1 }},
+	} {
+		for _, mnemonics := range shares {
+			first, err := ParseShare(mnemonics[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			second, err := ParseShare(mnemonics[1])
+			if err != nil {
+				t.Fatal(err)
+			}
+			c.change(second, first)
+			// The changed share is still a valid mnemonic.
+			if _, err := ParseShare(second.Mnemonic()); err != nil {
+				t.Fatalf("%s: %v", c.name, err)
+			}
+			secret, err := Combine([]string{mnemonics[0], second.Mnemonic()}, "TREZOR")
+			if err == nil {
+				t.Errorf("%s, %d bytes: got %x, want an error", c.name, len(first.Value), secret)
+			}
+			if c.name == "invalid digest" && err != ErrDigest {
+				t.Errorf("%s, %d bytes: %v, want ErrDigest", c.name, len(first.Value), err)
+			}
+		}
+	}
+
+	// Too few members: one share of a 2-of-3 group.
+	for _, mnemonics := range shares {
+		if _, err := Combine(mnemonics[:1], "TREZOR"); err == nil {
+			t.Errorf("one share of %d words combined", len(strings.Fields(mnemonics[0])))
+		}
+	}
+
+	// Too few groups: enough members of one group of a 2-of-2 split.
+	r := rand.New(rand.NewSource(1))
+	split, err := Split(r, make([]byte, 16), "TREZOR", 2, []Group{{2, 2}, {1, 1}}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Combine(split[0], "TREZOR"); err == nil {
+		t.Error("one group of a 2 group threshold combined")
+	}
+	if _, err := Combine(append(split[0][:1:1], split[1]...), "TREZOR"); err == nil {
+		t.Error("too few members of a group combined")
+	}
+}
+
+func TestSplitCombine(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, n := range []int{16, 32, 64} {
+		secret := make([]byte, n)
+		r.Read(secret)
+		groups := []Group{{2, 3}, {1, 1}, {3, 5}}
+		shares, err := Split(r, secret, "TREZOR", 2, groups, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, g := range shares {
+			if len(g) != groups[i].Count {
+				t.Fatalf("group %d has %d shares, want %d", i+1, len(g), groups[i].Count)
+			}
+		}
+
+		got, err := Combine([]string{shares[0][2], shares[0][0], shares[2][4], shares[2][1], shares[2][3]}, "TREZOR")
+		if err != nil {
+			t.Fatalf("%d bytes: %v", n, err)
+		}
+		if !bytes.Equal(got, secret) {
+			t.Errorf("%d bytes: got %x, want %x", n, got, secret)
+		}
+		if got, err := Combine([]string{shares[1][0], shares[0][1], shares[0][2]}, "TREZOR"); err != nil || !bytes.Equal(got, secret) {
+			t.Errorf("%d bytes: got %x, %v", n, got, err)
+		}
+		// A wrong passphrase gives another secret.
+		if got, err := Combine([]string{shares[1][0], shares[0][1], shares[0][2]}, "trezor"); err != nil || bytes.Equal(got, secret) {
+			t.Errorf("%d bytes, wrong passphrase: got %x, %v", n, got, err)
+		}
+		if _, err := Combine([]string{shares[0][0], shares[2][0], shares[2][1]}, "TREZOR"); err == nil {
+			t.Errorf("%d bytes: combined too few shares of group 3", n)
+		}
+	}
+}
+
+func TestSplitInvalid(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, v := range []struct {
+		secret         int
+		passphrase     string
+		groupThreshold int
+		groups         []Group
+	}{
+		{15, "", 1, []Group{{1, 1}}},
+		{17, "", 1, []Group{{1, 1}}},
+		{16, "é", 1, []Group{{1, 1}}},
+		{16, "", 2, []Group{{1, 1}}},
+		{16, "", 1, []Group{{1, 3}}},
+		{16, "", 1, []Group{{4, 3}}},
+		{16, "", 1, []Group{{2, 17}}},
+	} {
+		if _, err := Split(r, make([]byte, v.secret), v.passphrase, v.groupThreshold, v.groups, 0); err == nil {
+			t.Errorf("%+v: no error", v)
+		}
+	}
+}
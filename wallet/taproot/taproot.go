@@ -0,0 +1,104 @@
+// Package taproot implements the BIP341 key tweak that turns an internal
+// public key, and optionally a script tree, into a taproot output key.
+//
+// https://github.com/bitcoin/bips/blob/master/bip-0341.mediawiki
+package taproot
+
+import (
+	"crypto/sha256"
+	"errors"
+	"math/big"
+
+	"github.com/smallnest/bitcoin/wallet/ecc"
+)
+
+// TaggedHash returns SHA256(SHA256(tag) || SHA256(tag) || msg) as defined
+// by BIP340.
+func TaggedHash(tag string, msg ...[]byte) [32]byte {
+	tagHash := sha256.Sum256([]byte(tag))
+	h := sha256.New()
+	h.Write(tagHash[:])
+	h.Write(tagHash[:])
+	for _, m := range msg {
+		h.Write(m)
+	}
+	var sum [32]byte
+	copy(sum[:], h.Sum(nil))
+	return sum
+}
+
+// XOnly returns the 32-byte x-only form of a 33-byte compressed or 32-byte
+// x-only public key.
+func XOnly(pubKey []byte) ([]byte, error) {
+	switch len(pubKey) {
+	case 32:
+		return pubKey, nil
+	case 33:
+		return pubKey[1:], nil
+	}
+	return nil, errors.New("taproot: public key must be 32 or 33 bytes")
+}
+
+// liftX returns the point with the given x coordinate and an even y.
+func liftX(x []byte) (*ecc.Point, error) {
+	return ecc.ParsePoint(append([]byte{0x02}, x...))
+}
+
+// TweakHash returns the BIP341 tweak H_TapTweak(P || merkleRoot). A nil
+// merkleRoot means a key path only output, as used by BIP86.
+func TweakHash(internalKey []byte, merkleRoot []byte) ([32]byte, error) {
+	x, err := XOnly(internalKey)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	if merkleRoot != nil && len(merkleRoot) != 32 {
+		return [32]byte{}, errors.New("taproot: merkle root must be 32 bytes")
+	}
+	return TaggedHash("TapTweak", x, merkleRoot), nil
+}
+
+// OutputKey tweaks the internal public key with the script tree merkle root
+// and returns the x-only output key Q = P + H_TapTweak(P || root)·G and
+// whether Q has an odd y, which a script path spend must reveal.
+func OutputKey(internalKey []byte, merkleRoot []byte) (outputKey []byte, oddY bool, err error) {
+	x, err := XOnly(internalKey)
+	if err != nil {
+		return nil, false, err
+	}
+	p, err := liftX(x)
+	if err != nil {
+		return nil, false, err
+	}
+	t, err := TweakHash(x, merkleRoot)
+	if err != nil {
+		return nil, false, err
+	}
+	if !ecc.ValidScalar(t[:]) {
+		return nil, false, errors.New("taproot: tweak out of range")
+	}
+
+	q := ecc.Add(p, ecc.ScalarBaseMult(t[:]))
+	if q.IsInfinity() {
+		return nil, false, errors.New("taproot: tweaked key is infinity")
+	}
+	return q.Serialize(true)[1:], q.Y.Bit(0) == 1, nil
+}
+
+// TweakPrivateKey returns the private key of the output key of OutputKey:
+// the internal private key, negated if its public key has an odd y, plus
+// the tweak.
+func TweakPrivateKey(privateKey []byte, merkleRoot []byte) ([]byte, error) {
+	if !ecc.ValidScalar(privateKey) {
+		return nil, ecc.ErrInvalidScalar
+	}
+	p := ecc.ScalarBaseMult(privateKey)
+	d := new(big.Int).SetBytes(privateKey)
+	if p.Y.Bit(0) == 1 {
+		d.Sub(ecc.N, d)
+	}
+	t, err := TweakHash(p.Serialize(true), merkleRoot)
+	if err != nil {
+		return nil, err
+	}
+	return ecc.Default.TweakPrivateKey(d.FillBytes(make([]byte, 32)), t[:])
+}
@@ -0,0 +1,123 @@
+package taproot
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/smallnest/bitcoin/wallet/ecc"
+)
+
+func decodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+	if s == "" {
+		return nil
+	}
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// outputKeyVectors are from the scriptPubKey section of the BIP341 wallet
+// test vectors, and the BIP86 vectors of the mnemonic "abandon ... about",
+// which have no script tree.
+var outputKeyVectors = []struct {
+	name        string
+	internalKey string
+	merkleRoot  string
+	tweak       string
+	outputKey   string
+}{
+	{
+		name:        "BIP341 no scripts",
+		internalKey: "d6889cb081036e0faefa3a35157ad71086b123b2b144b649798b494c300a961d",
+		tweak:       "b86e7be8f39bab32a6f2c0443abbc210f0edac0e2c53d501b36b64437d9c6c70",
+		outputKey:   "53a1f6e454df1aa2776a2814a721372d6258050de330b3c6d10ee8f4e0dda343",
+	},
+	{
+		name:        "BIP341 one leaf",
+		internalKey: "187791b6f712a8ea41c8ecdd0ee77fab3e85263b37e1ec18a3651926b3a6cf27",
+		merkleRoot:  "5b75adecf53548f3ec6ad7d78383bf84cc57b55a3127c72b9a2481752dd88b21",
+		tweak:       "cbd8679ba636c1110ea247542cfbd964131a6be84f873f7f3b62a777528ed001",
+		outputKey:   "147c9c57132f6e7ecddba9800bb0c4449251c92a1e60371ee77557b6620f3ea3",
+	},
+	{
+		name:        "BIP341 one leaf, other version",
+		internalKey: "93478e9488f956df2396be2ce6c5cced75f900dfa18e7dabd2428aae78451820",
+		merkleRoot:  "c525714a7f49c28aedbbba78c005931a81c234b2f6c99a73e4d06082adc8bf2b",
+		tweak:       "6af9e28dbf9d6aaf027696e2598a5b3d056f5fd2355a7fd5a37a0e5008132d30",
+		outputKey:   "e4d810fd50586274face62b8a807eb9719cef49c04177cc6b76a9a4251d5450e",
+	},
+	{
+		name:        "BIP86 m/86'/0'/0'/0/0",
+		internalKey: "cc8a4bc64d897bddc5fbc2f670f7a8ba0b386779106cf1223c6fc5d7cd6fc115",
+		outputKey:   "a60869f0dbcf1dc659c9cecbaf8050135ea9e8cdc487053f1dc6880949dc684c",
+	},
+	{
+		name:        "BIP86 m/86'/0'/0'/0/1",
+		internalKey: "83dfe85a3151d2517290da461fe2815591ef69f2b18a2ce63f01697a8b313145",
+		outputKey:   "a82f29944d65b86ae6b5e5cc75e294ead6c59391a1edc5e016e3498c67fc7bbb",
+	},
+	{
+		name:        "BIP86 m/86'/0'/0'/1/0",
+		internalKey: "399f1b2f4393f29a18c937859c5dd8a77350103157eb880f02e8c08214277cef",
+		outputKey:   "882d74e5d0572d5a816cef0041a96b6c1de832f6f9676d9605c44d5e9a97d3dc",
+	},
+}
+
+func TestOutputKey(t *testing.T) {
+	for _, v := range outputKeyVectors {
+		internalKey := decodeHex(t, v.internalKey)
+		merkleRoot := decodeHex(t, v.merkleRoot)
+		if v.tweak != "" {
+			tweak, err := TweakHash(internalKey, merkleRoot)
+			if err != nil {
+				t.Errorf("%s: %v", v.name, err)
+				continue
+			}
+			if got := hex.EncodeToString(tweak[:]); got != v.tweak {
+				t.Errorf("%s: tweak %s, want %s", v.name, got, v.tweak)
+			}
+		}
+		outputKey, _, err := OutputKey(internalKey, merkleRoot)
+		if err != nil {
+			t.Errorf("%s: %v", v.name, err)
+			continue
+		}
+		if got := hex.EncodeToString(outputKey); got != v.outputKey {
+			t.Errorf("%s: output key %s, want %s", v.name, got, v.outputKey)
+		}
+	}
+
+	if _, _, err := OutputKey(decodeHex(t, outputKeyVectors[0].internalKey), make([]byte, 31)); err == nil {
+		t.Error("31-byte merkle root accepted")
+	}
+}
+
+// TestTweakPrivateKey is input 0 of the keyPathSpending vector of BIP341,
+// whose internal key has an odd y. The signature of the vector is checked
+// in the schnorr package.
+func TestTweakPrivateKey(t *testing.T) {
+	internalPrivateKey := decodeHex(t, "6b973d88838f27366ed61c9ad6367663045cb456e28335c109e30717ae0c6baa")
+	const (
+		tweakedPrivateKey = "2405b971772ad26915c8dcdf10f238753a9b837e5f8e6a86fd7c0cce5b7296d9"
+		outputKey         = "53a1f6e454df1aa2776a2814a721372d6258050de330b3c6d10ee8f4e0dda343"
+	)
+
+	tweaked, err := TweakPrivateKey(internalPrivateKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hex.EncodeToString(tweaked); got != tweakedPrivateKey {
+		t.Errorf("tweaked private key %s, want %s", got, tweakedPrivateKey)
+	}
+	internalKey, _ := ecc.PublicKey(internalPrivateKey, true)
+	if got, _, _ := OutputKey(internalKey, nil); hex.EncodeToString(got) != outputKey {
+		t.Errorf("output key %x, want %s", got, outputKey)
+	}
+	// The tweaked private key belongs to the output key.
+	if got, _ := ecc.PublicKey(tweaked, true); hex.EncodeToString(got[1:]) != outputKey {
+		t.Errorf("public key of the tweaked private key %x, want %s", got[1:], outputKey)
+	}
+}
@@ -3,36 +3,75 @@ package main
 import (
 	"bytes"
 	"crypto/sha256"
-	"encoding/binary"
 	"encoding/hex"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
-	"math"
-	"math/rand"
-	"time"
-
-	"github.com/smallnest/bitcoin/wallet/base58check"
-	secp256k1 "github.com/toxeus/go-secp256k1"
+	"strings"
+
+	"github.com/smallnest/bitcoin/wallet/address"
+	"github.com/smallnest/bitcoin/wallet/bip21"
+	"github.com/smallnest/bitcoin/wallet/ecc"
+	"github.com/smallnest/bitcoin/wallet/entropy"
+	"github.com/smallnest/bitcoin/wallet/schnorr"
+	"github.com/smallnest/bitcoin/wallet/taproot"
+	"github.com/smallnest/bitcoin/wallet/tx"
+	"github.com/smallnest/bitcoin/wallet/walletfile"
+	"github.com/smallnest/bitcoin/wallet/wif"
 )
 
 var (
 	privateKey       = flag.String("private-key", "", "The private key of the bitcoin wallet which contains the bitcoins you wish to send.")
+	walletFile       = flag.String("wallet", "", "A wallet file holding the private key of --public-key, used instead of --private-key. The password is read from $WALLET_PASSWORD or prompted for.")
 	publicKey        = flag.String("public-key", "", "The public address of the bitcoin wallet which contains the bitcoins you wish to send.")
-	destination      = flag.String("destination", "", "The public address of the bitcoin wallet to which you wish to send the bitcoins.")
+	destination      = flag.String("destination", "", "The public address of the bitcoin wallet to which you wish to send the bitcoins, or a BIP21 bitcoin: URI whose amount is used unless -satoshis is given.")
 	inputTransaction = flag.String("input-transaction", "", "An unspent input transaction hash which contains the bitcoins you wish to send. (Note: This program assumes a single input transaction, and a single output transaction for simplicity.)")
 	inputIndex       = flag.Int("input-index", 0, "The output index of the unspent input transaction which contains the bitcoins you wish to send. Defaults to 0 (first index).")
+	inputSatoshis    = flag.Int64("input-satoshis", 0, "The value in satoshis of the output being spent. Only needed for taproot inputs, whose signatures commit to it.")
+	merkleRoot       = flag.String("merkle-root", "", "Hex merkle root of the script tree of a taproot input. (optional, key path only by default)")
 	satoshis         = flag.Int("satoshis", 0, "The number of bitcoins you wish to send as represented in satoshis (100,000,000 satoshis = 1 bitcoin). (Important note: the number of satoshis left unspent in your input transaction will be spent as the transaction fee.)")
 )
 
 // https://zh-cn.bitcoin.it/wiki/Transactions
 // go run transaction.go --private-key  5K5ib2WaTvqs4n3r1bMJLhDXg4CnV1We995UyECmbHLbzNnoTft --public-key 1K6KHeR4pRJLMcgb82Hmrg4RDhUZ2CaL2p -destination 1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa --input-transaction  61ad94e4ad3b0cef86bbab2742f6946534ecbfd82153ce396c723cbbaa2a40fb -satoshis 1000
+// go run transaction.go --wallet wallet.json --public-key 1K6KHeR4pRJLMcgb82Hmrg4RDhUZ2CaL2p -destination 1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa --input-transaction  61ad94e4ad3b0cef86bbab2742f6946534ecbfd82153ce396c723cbbaa2a40fb -satoshis 1000
+// go run transaction.go --private-key L3VFeEujGtevx9w18HD1fhRbCH67Az2dpCymeRE1SoPK6XQtaN2k --public-key bc1ppv609nr0vr25u07u95waq5lucwfm6tde4nydujnu8npg4q75mr5sxq8lt3 -destination 1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa --input-transaction 61ad94e4ad3b0cef86bbab2742f6946534ecbfd82153ce396c723cbbaa2a40fb --input-satoshis 2000 -satoshis 1000
+// go run transaction.go --private-key  5K5ib2WaTvqs4n3r1bMJLhDXg4CnV1We995UyECmbHLbzNnoTft --public-key 1K6KHeR4pRJLMcgb82Hmrg4RDhUZ2CaL2p -destination "bitcoin:1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa?amount=0.00001" --input-transaction  61ad94e4ad3b0cef86bbab2742f6946534ecbfd82153ce396c723cbbaa2a40fb
 
 // https://bitcoin.org/en/developer-reference#raw-transaction-format
 func main() {
 	flag.Parse()
 
-	tempScriptSig := createScriptPubKey(*publicKey)
+	if err := applyPaymentRequest(); err != nil {
+		log.Fatalf("invalid destination %q: %v", *destination, err)
+	}
+
+	from, err := address.Parse(*publicKey)
+	if err != nil {
+		log.Fatalf("invalid public key %q: %v", *publicKey, err)
+	}
+	//Only P2PKH and taproot key path inputs can be signed.
+	if from.Kind != address.P2PKH && from.Kind != address.P2TR {
+		log.Fatalf("invalid public key %q: %s inputs are not supported", *publicKey, from.Kind)
+	}
+	tempScriptSig := from.ScriptPubKey()
+
+	privateKeyWif, err := loadPrivateKey(from)
+	if err != nil {
+		log.Fatalf("cannot load the private key: %v", err)
+	}
+
+	//Reject a mistyped destination before anything is signed.
+	if _, err := createScriptPubKey(*destination); err != nil {
+		log.Fatalf("invalid destination %q: %v", *destination, err)
+	}
+
+	if from.Kind == address.P2TR {
+		finalTransaction := signTaprootTransaction(from, privateKeyWif)
+		fmt.Println("Your final transaction is: ", hex.EncodeToString(finalTransaction))
+		return
+	}
 
 	rawTransaction := createRawTransaction(*inputTransaction, *inputIndex, *destination, *satoshis, tempScriptSig)
 
@@ -46,36 +85,84 @@ func main() {
 	rawTransWithHashCodeType := rawTransBuffer.Bytes()
 
 	//Sign the raw transaction, and output it to the console.
-	finalTransaction := signRawTransaction(rawTransWithHashCodeType, *privateKey)
+	finalTransaction := signRawTransaction(rawTransWithHashCodeType, privateKeyWif)
 	finalTransactionHex := hex.EncodeToString(finalTransaction)
 
 	fmt.Println("Your final transaction is: ", finalTransactionHex)
 }
 
-func createScriptPubKey(publicKeyBase58 string) []byte {
-	publicKeyBytes := base58check.Decode(publicKeyBase58)
-
-	var scriptPubKey bytes.Buffer
-	scriptPubKey.WriteByte(byte(118))                 //OP_DUP
-	scriptPubKey.WriteByte(byte(169))                 //OP_HASH160
-	scriptPubKey.WriteByte(byte(len(publicKeyBytes))) //PUSH
-	scriptPubKey.Write(publicKeyBytes)
-	scriptPubKey.WriteByte(byte(136)) //OP_EQUALVERIFY
-	scriptPubKey.WriteByte(byte(172)) //OP_CHECKSIG
-	return scriptPubKey.Bytes()
+// applyPaymentRequest replaces a BIP21 payment request given as
+// --destination by its address, and sends the requested amount unless
+// --satoshis is given.
+func applyPaymentRequest() error {
+	if !strings.HasPrefix(strings.ToLower(*destination), bip21.Scheme+":") {
+		return nil
+	}
+	u, err := bip21.Parse(*destination)
+	if err != nil {
+		return err
+	}
+	if u.Address == "" {
+		return errors.New("the payment request has no on-chain address")
+	}
+	switch {
+	case *satoshis == 0 && u.Amount == 0:
+		return errors.New("the payment request has no amount, give --satoshis")
+	case *satoshis == 0:
+		*satoshis = int(u.Amount)
+	case u.Amount != 0 && int64(*satoshis) != u.Amount:
+		return fmt.Errorf("--satoshis %d differs from the requested %d", *satoshis, u.Amount)
+	}
+	*destination = u.Address
+	return nil
+}
+
+// createScriptPubKey builds the output script for any address kind:
+// P2PKH, P2SH, P2WPKH, P2WSH or P2TR.
+func createScriptPubKey(addr string) ([]byte, error) {
+	a, err := address.Parse(addr)
+	if err != nil {
+		return nil, err
+	}
+	return a.ScriptPubKey(), nil
 }
 
-func signRawTransaction(rawTransaction []byte, privateKeyBase58 string) []byte {
+// loadPrivateKey returns the key given with --private-key, or looks up the
+// key of the address being spent from in the --wallet file.
+func loadPrivateKey(from *address.Address) (*wif.WIF, error) {
+	if *walletFile == "" {
+		return wif.Decode(*privateKey)
+	}
+	if *privateKey != "" {
+		return nil, errors.New("use either --private-key or --wallet")
+	}
+
+	w, err := walletfile.Open(*walletFile)
+	if err != nil {
+		return nil, err
+	}
+	password, err := walletfile.ReadPassword("Wallet password: ")
+	if err != nil {
+		return nil, err
+	}
+	if err := w.Unlock(password); err != nil {
+		return nil, err
+	}
+	defer w.Lock()
+	//Look a little past the used addresses of HD seeds, where funds
+	//can arrive before the wallet has handed the address out.
+	return w.FindKey(from, 20)
+}
+
+func signRawTransaction(rawTransaction []byte, privateKeyWif *wif.WIF) []byte {
 	//Here we start the process of signing the raw transaction.
 
-	secp256k1.Start()
-	privateKeyBytes := base58check.Decode(privateKeyBase58)
-	var privateKeyBytes32 [32]byte
-	copy(privateKeyBytes32[:], privateKeyBytes)
+	privateKeyBytes := privateKeyWif.PrivateKey[:]
 
-	//Get the raw public key
-	publicKeyBytes, success := secp256k1.Pubkey_create(privateKeyBytes32, false)
-	if !success {
+	//Get the raw public key, compressed if the WIF says so, otherwise the
+	//public key would not hash to the address being spent from.
+	publicKeyBytes, err := ecc.Default.PublicKey(privateKeyBytes, privateKeyWif.Compressed)
+	if err != nil {
 		log.Fatal("Failed to convert private key to public key")
 	}
 
@@ -88,24 +175,22 @@ func signRawTransaction(rawTransaction []byte, privateKeyBase58 string) []byte {
 	shaHash2.Write(hash)
 	rawTransactionHashed := shaHash2.Sum(nil)
 
-	var rawTransHashed32 [32]byte
-	copy(rawTransHashed32[:], rawTransactionHashed)
-
 	//Sign the raw transaction
-	nonce := generateNonce()
-	signedTransaction, success := secp256k1.Sign(rawTransHashed32, privateKeyBytes32, &nonce)
-	if !success {
+	nonce, err := generateNonce(entropy.Default)
+	if err != nil {
+		log.Fatal(err)
+	}
+	signedTransaction, err := ecc.Default.Sign(rawTransactionHashed, privateKeyBytes, nonce[:])
+	if err != nil {
 		log.Fatal("Failed to sign transaction")
 	}
 
 	//Verify that it worked.
-	verified := secp256k1.Verify(rawTransHashed32, signedTransaction, publicKeyBytes)
+	verified := ecc.Default.Verify(rawTransactionHashed, signedTransaction, publicKeyBytes)
 	if !verified {
 		log.Fatal("Failed to sign transaction")
 	}
 
-	secp256k1.Stop()
-
 	hashCodeType, _ := hex.DecodeString("01")
 
 	//+1 for hashCodeType
@@ -128,81 +213,97 @@ func signRawTransaction(rawTransaction []byte, privateKeyBase58 string) []byte {
 	return createRawTransaction(*inputTransaction, *inputIndex, *destination, *satoshis, scriptSig)
 }
 
-func generateNonce() [32]byte {
-	var bytes [32]byte
-	for i := 0; i < 32; i++ {
-		//This is not "cryptographically random"
-		bytes[i] = byte(randInt(0, math.MaxUint8))
+// signTaprootTransaction signs the key path spend of a P2TR output with a
+// BIP340 Schnorr signature. Unlike the ECDSA signature of a P2PKH input,
+// it goes in the witness and commits to the amount being spent.
+func signTaprootTransaction(from *address.Address, privateKeyWif *wif.WIF) []byte {
+	var root []byte
+	if *merkleRoot != "" {
+		var err error
+		if root, err = hex.DecodeString(*merkleRoot); err != nil {
+			log.Fatalf("invalid merkle root: %v", err)
+		}
+	}
+	if *inputSatoshis <= 0 {
+		log.Fatal("taproot inputs need --input-satoshis")
 	}
-	return bytes
-}
-
-func randInt(min int, max int) uint8 {
-	rand.Seed(time.Now().UTC().UnixNano())
-	return uint8(min + rand.Intn(max-min))
-}
-
-func createRawTransaction(inputTransactionHash string, inputTransactionIndex int, publicKeyBase58Destination string, satoshis int, scriptSig []byte) []byte {
-	//Create the raw transaction.
-
-	//Version field
-	version, _ := hex.DecodeString("01000000")
-
-	//# of inputs (always 1 in our case)
-	inputs, _ := hex.DecodeString("01")
 
-	//Input transaction hash
-	inputTransactionBytes, err := hex.DecodeString(inputTransactionHash)
+	//Tweak the private key like the address tweaks the public key, and
+	//check that it really is the key of the address.
+	privateKeyBytes := privateKeyWif.PrivateKey[:]
+	internalKey, err := schnorr.PublicKey(privateKeyBytes)
+	if err != nil {
+		log.Fatal(err)
+	}
+	outputKey, _, err := taproot.OutputKey(internalKey, root)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if !bytes.Equal(outputKey, from.Hash) {
+		log.Fatal("the private key does not belong to the taproot address")
+	}
+	tweakedKey, err := taproot.TweakPrivateKey(privateKeyBytes, root)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	//Convert input transaction hash to little-endian form
-	inputTransactionBytesReversed := make([]byte, len(inputTransactionBytes))
-	for i := 0; i < len(inputTransactionBytes); i++ {
-		inputTransactionBytesReversed[i] = inputTransactionBytes[len(inputTransactionBytes)-i-1]
+	unsigned, err := tx.Deserialize(createRawTransaction(*inputTransaction, *inputIndex, *destination, *satoshis, nil))
+	if err != nil {
+		log.Fatal(err)
+	}
+	prevOuts := []*tx.TxOut{{Value: *inputSatoshis, ScriptPubKey: from.ScriptPubKey()}}
+	sigHash, err := unsigned.TaprootSigHash(0, prevOuts, tx.SigHashDefault)
+	if err != nil {
+		log.Fatal(err)
 	}
 
-	//Output index of input transaction
-	outputIndexBytes := make([]byte, 4)
-	binary.LittleEndian.PutUint32(outputIndexBytes, uint32(inputTransactionIndex))
+	//Sign with fresh auxiliary randomness, the nonce is derived from it,
+	//the key and the message.
+	aux, err := generateNonce(entropy.Default)
+	if err != nil {
+		log.Fatal(err)
+	}
+	signature, err := schnorr.Sign(sigHash[:], tweakedKey, aux[:])
+	if err != nil {
+		log.Fatal("Failed to sign transaction")
+	}
 
-	//Script sig length
-	scriptSigLength := len(scriptSig)
+	//SIGHASH_DEFAULT signatures are 64 bytes, without a hash type.
+	unsigned.Inputs[0].Witness = [][]byte{signature}
+	return unsigned.Serialize()
+}
 
-	//sequence_no. Normally 0xFFFFFFFF. Always in this case.
-	sequence, _ := hex.DecodeString("ffffffff")
+// generateNonce returns a random nonce in [1, n-1] from a cryptographically
+// secure source.
+func generateNonce(src entropy.Source) ([32]byte, error) {
+	return entropy.Scalar(src)
+}
 
-	//Numbers of outputs for the transaction being created. Always one in this example.
-	numOutputs, _ := hex.DecodeString("01")
+func createRawTransaction(inputTransactionHash string, inputTransactionIndex int, publicKeyBase58Destination string, satoshis int, scriptSig []byte) []byte {
+	//Create the raw transaction.
 
-	//Satoshis to send.
-	satoshiBytes := make([]byte, 8)
-	binary.LittleEndian.PutUint64(satoshiBytes, uint64(satoshis))
+	//Input transaction hash, in little-endian form
+	inputTransactionBytes, err := tx.ParseHash(inputTransactionHash)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	//Script pub key
-	scriptPubKey := createScriptPubKey(publicKeyBase58Destination)
-	scriptPubKeyLength := len(scriptPubKey)
-
-	//Lock time field
-	lockTimeField, err := hex.DecodeString("00000000")
+	scriptPubKey, err := createScriptPubKey(publicKeyBase58Destination)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	var buffer bytes.Buffer
-	buffer.Write(version)
-	buffer.Write(inputs)
-	buffer.Write(inputTransactionBytesReversed)
-	buffer.Write(outputIndexBytes)
-	buffer.WriteByte(byte(scriptSigLength))
-	buffer.Write(scriptSig)
-	buffer.Write(sequence)
-	buffer.Write(numOutputs)
-	buffer.Write(satoshiBytes)
-	buffer.WriteByte(byte(scriptPubKeyLength))
-	buffer.Write(scriptPubKey)
-	buffer.Write(lockTimeField)
-
-	return buffer.Bytes()
+	//One input and one output. The sequence_no is normally 0xFFFFFFFF,
+	//always in this case, and the lock time is zero.
+	rawTransaction := &tx.Tx{
+		Version: 1,
+		Inputs: []*tx.TxIn{{
+			PreviousOutPoint: tx.OutPoint{Hash: inputTransactionBytes, Index: uint32(inputTransactionIndex)},
+			ScriptSig:        scriptSig,
+			Sequence:         0xffffffff,
+		}},
+		Outputs: []*tx.TxOut{{Value: int64(satoshis), ScriptPubKey: scriptPubKey}},
+	}
+	return rawTransaction.Serialize()
 }
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/smallnest/bitcoin/wallet/address"
+	"github.com/smallnest/bitcoin/wallet/schnorr"
+	"github.com/smallnest/bitcoin/wallet/taproot"
+	"github.com/smallnest/bitcoin/wallet/tx"
+	"github.com/smallnest/bitcoin/wallet/wif"
+)
+
+// setFlags sets the transaction flags for one test and restores them after.
+func setFlags(t *testing.T, input string, destinationAddress string, send int, spent int64, root string) {
+	t.Helper()
+	oldInput, oldIndex, oldDestination := *inputTransaction, *inputIndex, *destination
+	oldSatoshis, oldSpent, oldRoot := *satoshis, *inputSatoshis, *merkleRoot
+	t.Cleanup(func() {
+		*inputTransaction, *inputIndex, *destination = oldInput, oldIndex, oldDestination
+		*satoshis, *inputSatoshis, *merkleRoot = oldSatoshis, oldSpent, oldRoot
+	})
+	*inputTransaction, *inputIndex, *destination = input, 1, destinationAddress
+	*satoshis, *inputSatoshis, *merkleRoot = send, spent, root
+}
+
+func TestSignTaprootTransaction(t *testing.T) {
+	const input = "61ad94e4ad3b0cef86bbab2742f6946534ecbfd82153ce396c723cbbaa2a40fb"
+	key, err := wif.Decode("L3VFeEujGtevx9w18HD1fhRbCH67Az2dpCymeRE1SoPK6XQtaN2k")
+	if err != nil {
+		t.Fatal(err)
+	}
+	internalKey, _ := schnorr.PublicKey(key.PrivateKey[:])
+	root := bytes.Repeat([]byte{0x42}, 32)
+
+	for _, v := range []struct {
+		name string
+		root []byte
+		// The BIP322 vector address of the key, for the key path only
+		// output.
+		address string
+	}{
+		{"key path only", nil, "bc1ppv609nr0vr25u07u95waq5lucwfm6tde4nydujnu8npg4q75mr5sxq8lt3"},
+		{"with a script tree", root, ""},
+	} {
+		outputKey, _, err := taproot.OutputKey(internalKey, v.root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		from, _ := address.New(address.Mainnet, address.P2TR, outputKey)
+		if v.address != "" && from.String() != v.address {
+			t.Fatalf("%s: address %s, want %s", v.name, from, v.address)
+		}
+
+		setFlags(t, input, "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", 1000, 2000, hex.EncodeToString(v.root))
+		signed, err := tx.Deserialize(signTaprootTransaction(from, key))
+		if err != nil {
+			t.Fatalf("%s: %v", v.name, err)
+		}
+
+		in := signed.Inputs[0]
+		if in.PreviousOutPoint.Hash.String() != input || in.PreviousOutPoint.Index != 1 || len(in.ScriptSig) != 0 {
+			t.Errorf("%s: input %s:%d with scriptSig %x", v.name, in.PreviousOutPoint.Hash, in.PreviousOutPoint.Index, in.ScriptSig)
+		}
+		if len(signed.Outputs) != 1 || signed.Outputs[0].Value != 1000 {
+			t.Errorf("%s: outputs %+v", v.name, signed.Outputs)
+		}
+		if len(in.Witness) != 1 || len(in.Witness[0]) != 64 {
+			t.Fatalf("%s: witness %x, want one 64-byte signature", v.name, in.Witness)
+		}
+
+		// The signature commits to the amount being spent.
+		prevOuts := []*tx.TxOut{{Value: 2000, ScriptPubKey: from.ScriptPubKey()}}
+		sigHash, err := signed.TaprootSigHash(0, prevOuts, tx.SigHashDefault)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !schnorr.Verify(sigHash[:], in.Witness[0], outputKey) {
+			t.Errorf("%s: signature does not verify with the output key", v.name)
+		}
+		prevOuts[0].Value = 2001
+		sigHash, _ = signed.TaprootSigHash(0, prevOuts, tx.SigHashDefault)
+		if schnorr.Verify(sigHash[:], in.Witness[0], outputKey) {
+			t.Errorf("%s: signature verifies for another spent amount", v.name)
+		}
+	}
+}
+
+func TestApplyPaymentRequest(t *testing.T) {
+	const addr = "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa"
+	for _, v := range []struct {
+		destination string
+		send        int
+		// wantSend is the amount sent, -1 if the request is refused.
+		wantSend int
+	}{
+		{addr, 0, 0},
+		{"bitcoin:" + addr + "?amount=0.001", 0, 100000},
+		{"bitcoin:" + addr + "?amount=0.001", 100000, 100000},
+		{"bitcoin:" + addr + "?amount=0.001", 5000, -1},
+		{"bitcoin:" + addr, 5000, 5000},
+		{"bitcoin:" + addr, 0, -1},
+		{"bitcoin:" + addr + "?label=Luke-Jr", 0, -1},
+		{"bitcoin:?lightning=lnbc1&amount=0.001", 0, -1},
+		{"bitcoin:" + addr + "?req-x=1", 1000, -1},
+	} {
+		setFlags(t, "", v.destination, v.send, 0, "")
+		err := applyPaymentRequest()
+		if v.wantSend < 0 {
+			if err == nil {
+				t.Errorf("%s with %d: no error", v.destination, v.send)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s with %d: %v", v.destination, v.send, err)
+			continue
+		}
+		if *destination != addr || *satoshis != v.wantSend {
+			t.Errorf("%s with %d: got %s and %d, want %s and %d",
+				v.destination, v.send, *destination, *satoshis, addr, v.wantSend)
+		}
+	}
+}
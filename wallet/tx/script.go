@@ -0,0 +1,99 @@
+package tx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Script opcodes used by the standard output and input scripts.
+const (
+	Op0             byte = 0x00
+	OpPushData1     byte = 0x4c
+	OpPushData2     byte = 0x4d
+	Op1             byte = 0x51
+	OpReturn        byte = 0x6a
+	OpDup           byte = 0x76
+	OpEqualVerify   byte = 0x88
+	OpHash160       byte = 0xa9
+	OpCheckSig      byte = 0xac
+	OpCheckMultiSig byte = 0xae
+)
+
+// MaxMultisigKeys is the number of keys a standard P2SH multisig redeem
+// script can hold within the 520-byte push limit, with compressed keys.
+const MaxMultisigKeys = 15
+
+// PushData returns the script that pushes b, at most 65535 bytes, on the
+// stack with the smallest push opcode.
+func PushData(b []byte) []byte {
+	var buf bytes.Buffer
+	switch n := len(b); {
+	case n < int(OpPushData1):
+		buf.WriteByte(byte(n))
+	case n <= 0xff:
+		buf.WriteByte(OpPushData1)
+		buf.WriteByte(byte(n))
+	default:
+		buf.WriteByte(OpPushData2)
+		binary.Write(&buf, binary.LittleEndian, uint16(n))
+	}
+	buf.Write(b)
+	return buf.Bytes()
+}
+
+// P2PKHScript returns the P2PKH output script of a 20-byte public key hash,
+// which is also the BIP143 script code of a P2WPKH input.
+func P2PKHScript(pubKeyHash []byte) []byte {
+	script := []byte{OpDup, OpHash160, 20}
+	script = append(script, pubKeyHash...)
+	return append(script, OpEqualVerify, OpCheckSig)
+}
+
+// MultisigScript returns the m-of-n script
+// OP_m <pubKey>... OP_n OP_CHECKMULTISIG with the keys in the given order.
+func MultisigScript(m int, pubKeys [][]byte) ([]byte, error) {
+	n := len(pubKeys)
+	if n == 0 || n > MaxMultisigKeys {
+		return nil, fmt.Errorf("tx: multisig needs 1 to %d keys, got %d", MaxMultisigKeys, n)
+	}
+	if m < 1 || m > n {
+		return nil, fmt.Errorf("tx: multisig threshold must be between 1 and %d, got %d", n, m)
+	}
+	script := []byte{Op1 - 1 + byte(m)}
+	for _, k := range pubKeys {
+		script = append(script, PushData(k)...)
+	}
+	return append(script, Op1-1+byte(n), OpCheckMultiSig), nil
+}
+
+// SortedMultisigScript returns the BIP67 m-of-n script: the keys sorted by
+// SortPubKeys, so every cosigner derives the same script and address from
+// the same set of keys.
+//
+// https://github.com/bitcoin/bips/blob/master/bip-0067.mediawiki
+func SortedMultisigScript(m int, pubKeys [][]byte) ([]byte, error) {
+	sorted, err := SortPubKeys(pubKeys)
+	if err != nil {
+		return nil, err
+	}
+	return MultisigScript(m, sorted)
+}
+
+// SortPubKeys returns a copy of the compressed public keys sorted in
+// lexicographic order, as BIP67 requires.
+func SortPubKeys(pubKeys [][]byte) ([][]byte, error) {
+	sorted := make([][]byte, len(pubKeys))
+	for i, k := range pubKeys {
+		if len(k) != 33 || (k[0] != 0x02 && k[0] != 0x03) {
+			return nil, errors.New("tx: BIP67 only allows compressed public keys")
+		}
+		sorted[i] = k
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i], sorted[j]) < 0
+	})
+	return sorted, nil
+}
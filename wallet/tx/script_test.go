@@ -0,0 +1,137 @@
+package tx
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/smallnest/bitcoin/wallet/address"
+)
+
+func decodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// bip67Vectors are the test vectors of BIP67, all 2-of-n.
+var bip67Vectors = []struct {
+	pubKeys []string
+	script  string
+	p2sh    string
+}{
+	{
+		pubKeys: []string{
+			"02ff12471208c14bd580709cb2358d98975247d8765f92bc25eab3b2763ed605f8",
+			"02fe6f0a5a297eb38c391581c4413e084773ea23954d93f7753db7dc0adc188b2f",
+		},
+		script: "522102fe6f0a5a297eb38c391581c4413e084773ea23954d93f7753db7dc0adc188b2f2102ff12471208c14bd580709cb2358d98975247d8765f92bc25eab3b2763ed605f852ae",
+		p2sh:   "39bgKC7RFbpoCRbtD5KEdkYKtNyhpsNa3Z",
+	},
+	{
+		pubKeys: []string{
+			"02632b12f4ac5b1d1b72b2a3b508c19172de44f6f46bcee50ba33f3f9291e47ed0",
+			"027735a29bae7780a9755fae7a1c4374c656ac6a69ea9f3697fda61bb99a4f3e77",
+			"02e2cc6bd5f45edd43bebe7cb9b675f0ce9ed3efe613b177588290ad188d11b404",
+		},
+		script: "522102632b12f4ac5b1d1b72b2a3b508c19172de44f6f46bcee50ba33f3f9291e47ed021027735a29bae7780a9755fae7a1c4374c656ac6a69ea9f3697fda61bb99a4f3e772102e2cc6bd5f45edd43bebe7cb9b675f0ce9ed3efe613b177588290ad188d11b40453ae",
+		p2sh:   "3CKHTjBKxCARLzwABMu9yD85kvtm7WnMfH",
+	},
+	{
+		pubKeys: []string{
+			"030000000000000000000000000000000000004141414141414141414141414141",
+			"020000000000000000000000000000000000004141414141414141414141414141",
+			"020000000000000000000000000000000000004141414141414141414141414140",
+			"030000000000000000000000000000000000004141414141414141414141414140",
+		},
+		script: "522102000000000000000000000000000000000000414141414141414141414141414021020000000000000000000000000000000000004141414141414141414141414141210300000000000000000000000000000000000041414141414141414141414141402103000000000000000000000000000000000000414141414141414141414141414154ae",
+		p2sh:   "32V85igBri9zcfBRVupVvwK18NFtS37FuD",
+	},
+	{
+		pubKeys: []string{
+			"022df8750480ad5b26950b25c7ba79d3e37d75f640f8e5d9bcd5b150a0f85014da",
+			"03e3818b65bcc73a7d64064106a859cc1a5a728c4345ff0b641209fba0d90de6e9",
+			"021f2f6e1e50cb6a953935c3601284925decd3fd21bc445712576873fb8c6ebc18",
+		},
+		script: "5221021f2f6e1e50cb6a953935c3601284925decd3fd21bc445712576873fb8c6ebc1821022df8750480ad5b26950b25c7ba79d3e37d75f640f8e5d9bcd5b150a0f85014da2103e3818b65bcc73a7d64064106a859cc1a5a728c4345ff0b641209fba0d90de6e953ae",
+		p2sh:   "3Q4sF6tv9wsdqu2NtARzNCpQgwifm2rAba",
+	},
+}
+
+func TestSortedMultisigScript(t *testing.T) {
+	for i, v := range bip67Vectors {
+		var pubKeys [][]byte
+		for _, k := range v.pubKeys {
+			pubKeys = append(pubKeys, decodeHex(t, k))
+		}
+		script, err := SortedMultisigScript(2, pubKeys)
+		if err != nil {
+			t.Fatalf("vector %d: %v", i+1, err)
+		}
+		if got := hex.EncodeToString(script); got != v.script {
+			t.Errorf("vector %d: script %s, want %s", i+1, got, v.script)
+		}
+		if got := address.NewP2SHFromScript(address.Mainnet, script).String(); got != v.p2sh {
+			t.Errorf("vector %d: address %s, want %s", i+1, got, v.p2sh)
+		}
+		// The keys passed in keep their order.
+		if !bytes.Equal(pubKeys[0], decodeHex(t, v.pubKeys[0])) {
+			t.Errorf("vector %d: the keys were sorted in place", i+1)
+		}
+	}
+}
+
+func TestSortPubKeysUncompressed(t *testing.T) {
+	uncompressed := decodeHex(t, "04"+strings.Repeat("41", 64))
+	if _, err := SortPubKeys([][]byte{uncompressed}); err == nil {
+		t.Error("an uncompressed key was accepted")
+	}
+	if _, err := SortPubKeys([][]byte{decodeHex(t, "04"+strings.Repeat("41", 32))}); err == nil {
+		t.Error("a 33-byte key with prefix 04 was accepted")
+	}
+}
+
+func TestMultisigScriptLimits(t *testing.T) {
+	keys := func(n int) [][]byte {
+		pubKeys := make([][]byte, n)
+		for i := range pubKeys {
+			pubKeys[i] = append([]byte{0x02}, bytes.Repeat([]byte{byte(i)}, 32)...)
+		}
+		return pubKeys
+	}
+	for _, v := range []struct {
+		m, n int
+		ok   bool
+	}{
+		{1, 1, true},
+		{2, 3, true},
+		{15, 15, true},
+		{1, 15, true},
+		{0, 3, false},
+		{-1, 3, false},
+		{4, 3, false},
+		{1, 0, false},
+		{1, 16, false},
+		{16, 16, false},
+	} {
+		script, err := MultisigScript(v.m, keys(v.n))
+		if (err == nil) != v.ok {
+			t.Errorf("%d-of-%d: %v", v.m, v.n, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if script[0] != Op1-1+byte(v.m) || script[len(script)-2] != Op1-1+byte(v.n) || script[len(script)-1] != OpCheckMultiSig {
+			t.Errorf("%d-of-%d: script %x", v.m, v.n, script)
+		}
+		// 15 compressed keys still fit in a 520-byte redeem script push.
+		if len(script) > 520 {
+			t.Errorf("%d-of-%d: script of %d bytes", v.m, v.n, len(script))
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package tx
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/smallnest/bitcoin/wallet/taproot"
+)
+
+// Signature hash types.
+const (
+	// SigHashDefault is the taproot default, it commits to the same data
+	// as SigHashAll but is not appended to the signature.
+	SigHashDefault byte = 0x00
+	SigHashAll     byte = 0x01
+)
+
+// LegacySigHash returns the hash signed by a pre-segwit input: the double
+// SHA256 of the transaction with every scriptSig emptied except the one of
+// input i, which is replaced by subscript, followed by the hash type.
+// Only SIGHASH_ALL is supported.
+func (t *Tx) LegacySigHash(i int, subscript []byte, hashType byte) ([32]byte, error) {
+	if i < 0 || i >= len(t.Inputs) {
+		return [32]byte{}, fmt.Errorf("tx: no input %d", i)
+	}
+	if hashType != SigHashAll {
+		return [32]byte{}, fmt.Errorf("tx: unsupported hash type 0x%02x", hashType)
+	}
+	c := &Tx{Version: t.Version, Outputs: t.Outputs, LockTime: t.LockTime}
+	for j, in := range t.Inputs {
+		cin := &TxIn{PreviousOutPoint: in.PreviousOutPoint, Sequence: in.Sequence}
+		if j == i {
+			cin.ScriptSig = subscript
+		}
+		c.Inputs = append(c.Inputs, cin)
+	}
+
+	var buf bytes.Buffer
+	c.serialize(&buf, false)
+	binary.Write(&buf, binary.LittleEndian, uint32(hashType))
+	return doubleSHA256(buf.Bytes()), nil
+}
+
+// WitnessV0SigHash returns the BIP143 hash signed by segwit version 0
+// input i, which spends amount satoshis and whose script code is
+// scriptCode: the P2PKH script of the key for P2WPKH, the witness script
+// for P2WSH. Only SIGHASH_ALL is supported.
+//
+// https://github.com/bitcoin/bips/blob/master/bip-0143.mediawiki
+func (t *Tx) WitnessV0SigHash(i int, scriptCode []byte, amount int64, hashType byte) ([32]byte, error) {
+	if i < 0 || i >= len(t.Inputs) {
+		return [32]byte{}, fmt.Errorf("tx: no input %d", i)
+	}
+	if hashType != SigHashAll {
+		return [32]byte{}, fmt.Errorf("tx: unsupported hash type 0x%02x", hashType)
+	}
+	var prevouts, sequences, outputs bytes.Buffer
+	for _, in := range t.Inputs {
+		prevouts.Write(in.PreviousOutPoint.Hash[:])
+		binary.Write(&prevouts, binary.LittleEndian, in.PreviousOutPoint.Index)
+		binary.Write(&sequences, binary.LittleEndian, in.Sequence)
+	}
+	for _, out := range t.Outputs {
+		writeTxOut(&outputs, out)
+	}
+	hashPrevouts := doubleSHA256(prevouts.Bytes())
+	hashSequence := doubleSHA256(sequences.Bytes())
+	hashOutputs := doubleSHA256(outputs.Bytes())
+
+	in := t.Inputs[i]
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, t.Version)
+	buf.Write(hashPrevouts[:])
+	buf.Write(hashSequence[:])
+	buf.Write(in.PreviousOutPoint.Hash[:])
+	binary.Write(&buf, binary.LittleEndian, in.PreviousOutPoint.Index)
+	WriteVarBytes(&buf, scriptCode)
+	binary.Write(&buf, binary.LittleEndian, amount)
+	binary.Write(&buf, binary.LittleEndian, in.Sequence)
+	buf.Write(hashOutputs[:])
+	binary.Write(&buf, binary.LittleEndian, t.LockTime)
+	binary.Write(&buf, binary.LittleEndian, uint32(hashType))
+	return doubleSHA256(buf.Bytes()), nil
+}
+
+// TaprootSigHash returns the BIP341 hash signed by a key path spend of
+// input i. prevOuts are the outputs spent by every input of the
+// transaction, in order. Only SIGHASH_DEFAULT and SIGHASH_ALL are
+// supported, without annex.
+//
+// https://github.com/bitcoin/bips/blob/master/bip-0341.mediawiki#common-signature-message
+func (t *Tx) TaprootSigHash(i int, prevOuts []*TxOut, hashType byte) ([32]byte, error) {
+	if i < 0 || i >= len(t.Inputs) {
+		return [32]byte{}, fmt.Errorf("tx: no input %d", i)
+	}
+	if len(prevOuts) != len(t.Inputs) {
+		return [32]byte{}, errors.New("tx: taproot signatures need the outputs spent by every input")
+	}
+	if hashType != SigHashDefault && hashType != SigHashAll {
+		return [32]byte{}, fmt.Errorf("tx: unsupported hash type 0x%02x", hashType)
+	}
+	var prevouts, amounts, scriptPubKeys, sequences, outputs bytes.Buffer
+	for j, in := range t.Inputs {
+		prevouts.Write(in.PreviousOutPoint.Hash[:])
+		binary.Write(&prevouts, binary.LittleEndian, in.PreviousOutPoint.Index)
+		binary.Write(&amounts, binary.LittleEndian, prevOuts[j].Value)
+		WriteVarBytes(&scriptPubKeys, prevOuts[j].ScriptPubKey)
+		binary.Write(&sequences, binary.LittleEndian, in.Sequence)
+	}
+	for _, out := range t.Outputs {
+		writeTxOut(&outputs, out)
+	}
+
+	var buf bytes.Buffer
+	// sighash epoch
+	buf.WriteByte(0x00)
+	buf.WriteByte(hashType)
+	binary.Write(&buf, binary.LittleEndian, t.Version)
+	binary.Write(&buf, binary.LittleEndian, t.LockTime)
+	for _, b := range []*bytes.Buffer{&prevouts, &amounts, &scriptPubKeys, &sequences, &outputs} {
+		sum := sha256.Sum256(b.Bytes())
+		buf.Write(sum[:])
+	}
+	// spend type: key path, no annex
+	buf.WriteByte(0x00)
+	binary.Write(&buf, binary.LittleEndian, uint32(i))
+	return taproot.TaggedHash("TapSighash", buf.Bytes()), nil
+}
@@ -0,0 +1,141 @@
+package tx
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/smallnest/bitcoin/wallet/ecc"
+)
+
+// bip143Native is the native P2WPKH example of BIP143. Input 0 spends a
+// P2PK output and is signed with a legacy signature, input 1 spends a
+// P2WPKH output of 6 BTC.
+const bip143Native = "0100000002fff7f7881a8099afa6940d42d1e7f6362bec38171ea3edf433541db4e4ad969f0000000000eeffffffef51e1b804cc89d182d279655c3aa89e815b1b309fe287d9b2b55d57b90ec68a0100000000ffffffff02202cb206000000001976a9148280b37df378db99f66f85c95a783a76ac7a6d5988ac9093510d000000001976a9143bde42dbee7e4dbe6a21b2d50ce2f0167faa815988ac11000000"
+
+func TestLegacySigHash(t *testing.T) {
+	tx, err := Deserialize(decodeHex(t, bip143Native))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The P2PK output spent by input 0 and the signature of the signed
+	// transaction in BIP143, without its hash type byte.
+	pubKey := decodeHex(t, "03c9f4836b9a4f77fc0d81f7bcb01b7f1b35916864b9476c241ce9fc198bd25432")
+	subscript := append(PushData(pubKey), 0xac)
+	sig := decodeHex(t, "30450221008b9d1dc26ba6a9cb62127b02742fa9d754cd3bebf337f7a55d114c8e5cdd30be022040529b194ba3f9281a99f2b1c0a19c0489bc22ede944ccf4ecbab4cc618ef3ed")
+
+	hash, err := tx.LegacySigHash(0, subscript, SigHashAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ecc.Verify(hash[:], sig, pubKey) {
+		t.Errorf("the BIP143 signature of input 0 does not verify with sighash %x", hash)
+	}
+	// The hash commits to the script of the input being signed.
+	other, _ := tx.LegacySigHash(1, subscript, SigHashAll)
+	if other == hash {
+		t.Error("inputs 0 and 1 have the same sighash")
+	}
+	if _, err := tx.LegacySigHash(0, subscript, 0x02); err == nil {
+		t.Error("SIGHASH_NONE accepted")
+	}
+}
+
+func TestWitnessV0SigHash(t *testing.T) {
+	for _, v := range []struct {
+		name       string
+		tx         string
+		input      int
+		scriptCode string
+		amount     int64
+		sigHash    string
+		pubKey     string
+		sig        string
+	}{
+		{
+			name:       "native P2WPKH",
+			tx:         bip143Native,
+			input:      1,
+			scriptCode: "76a9141d0f172a0ecb48aee1be1f2687d2963ae33f71a188ac",
+			amount:     600000000,
+			sigHash:    "c37af31116d1b27caf68aae9e3ac82f1477929014d5b917657d0eb49478cb670",
+			pubKey:     "025476c2e83188368da1ff3e292e7acafcdb3566bb0ad253f62fc70f07aeee6357",
+			sig:        "304402203609e17b84f6a7d30c80bfa610b5b4542f32a8a0d5447a12fb1366d7f01cc44a0220573a954c4518331561406f90300e8f3358f51928d43c212a8caed02de67eebee",
+		},
+		{
+			name:       "P2SH-P2WPKH",
+			tx:         "0100000001db6b1b20aa0fd7b23880be2ecbd4a98130974cf4748fb66092ac4d3ceb1a54770100000000feffffff02b8b4eb0b000000001976a914a457b684d7f0d539a46a45bbc043f35b59d0d96388ac0008af2f000000001976a914fd270b1ee6abcaea97fea7ad0402e8bd8ad6d77c88ac92040000",
+			input:      0,
+			scriptCode: "76a91479091972186c449eb1ded22b78e40d009bdf008988ac",
+			amount:     1000000000,
+			sigHash:    "64f3b0f4dd2bb3aa1ce8566d220cc74dda9df97d8490cc81d89d735c92e59fb6",
+		},
+	} {
+		tx, err := Deserialize(decodeHex(t, v.tx))
+		if err != nil {
+			t.Fatal(err)
+		}
+		hash, err := tx.WitnessV0SigHash(v.input, decodeHex(t, v.scriptCode), v.amount, SigHashAll)
+		if err != nil {
+			t.Errorf("%s: %v", v.name, err)
+			continue
+		}
+		if got := hex.EncodeToString(hash[:]); got != v.sigHash {
+			t.Errorf("%s: got %s, want %s", v.name, got, v.sigHash)
+		}
+		if v.sig != "" && !ecc.Verify(hash[:], decodeHex(t, v.sig), decodeHex(t, v.pubKey)) {
+			t.Errorf("%s: the BIP143 signature does not verify", v.name)
+		}
+	}
+}
+
+// TestTaprootSigHash checks the key path spending vectors of BIP341 that
+// use SIGHASH_ALL and SIGHASH_DEFAULT, the hash types TaprootSigHash
+// supports.
+func TestTaprootSigHash(t *testing.T) {
+	tx, err := Deserialize(decodeHex(t, "02000000097de20cbff686da83a54981d2b9bab3586f4ca7e48f57f5b55963115f3b334e9c010000000000000000d7b7cab57b1393ace2d064f4d4a2cb8af6def61273e127517d44759b6dafdd990000000000fffffffff8e1f583384333689228c5d28eac13366be082dc57441760d957275419a418420000000000fffffffff0689180aa63b30cb162a73c6d2a38b7eeda2a83ece74310fda0843ad604853b0100000000feffffffaa5202bdf6d8ccd2ee0f0202afbbb7461d9264a25e5bfd3c5a52ee1239e0ba6c0000000000feffffff956149bdc66faa968eb2be2d2faa29718acbfe3941215893a2a3446d32acd050000000000000000000e664b9773b88c09c32cb70a2a3e4da0ced63b7ba3b22f848531bbb1d5d5f4c94010000000000000000e9aa6b8e6c9de67619e6a3924ae25696bb7b694bb677a632a74ef7eadfd4eabf0000000000ffffffffa778eb6a263dc090464cd125c466b5a99667720b1c110468831d058aa1b82af10100000000ffffffff0200ca9a3b000000001976a91406afd46bcdfd22ef94ac122aa11f241244a37ecc88ac807840cb0000000020ac9a87f5594be208f8532db38cff670c450ed2fea8fcdefcc9a663f78bab962b0065cd1d"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var prevOuts []*TxOut
+	for _, u := range []struct {
+		script string
+		amount int64
+	}{
+		{"512053a1f6e454df1aa2776a2814a721372d6258050de330b3c6d10ee8f4e0dda343", 420000000},
+		{"5120147c9c57132f6e7ecddba9800bb0c4449251c92a1e60371ee77557b6620f3ea3", 462000000},
+		{"76a914751e76e8199196d454941c45d1b3a323f1433bd688ac", 294000000},
+		{"5120e4d810fd50586274face62b8a807eb9719cef49c04177cc6b76a9a4251d5450e", 504000000},
+		{"512091b64d5324723a985170e4dc5a0f84c041804f2cd12660fa5dec09fc21783605", 630000000},
+		{"00147dd65592d0ab2fe0d0257d571abf032cd9db93dc", 378000000},
+		{"512075169f4001aa68f15bbed28b218df1d0a62cbbcf1188c6665110c293c907b831", 672000000},
+		{"5120712447206d7a5238acc7ff53fbe94a3b64539ad291c7cdbc490b7577e4b17df5", 546000000},
+		{"512077e30a5522dd9f894c3f8b8bd4c4b2cf82ca7da8a3ea6a239655c39c050ab220", 588000000},
+	} {
+		prevOuts = append(prevOuts, &TxOut{Value: u.amount, ScriptPubKey: decodeHex(t, u.script)})
+	}
+
+	for _, v := range []struct {
+		input    int
+		hashType byte
+		sigHash  string
+	}{
+		{3, SigHashAll, "bf013ea93474aa67815b1b6cc441d23b64fa310911d991e713cd34c7f5d46669"},
+		{4, SigHashDefault, "4f900a0bae3f1446fd48490c2958b5a023228f01661cda3496a11da502a7f7ef"},
+	} {
+		hash, err := tx.TaprootSigHash(v.input, prevOuts, v.hashType)
+		if err != nil {
+			t.Errorf("input %d: %v", v.input, err)
+			continue
+		}
+		if got := hex.EncodeToString(hash[:]); got != v.sigHash {
+			t.Errorf("input %d: got %s, want %s", v.input, got, v.sigHash)
+		}
+	}
+
+	if _, err := tx.TaprootSigHash(0, prevOuts, 0x03); err == nil {
+		t.Error("SIGHASH_SINGLE accepted")
+	}
+	if _, err := tx.TaprootSigHash(0, prevOuts[1:], SigHashDefault); err == nil {
+		t.Error("missing spent outputs accepted")
+	}
+}
@@ -0,0 +1,337 @@
+// Package tx serializes bitcoin transactions and computes their signature
+// hashes.
+//
+// https://bitcoin.org/en/developer-reference#raw-transaction-format
+// https://github.com/bitcoin/bips/blob/master/bip-0144.mediawiki
+package tx
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Hash is a transaction id in internal byte order, the reverse of the hex
+// form shown by explorers.
+type Hash [32]byte
+
+// String returns the hash in the usual reversed hex form.
+func (h Hash) String() string {
+	r := make([]byte, 32)
+	for i := range h {
+		r[i] = h[31-i]
+	}
+	return hex.EncodeToString(r)
+}
+
+// ParseHash decodes a hash in the reversed hex form.
+func ParseHash(s string) (Hash, error) {
+	var h Hash
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return h, err
+	}
+	if len(b) != 32 {
+		return h, fmt.Errorf("tx: hash must be 32 bytes, got %d", len(b))
+	}
+	for i := range b {
+		h[i] = b[31-i]
+	}
+	return h, nil
+}
+
+// OutPoint references an output of a previous transaction.
+type OutPoint struct {
+	Hash  Hash
+	Index uint32
+}
+
+// TxIn is a transaction input.
+type TxIn struct {
+	PreviousOutPoint OutPoint
+	ScriptSig        []byte
+	Sequence         uint32
+	// Witness is the segwit witness stack of the input.
+	Witness [][]byte
+}
+
+// TxOut is a transaction output.
+type TxOut struct {
+	// Value is the amount in satoshis.
+	Value        int64
+	ScriptPubKey []byte
+}
+
+// Tx is a transaction.
+type Tx struct {
+	Version  int32
+	Inputs   []*TxIn
+	Outputs  []*TxOut
+	LockTime uint32
+}
+
+// HasWitness reports whether any input has a witness.
+func (t *Tx) HasWitness() bool {
+	for _, in := range t.Inputs {
+		if len(in.Witness) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Serialize encodes the transaction, in the BIP144 segwit format if it has
+// witnesses.
+func (t *Tx) Serialize() []byte {
+	var buf bytes.Buffer
+	t.serialize(&buf, t.HasWitness())
+	return buf.Bytes()
+}
+
+// SerializeNoWitness encodes the transaction without witnesses, the form
+// the transaction id is computed from.
+func (t *Tx) SerializeNoWitness() []byte {
+	var buf bytes.Buffer
+	t.serialize(&buf, false)
+	return buf.Bytes()
+}
+
+func (t *Tx) serialize(buf *bytes.Buffer, witness bool) {
+	//Version field, little-endian
+	binary.Write(buf, binary.LittleEndian, t.Version)
+	if witness {
+		//Segwit marker and flag
+		buf.Write([]byte{0x00, 0x01})
+	}
+
+	WriteVarInt(buf, uint64(len(t.Inputs)))
+	for _, in := range t.Inputs {
+		//Previous transaction hash, output index, script sig and sequence_no
+		buf.Write(in.PreviousOutPoint.Hash[:])
+		binary.Write(buf, binary.LittleEndian, in.PreviousOutPoint.Index)
+		WriteVarBytes(buf, in.ScriptSig)
+		binary.Write(buf, binary.LittleEndian, in.Sequence)
+	}
+
+	WriteVarInt(buf, uint64(len(t.Outputs)))
+	for _, out := range t.Outputs {
+		writeTxOut(buf, out)
+	}
+
+	if witness {
+		for _, in := range t.Inputs {
+			writeWitness(buf, in.Witness)
+		}
+	}
+
+	//Lock time field
+	binary.Write(buf, binary.LittleEndian, t.LockTime)
+}
+
+func writeTxOut(buf *bytes.Buffer, out *TxOut) {
+	//Satoshis to send and the script pub key
+	binary.Write(buf, binary.LittleEndian, out.Value)
+	WriteVarBytes(buf, out.ScriptPubKey)
+}
+
+func writeWitness(buf *bytes.Buffer, stack [][]byte) {
+	WriteVarInt(buf, uint64(len(stack)))
+	for _, item := range stack {
+		WriteVarBytes(buf, item)
+	}
+}
+
+// TxID returns the transaction id: the double SHA256 of the transaction
+// without witnesses.
+func (t *Tx) TxID() Hash {
+	return Hash(doubleSHA256(t.SerializeNoWitness()))
+}
+
+// SerializeWitness encodes a witness stack on its own, with the item count
+// and the length of each item.
+func SerializeWitness(stack [][]byte) []byte {
+	var buf bytes.Buffer
+	writeWitness(&buf, stack)
+	return buf.Bytes()
+}
+
+// ParseWitness decodes a witness stack encoded by SerializeWitness.
+func ParseWitness(b []byte) ([][]byte, error) {
+	r := bytes.NewReader(b)
+	stack, err := readWitness(r)
+	if err != nil {
+		return nil, err
+	}
+	if r.Len() != 0 {
+		return nil, errors.New("tx: trailing bytes after the witness")
+	}
+	return stack, nil
+}
+
+func readWitness(r *bytes.Reader) ([][]byte, error) {
+	n, err := ReadVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(r.Len()) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	stack := make([][]byte, n)
+	for i := range stack {
+		if stack[i], err = ReadVarBytes(r); err != nil {
+			return nil, err
+		}
+	}
+	return stack, nil
+}
+
+// Deserialize decodes a transaction in either the legacy or the segwit
+// format.
+func Deserialize(b []byte) (*Tx, error) {
+	r := bytes.NewReader(b)
+	t := &Tx{}
+	if err := binary.Read(r, binary.LittleEndian, &t.Version); err != nil {
+		return nil, err
+	}
+
+	nIn, err := ReadVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+	witness := false
+	if nIn == 0 {
+		// An empty input list is the segwit marker, followed by the flag.
+		flag, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if flag != 0x01 {
+			return nil, fmt.Errorf("tx: unknown segwit flag 0x%02x", flag)
+		}
+		witness = true
+		if nIn, err = ReadVarInt(r); err != nil {
+			return nil, err
+		}
+	}
+	if nIn > uint64(r.Len()) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	for i := uint64(0); i < nIn; i++ {
+		in := &TxIn{}
+		if _, err := io.ReadFull(r, in.PreviousOutPoint.Hash[:]); err != nil {
+			return nil, err
+		}
+		if err := binary.Read(r, binary.LittleEndian, &in.PreviousOutPoint.Index); err != nil {
+			return nil, err
+		}
+		if in.ScriptSig, err = ReadVarBytes(r); err != nil {
+			return nil, err
+		}
+		if err := binary.Read(r, binary.LittleEndian, &in.Sequence); err != nil {
+			return nil, err
+		}
+		t.Inputs = append(t.Inputs, in)
+	}
+
+	nOut, err := ReadVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+	if nOut > uint64(r.Len()) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	for i := uint64(0); i < nOut; i++ {
+		out := &TxOut{}
+		if err := binary.Read(r, binary.LittleEndian, &out.Value); err != nil {
+			return nil, err
+		}
+		if out.ScriptPubKey, err = ReadVarBytes(r); err != nil {
+			return nil, err
+		}
+		t.Outputs = append(t.Outputs, out)
+	}
+
+	if witness {
+		for _, in := range t.Inputs {
+			if in.Witness, err = readWitness(r); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := binary.Read(r, binary.LittleEndian, &t.LockTime); err != nil {
+		return nil, err
+	}
+	if r.Len() != 0 {
+		return nil, errors.New("tx: trailing bytes after the transaction")
+	}
+	return t, nil
+}
+
+// WriteVarInt writes a compact size integer.
+func WriteVarInt(buf *bytes.Buffer, n uint64) {
+	switch {
+	case n < 0xfd:
+		buf.WriteByte(byte(n))
+	case n <= 0xffff:
+		buf.WriteByte(0xfd)
+		binary.Write(buf, binary.LittleEndian, uint16(n))
+	case n <= 0xffffffff:
+		buf.WriteByte(0xfe)
+		binary.Write(buf, binary.LittleEndian, uint32(n))
+	default:
+		buf.WriteByte(0xff)
+		binary.Write(buf, binary.LittleEndian, n)
+	}
+}
+
+// WriteVarBytes writes b prefixed with its length as a compact size.
+func WriteVarBytes(buf *bytes.Buffer, b []byte) {
+	WriteVarInt(buf, uint64(len(b)))
+	buf.Write(b)
+}
+
+// ReadVarInt reads a compact size integer.
+func ReadVarInt(r io.Reader) (uint64, error) {
+	var prefix [1]byte
+	if _, err := io.ReadFull(r, prefix[:]); err != nil {
+		return 0, err
+	}
+	switch prefix[0] {
+	case 0xfd:
+		var n uint16
+		err := binary.Read(r, binary.LittleEndian, &n)
+		return uint64(n), err
+	case 0xfe:
+		var n uint32
+		err := binary.Read(r, binary.LittleEndian, &n)
+		return uint64(n), err
+	case 0xff:
+		var n uint64
+		err := binary.Read(r, binary.LittleEndian, &n)
+		return n, err
+	}
+	return uint64(prefix[0]), nil
+}
+
+// ReadVarBytes reads bytes prefixed with their length as a compact size.
+func ReadVarBytes(r *bytes.Reader) ([]byte, error) {
+	n, err := ReadVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(r.Len()) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	b := make([]byte, n)
+	_, err = io.ReadFull(r, b)
+	return b, err
+}
+
+func doubleSHA256(b []byte) [32]byte {
+	first := sha256.Sum256(b)
+	return sha256.Sum256(first[:])
+}
@@ -0,0 +1,315 @@
+package walletfile
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/smallnest/bitcoin/wallet/address"
+	"github.com/smallnest/bitcoin/wallet/bip39"
+	"github.com/smallnest/bitcoin/wallet/ecc"
+	"github.com/smallnest/bitcoin/wallet/hdkey"
+	"github.com/smallnest/bitcoin/wallet/taproot"
+	"github.com/smallnest/bitcoin/wallet/wif"
+)
+
+// EntryType tells what an entry holds.
+type EntryType string
+
+const (
+	// KeyEntry is a single private key.
+	KeyEntry EntryType = "key"
+	// SeedEntry is a BIP39 mnemonic and one of its BIP44/49/84/86 accounts.
+	SeedEntry EntryType = "seed"
+)
+
+// ErrNotFound is returned when no entry has the requested label or holds
+// the requested address.
+var ErrNotFound = errors.New("walletfile: not found")
+
+// Entry is a private key or HD seed stored in the wallet.
+type Entry struct {
+	Label   string          `json:"label"`
+	Type    EntryType       `json:"type"`
+	Created time.Time       `json:"created"`
+	Network address.Network `json:"network"`
+
+	// WIF is the private key of a KeyEntry.
+	WIF string `json:"wif,omitempty"`
+
+	// Mnemonic and Passphrase are the BIP39 seed of a SeedEntry.
+	Mnemonic   string `json:"mnemonic,omitempty"`
+	Passphrase string `json:"passphrase,omitempty"`
+	// The derivation state of a SeedEntry: the account
+	// m/purpose'/coin_type'/account' and the next unused receive and change
+	// indexes.
+	Purpose     uint32 `json:"purpose,omitempty"`
+	Account     uint32 `json:"account"`
+	NextReceive uint32 `json:"next_receive"`
+	NextChange  uint32 `json:"next_change"`
+}
+
+// Entries returns the entries of an unlocked wallet.
+func (w *Wallet) Entries() ([]*Entry, error) {
+	if w.Locked() {
+		return nil, ErrLocked
+	}
+	return w.entries, nil
+}
+
+// Entry returns the entry with the given label.
+func (w *Wallet) Entry(label string) (*Entry, error) {
+	if w.Locked() {
+		return nil, ErrLocked
+	}
+	for _, e := range w.entries {
+		if e.Label == label {
+			return e, nil
+		}
+	}
+	return nil, fmt.Errorf("walletfile: no entry labeled %q: %w", label, ErrNotFound)
+}
+
+func (w *Wallet) add(e *Entry) (*Entry, error) {
+	if w.Locked() {
+		return nil, ErrLocked
+	}
+	if e.Label == "" {
+		return nil, errors.New("walletfile: an entry needs a label")
+	}
+	for _, o := range w.entries {
+		if o.Label == e.Label {
+			return nil, fmt.Errorf("walletfile: an entry labeled %q already exists", e.Label)
+		}
+	}
+	e.Created = time.Now().UTC().Truncate(time.Second)
+	w.entries = append(w.entries, e)
+	return e, nil
+}
+
+// AddKey adds a private key. Call Save to write it.
+func (w *Wallet) AddKey(label string, key *wif.WIF) (*Entry, error) {
+	return w.add(&Entry{Label: label, Type: KeyEntry, Network: key.Network, WIF: key.String()})
+}
+
+// AddSeed adds a BIP39 mnemonic and the account to derive addresses from.
+// The mnemonic is not validated. Call Save to write it.
+func (w *Wallet) AddSeed(label, mnemonic, passphrase string, net address.Network, purpose, account uint32) (*Entry, error) {
+	switch purpose {
+	case 44, 49, 84, 86:
+	default:
+		return nil, fmt.Errorf("walletfile: unsupported purpose %d", purpose)
+	}
+	if account >= hdkey.HardenedOffset {
+		return nil, errors.New("walletfile: account must be below 2^31")
+	}
+	return w.add(&Entry{
+		Label:      label,
+		Type:       SeedEntry,
+		Network:    net,
+		Mnemonic:   mnemonic,
+		Passphrase: passphrase,
+		Purpose:    purpose,
+		Account:    account,
+	})
+}
+
+// Remove deletes the entry with the given label. Call Save to write it.
+func (w *Wallet) Remove(label string) error {
+	e, err := w.Entry(label)
+	if err != nil {
+		return err
+	}
+	for i, o := range w.entries {
+		if o == e {
+			w.entries = append(w.entries[:i], w.entries[i+1:]...)
+		}
+	}
+	e.wipe()
+	return nil
+}
+
+// PrivateKey returns the private key of a KeyEntry.
+func (e *Entry) PrivateKey() (*wif.WIF, error) {
+	if e.Type != KeyEntry {
+		return nil, fmt.Errorf("walletfile: %q is not a single key", e.Label)
+	}
+	return wif.Decode(e.WIF)
+}
+
+// AccountKey returns the extended private key of the account of a
+// SeedEntry.
+func (e *Entry) AccountKey() (*hdkey.Key, error) {
+	if e.Type != SeedEntry {
+		return nil, fmt.Errorf("walletfile: %q is not an HD seed", e.Label)
+	}
+	master, err := hdkey.NewMaster(bip39.NewSeed(e.Mnemonic, e.Passphrase), e.Network)
+	if err != nil {
+		return nil, err
+	}
+	return master.DerivePath(e.AccountPath())
+}
+
+// AccountPath returns m/purpose'/coin_type'/account' of a SeedEntry.
+func (e *Entry) AccountPath() []uint32 {
+	coinType := uint32(0)
+	if e.Network != address.Mainnet {
+		coinType = 1
+	}
+	return []uint32{
+		e.Purpose + hdkey.HardenedOffset,
+		coinType + hdkey.HardenedOffset,
+		e.Account + hdkey.HardenedOffset,
+	}
+}
+
+// Derive returns the key at index of the receive (0) or change (1) chain
+// of a SeedEntry, and its path.
+func (e *Entry) Derive(change, index uint32) (*hdkey.Key, string, error) {
+	acct, err := e.AccountKey()
+	if err != nil {
+		return nil, "", err
+	}
+	k, err := acct.DerivePath([]uint32{change, index})
+	if err != nil {
+		return nil, "", err
+	}
+	return k, hdkey.FormatPath(append(e.AccountPath(), change, index)), nil
+}
+
+// NextAddress derives the next unused receive or change address of a
+// SeedEntry and advances the derivation state. Call Save to keep it.
+func (e *Entry) NextAddress(change bool) (*address.Address, string, error) {
+	chain, next := uint32(0), &e.NextReceive
+	if change {
+		chain, next = 1, &e.NextChange
+	}
+	for *next < hdkey.HardenedOffset {
+		k, path, err := e.Derive(chain, *next)
+		*next++
+		if err == hdkey.ErrInvalidChild {
+			continue
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		addr, err := e.address(k.PublicKey())
+		return addr, path, err
+	}
+	return nil, "", errors.New("walletfile: no addresses left in the chain")
+}
+
+// address returns the address of a public key for the purpose of the entry.
+func (e *Entry) address(pubKey []byte) (*address.Address, error) {
+	return purposeAddress(e.Network, e.Purpose, pubKey)
+}
+
+// purposes are the BIP44, BIP49, BIP84 and BIP86 purposes, one for each
+// address type.
+var purposes = []uint32{44, 49, 84, 86}
+
+// purposeAddress returns the address of a public key for a purpose:
+// P2PKH, P2SH-P2WPKH, P2WPKH or a P2TR key path spend.
+func purposeAddress(net address.Network, purpose uint32, pubKey []byte) (*address.Address, error) {
+	switch purpose {
+	case 44:
+		return address.NewP2PKHFromKey(net, pubKey), nil
+	case 49:
+		return address.NewP2SHP2WPKHFromKey(net, pubKey)
+	case 84:
+		return address.NewP2WPKHFromKey(net, pubKey)
+	case 86:
+		outputKey, _, err := taproot.OutputKey(pubKey, nil)
+		if err != nil {
+			return nil, err
+		}
+		return address.New(net, address.P2TR, outputKey)
+	}
+	return nil, fmt.Errorf("walletfile: unsupported purpose %d", purpose)
+}
+
+// FindKey returns the private key of an address. Seed entries are searched
+// up to gap addresses past their derivation state.
+func (w *Wallet) FindKey(addr *address.Address, gap uint32) (*wif.WIF, error) {
+	if w.Locked() {
+		return nil, ErrLocked
+	}
+	for _, e := range w.entries {
+		switch e.Type {
+		case KeyEntry:
+			if !sameNetwork(e.Network, addr.Network) {
+				continue
+			}
+			key, err := e.PrivateKey()
+			if err != nil {
+				return nil, err
+			}
+			pub, err := ecc.PublicKey(key.PrivateKey[:], key.Compressed)
+			if err != nil {
+				return nil, err
+			}
+			// A single key may be used with any address type, the segwit
+			// ones only with its compressed public key.
+			for _, purpose := range purposes {
+				if purpose != 44 && !key.Compressed {
+					continue
+				}
+				a, err := purposeAddress(addr.Network, purpose, pub)
+				if err == nil && a.Kind == addr.Kind && bytes.Equal(a.Hash, addr.Hash) {
+					return key, nil
+				}
+			}
+		case SeedEntry:
+			if !sameNetwork(e.Network, addr.Network) {
+				continue
+			}
+			key, err := e.find(addr, gap)
+			if err != ErrNotFound {
+				return key, err
+			}
+		}
+	}
+	return nil, fmt.Errorf("walletfile: no key for %s: %w", addr, ErrNotFound)
+}
+
+func (e *Entry) find(addr *address.Address, gap uint32) (*wif.WIF, error) {
+	acct, err := e.AccountKey()
+	if err != nil {
+		return nil, err
+	}
+	for chain, next := range []uint32{e.NextReceive, e.NextChange} {
+		c, err := acct.Child(uint32(chain))
+		if err != nil {
+			return nil, err
+		}
+		for i := uint32(0); i < next+gap && i < hdkey.HardenedOffset; i++ {
+			k, err := c.Child(i)
+			if err == hdkey.ErrInvalidChild {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			a, err := e.address(k.PublicKey())
+			if err != nil {
+				return nil, err
+			}
+			if a.Kind == addr.Kind && bytes.Equal(a.Hash, addr.Hash) {
+				return wif.New(k.Key, e.Network, true)
+			}
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// sameNetwork reports whether keys of n can control addresses of m, which
+// parse as testnet for every test network.
+func sameNetwork(n, m address.Network) bool {
+	return n == m || (n != address.Mainnet && m != address.Mainnet)
+}
+
+// wipe drops the secrets of an entry that is no longer used.
+func (e *Entry) wipe() {
+	e.WIF, e.Mnemonic, e.Passphrase = "", "", ""
+}
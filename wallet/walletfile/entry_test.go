@@ -0,0 +1,102 @@
+package walletfile
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"testing"
+
+	"github.com/smallnest/bitcoin/wallet/address"
+	"github.com/smallnest/bitcoin/wallet/ecc"
+	"github.com/smallnest/bitcoin/wallet/taproot"
+	"github.com/smallnest/bitcoin/wallet/wif"
+)
+
+// unlocked returns an empty wallet that is not backed by a file.
+func unlocked() *Wallet {
+	return &Wallet{key: make([]byte, 32), entries: []*Entry{}}
+}
+
+func TestFindKeyEntry(t *testing.T) {
+	secret := sha256.Sum256([]byte("walletfile key entry"))
+	for _, net := range []address.Network{address.Mainnet, address.Testnet} {
+		key, err := wif.New(secret[:], net, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w := unlocked()
+		if _, err := w.AddKey("key", key); err != nil {
+			t.Fatal(err)
+		}
+
+		pub, _ := ecc.PublicKey(secret[:], true)
+		p2pkh := address.NewP2PKHFromKey(net, pub)
+		p2shP2WPKH, _ := address.NewP2SHP2WPKHFromKey(net, pub)
+		p2wpkh, _ := address.NewP2WPKHFromKey(net, pub)
+		outputKey, _, err := taproot.OutputKey(pub, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p2tr, _ := address.New(net, address.P2TR, outputKey)
+
+		for _, addr := range []*address.Address{p2pkh, p2shP2WPKH, p2wpkh, p2tr} {
+			found, err := w.FindKey(addr, 0)
+			if err != nil {
+				t.Errorf("%s: %v", addr, err)
+				continue
+			}
+			if !bytes.Equal(found.PrivateKey[:], secret[:]) {
+				t.Errorf("%s: found another key", addr)
+			}
+		}
+
+		otherSecret := sha256.Sum256([]byte("another key"))
+		otherPub, _ := ecc.PublicKey(otherSecret[:], true)
+		other, _ := address.NewP2WPKHFromKey(net, otherPub)
+		if _, err := w.FindKey(other, 0); !errors.Is(err, ErrNotFound) {
+			t.Errorf("%s: %v, want ErrNotFound", other, err)
+		}
+	}
+}
+
+// TestFindKeyUncompressed checks that an uncompressed key only matches its
+// P2PKH address, segwit needs the compressed public key.
+func TestFindKeyUncompressed(t *testing.T) {
+	secret := sha256.Sum256([]byte("walletfile uncompressed key"))
+	key, err := wif.New(secret[:], address.Mainnet, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := unlocked()
+	if _, err := w.AddKey("key", key); err != nil {
+		t.Fatal(err)
+	}
+
+	uncompressed, _ := ecc.PublicKey(secret[:], false)
+	if _, err := w.FindKey(address.NewP2PKHFromKey(address.Mainnet, uncompressed), 0); err != nil {
+		t.Error(err)
+	}
+	compressed, _ := ecc.PublicKey(secret[:], true)
+	p2wpkh, _ := address.NewP2WPKHFromKey(address.Mainnet, compressed)
+	if _, err := w.FindKey(p2wpkh, 0); !errors.Is(err, ErrNotFound) {
+		t.Errorf("P2WPKH of an uncompressed key: %v, want ErrNotFound", err)
+	}
+}
+
+func TestFindKeySeed(t *testing.T) {
+	const mnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	for _, purpose := range purposes {
+		w := unlocked()
+		e, err := w.AddSeed("seed", mnemonic, "", address.Mainnet, purpose, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		addr, _, err := e.NextAddress(false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.FindKey(addr, 0); err != nil {
+			t.Errorf("purpose %d, %s: %v", purpose, addr, err)
+		}
+	}
+}
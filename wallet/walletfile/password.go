@@ -0,0 +1,49 @@
+package walletfile
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// PasswordEnv is the environment variable ReadPassword reads first.
+const PasswordEnv = "WALLET_PASSWORD"
+
+// stdin is shared by the prompts, so that lines piped in are not lost in
+// the buffer of a previous prompt.
+var stdin = bufio.NewReader(os.Stdin)
+
+// ReadPassword returns the wallet password from $WALLET_PASSWORD, or
+// prompts for it on stderr. On a terminal the password is read without
+// echo, otherwise a line is read from stdin so that scripts can pipe it in.
+func ReadPassword(prompt string) (string, error) {
+	if p := os.Getenv(PasswordEnv); p != "" {
+		return p, nil
+	}
+	fmt.Fprint(os.Stderr, prompt)
+
+	var p string
+	if fd := int(os.Stdin.Fd()); term.IsTerminal(fd) {
+		b, err := term.ReadPassword(fd)
+		// The newline typed by the user was not echoed either.
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", err
+		}
+		p = string(b)
+	} else {
+		line, err := stdin.ReadString('\n')
+		if err != nil && line == "" {
+			return "", err
+		}
+		p = strings.TrimRight(line, "\r\n")
+	}
+	if p == "" {
+		return "", errors.New("walletfile: empty password")
+	}
+	return p, nil
+}
@@ -0,0 +1,274 @@
+// Package walletfile stores private keys and HD seeds in an encrypted file.
+//
+// The file is JSON. Its header holds the key derivation parameters, scrypt
+// or argon2id, and the entries are encrypted together with AES-256-GCM, so
+// labels and addresses are not visible without the password either.
+//
+// A Wallet is opened locked. Unlock derives the file key from the password
+// and decrypts the entries, Lock forgets the key and the entries again.
+package walletfile
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/smallnest/bitcoin/wallet/entropy"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Version is the version of the file format.
+const Version = 1
+
+// Key derivation functions.
+const (
+	Scrypt   = "scrypt"
+	Argon2id = "argon2id"
+)
+
+var (
+	// ErrLocked is returned when the entries are accessed while the wallet
+	// is locked.
+	ErrLocked = errors.New("walletfile: wallet is locked")
+	// ErrPassword is returned by Unlock when the password is wrong or the
+	// file has been tampered with.
+	ErrPassword = errors.New("walletfile: wrong password")
+)
+
+// KDF holds the parameters deriving the file key from the password.
+type KDF struct {
+	Name string `json:"name"`
+	Salt []byte `json:"salt"`
+	// scrypt
+	N int `json:"n,omitempty"`
+	R int `json:"r,omitempty"`
+	P int `json:"p,omitempty"`
+	// argon2id
+	Time    uint32 `json:"time,omitempty"`
+	Memory  uint32 `json:"memory,omitempty"` // KiB
+	Threads uint8  `json:"threads,omitempty"`
+}
+
+// NewKDF returns the default parameters of the named function with a
+// random salt.
+func NewKDF(name string) (KDF, error) {
+	k := KDF{Name: name, Salt: make([]byte, 16)}
+	switch name {
+	case Scrypt:
+		k.N, k.R, k.P = 1<<15, 8, 1
+	case Argon2id:
+		k.Time, k.Memory, k.Threads = 3, 64*1024, 4
+	default:
+		return KDF{}, fmt.Errorf("walletfile: unknown key derivation function %q", name)
+	}
+	if _, err := io.ReadFull(entropy.Default, k.Salt); err != nil {
+		return KDF{}, err
+	}
+	return k, nil
+}
+
+func (k KDF) deriveKey(password string) ([]byte, error) {
+	switch k.Name {
+	case Scrypt:
+		return scrypt.Key([]byte(password), k.Salt, k.N, k.R, k.P, 32)
+	case Argon2id:
+		return argon2.IDKey([]byte(password), k.Salt, k.Time, k.Memory, k.Threads, 32), nil
+	}
+	return nil, fmt.Errorf("walletfile: unknown key derivation function %q", k.Name)
+}
+
+// file is the on-disk format.
+type file struct {
+	Version    int    `json:"version"`
+	KDF        KDF    `json:"kdf"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// contents is the plaintext of the ciphertext.
+type contents struct {
+	Entries []*Entry `json:"entries"`
+}
+
+// Wallet is an open wallet file.
+type Wallet struct {
+	path string
+	kdf  KDF
+
+	// set while unlocked
+	key     []byte
+	entries []*Entry
+	file    *file
+}
+
+// Create creates a new empty wallet file, which must not exist yet, and
+// returns it unlocked.
+func Create(path, password, kdfName string) (*Wallet, error) {
+	if _, err := os.Stat(path); err == nil {
+		return nil, fmt.Errorf("walletfile: %s already exists", path)
+	}
+	kdf, err := NewKDF(kdfName)
+	if err != nil {
+		return nil, err
+	}
+	key, err := kdf.deriveKey(password)
+	if err != nil {
+		return nil, err
+	}
+	w := &Wallet{path: path, kdf: kdf, key: key, entries: []*Entry{}}
+	if err := w.Save(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Open reads a wallet file. The wallet is locked.
+func Open(path string) (*Wallet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("walletfile: %s: %v", path, err)
+	}
+	if f.Version != Version {
+		return nil, fmt.Errorf("walletfile: unsupported version %d", f.Version)
+	}
+	return &Wallet{path: path, kdf: f.KDF, file: &f}, nil
+}
+
+// Path returns the path of the wallet file.
+func (w *Wallet) Path() string {
+	return w.path
+}
+
+// Locked reports whether the wallet is locked.
+func (w *Wallet) Locked() bool {
+	return w.key == nil
+}
+
+// Unlock decrypts the entries with the password.
+func (w *Wallet) Unlock(password string) error {
+	if !w.Locked() {
+		return nil
+	}
+	key, err := w.kdf.deriveKey(password)
+	if err != nil {
+		return err
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return err
+	}
+	ad, err := json.Marshal(w.kdf)
+	if err != nil {
+		return err
+	}
+	plaintext, err := aead.Open(nil, w.file.Nonce, w.file.Ciphertext, ad)
+	if err != nil {
+		return ErrPassword
+	}
+	defer wipe(plaintext)
+
+	var c contents
+	if err := json.Unmarshal(plaintext, &c); err != nil {
+		return fmt.Errorf("walletfile: %s: %v", w.path, err)
+	}
+	w.key, w.entries = key, c.Entries
+	return nil
+}
+
+// Lock wipes the file key and forgets the decrypted entries.
+func (w *Wallet) Lock() {
+	wipe(w.key)
+	for _, e := range w.entries {
+		e.wipe()
+	}
+	w.key, w.entries = nil, nil
+}
+
+// Save encrypts the entries with a fresh nonce and replaces the file.
+func (w *Wallet) Save() error {
+	if w.Locked() {
+		return ErrLocked
+	}
+	plaintext, err := json.Marshal(contents{Entries: w.entries})
+	if err != nil {
+		return err
+	}
+	defer wipe(plaintext)
+
+	aead, err := newAEAD(w.key)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(entropy.Default, nonce); err != nil {
+		return err
+	}
+	// The header is authenticated, so the parameters cannot be weakened
+	// without the password.
+	ad, err := json.Marshal(w.kdf)
+	if err != nil {
+		return err
+	}
+	f := &file{
+		Version:    Version,
+		KDF:        w.kdf,
+		Nonce:      nonce,
+		Ciphertext: aead.Seal(nil, nonce, plaintext, ad),
+	}
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write a temporary file and rename it, so a failed write never
+	// leaves a truncated wallet.
+	tmp, err := os.CreateTemp(filepath.Dir(w.path), filepath.Base(w.path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), w.path); err != nil {
+		return err
+	}
+	w.file = f
+	return nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
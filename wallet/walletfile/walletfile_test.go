@@ -0,0 +1,149 @@
+package walletfile
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/smallnest/bitcoin/wallet/address"
+	"github.com/smallnest/bitcoin/wallet/wif"
+)
+
+const mnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+func TestRoundTrip(t *testing.T) {
+	secret := sha256.Sum256([]byte("walletfile round trip"))
+	key, err := wif.New(secret[:], address.Testnet, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, kdf := range []string{Scrypt, Argon2id} {
+		path := filepath.Join(t.TempDir(), "wallet.json")
+		w, err := Create(path, "password", kdf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.AddKey("key", key); err != nil {
+			t.Fatal(err)
+		}
+		seed, err := w.AddSeed("seed", mnemonic, "", address.Mainnet, 84, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		addr, _, err := seed.NextAddress(false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := addr.String(); got != "bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu" {
+			t.Errorf("%s: first address %s", kdf, got)
+		}
+		if err := w.Save(); err != nil {
+			t.Fatal(err)
+		}
+		w.Lock()
+		if _, err := w.Entries(); err != ErrLocked {
+			t.Errorf("%s: Entries of a locked wallet: %v, want ErrLocked", kdf, err)
+		}
+
+		w, err = Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !w.Locked() {
+			t.Errorf("%s: opened unlocked", kdf)
+		}
+		if err := w.Unlock("password"); err != nil {
+			t.Fatal(err)
+		}
+		k, err := w.Entry("key")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, err := k.PrivateKey(); err != nil || got.String() != key.String() {
+			t.Errorf("%s: key %v, %v", kdf, got, err)
+		}
+		s, err := w.Entry("seed")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.Mnemonic != mnemonic || s.Purpose != 84 || s.NextReceive != 1 {
+			t.Errorf("%s: seed entry %+v", kdf, s)
+		}
+		// The derivation state was saved, the next address is index 1.
+		if addr, path, err := s.NextAddress(false); err != nil || addr.String() != "bc1qnjg0jd8228aq7egyzacy8cys3knf9xvrerkf9g" {
+			t.Errorf("%s: second address %s %v, %v", kdf, addr, path, err)
+		}
+	}
+}
+
+func TestWrongPassword(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wallet.json")
+	w, err := Create(path, "password", Scrypt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Lock()
+
+	w, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Unlock("Password"); err != ErrPassword {
+		t.Errorf("wrong password: %v, want ErrPassword", err)
+	}
+	if !w.Locked() {
+		t.Error("unlocked with a wrong password")
+	}
+	if err := w.Unlock("password"); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestKDFTamper checks that the KDF parameters are authenticated: the
+// header is the associated data of the ciphertext, so changing it fails
+// even with the right password.
+func TestKDFTamper(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wallet.json")
+	w, err := Create(path, "password", Scrypt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Lock()
+
+	tamper := func(change func(f *file)) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var f file
+		if err := json.Unmarshal(data, &f); err != nil {
+			t.Fatal(err)
+		}
+		change(&f)
+		if data, err = json.Marshal(f); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(filepath.Dir(path), "tampered.json"), data, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for name, change := range map[string]func(f *file){
+		// Same key, since scrypt ignores fields of other functions.
+		"unused argon2 field": func(f *file) { f.KDF.Time = 1 },
+		"nonce":               func(f *file) { f.Nonce[0] ^= 1 },
+		"ciphertext":          func(f *file) { f.Ciphertext[0] ^= 1 },
+	} {
+		tamper(change)
+		w, err := Open(filepath.Join(filepath.Dir(path), "tampered.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Unlock("password"); err != ErrPassword {
+			t.Errorf("%s: %v, want ErrPassword", name, err)
+		}
+	}
+}
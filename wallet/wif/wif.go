@@ -0,0 +1,83 @@
+// Package wif encodes and decodes private keys in Wallet Import Format.
+//
+// A WIF is the base58check encoding of a version byte (0x80 for mainnet,
+// 0xef for the test networks), the 32-byte private key and, when the key is
+// used with compressed public keys, a trailing 0x01 byte.
+package wif
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/smallnest/bitcoin/wallet/address"
+	"github.com/smallnest/bitcoin/wallet/base58check"
+	"github.com/smallnest/bitcoin/wallet/entropy"
+)
+
+// compressMagic is appended to the private key of a compressed WIF.
+const compressMagic = 0x01
+
+// WIF is a decoded private key.
+type WIF struct {
+	PrivateKey [32]byte
+	// Compressed tells whether the key is used with a compressed public key,
+	// which changes the address it controls.
+	Compressed bool
+	// Network is Mainnet or Testnet. Signet and regtest share the testnet
+	// version byte.
+	Network address.Network
+}
+
+// New returns the WIF of a private key.
+func New(privateKey []byte, net address.Network, compressed bool) (*WIF, error) {
+	if !entropy.ValidScalar(privateKey) {
+		return nil, errors.New("wif: invalid private key")
+	}
+	w := &WIF{Compressed: compressed, Network: net}
+	copy(w.PrivateKey[:], privateKey)
+	return w, nil
+}
+
+// Decode decodes a WIF string and verifies its checksum.
+func Decode(s string) (*WIF, error) {
+	version, payload, err := base58check.Decode(s)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &WIF{}
+	switch version {
+	case address.Mainnet.PrivateKeyID():
+		w.Network = address.Mainnet
+	case address.Testnet.PrivateKeyID():
+		w.Network = address.Testnet
+	default:
+		return nil, fmt.Errorf("wif: unknown version byte 0x%02x", version)
+	}
+
+	switch {
+	case len(payload) == 32:
+	case len(payload) == 33 && payload[32] == compressMagic:
+		w.Compressed = true
+	case len(payload) == 33:
+		return nil, fmt.Errorf("wif: invalid compression flag 0x%02x", payload[32])
+	default:
+		return nil, fmt.Errorf("wif: invalid payload length %d", len(payload))
+	}
+
+	if !entropy.ValidScalar(payload[:32]) {
+		return nil, errors.New("wif: private key out of range")
+	}
+	copy(w.PrivateKey[:], payload[:32])
+	return w, nil
+}
+
+// String encodes the WIF.
+func (w *WIF) String() string {
+	payload := make([]byte, 32, 33)
+	copy(payload, w.PrivateKey[:])
+	if w.Compressed {
+		payload = append(payload, compressMagic)
+	}
+	return base58check.EncodeVersion([]byte{w.Network.PrivateKeyID()}, payload)
+}
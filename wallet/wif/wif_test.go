@@ -0,0 +1,96 @@
+package wif
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/smallnest/bitcoin/wallet/address"
+	"github.com/smallnest/bitcoin/wallet/base58check"
+)
+
+var vectors = []struct {
+	wif        string
+	key        string
+	net        address.Network
+	compressed bool
+}{
+	{"5HueCGU8rMjxEXxiPuD5BDku4MkFqeZyd4dZ1jvhTVqvbTLvyTJ", "0c28fca386c7a227600b2fe50b7cae11ec86d3bf1fbe471be89827e19d72aa1d", address.Mainnet, false},
+	{"KwdMAjGmerYanjeui5SHS7JkmpZvVipYvB2LJGU1ZxJwYvP98617", "0c28fca386c7a227600b2fe50b7cae11ec86d3bf1fbe471be89827e19d72aa1d", address.Mainnet, true},
+	{"5HpHagT65TZzG1PH3CSu63k8DbpvD8s5ip4nEB3kEsreAnchuDf", "0000000000000000000000000000000000000000000000000000000000000001", address.Mainnet, false},
+	{"KwDiBf89QgGbjEhKnhXJuH7LrciVrZi3qYjgd9M7rFU73sVHnoWn", "0000000000000000000000000000000000000000000000000000000000000001", address.Mainnet, true},
+	{"91avARGdfge8E4tZfYLoxeJ5sGBdNJQH4kvjJoQFacbgwmaKkrx", "0000000000000000000000000000000000000000000000000000000000000001", address.Testnet, false},
+	{"cMahea7zqjxrtgAbB7LSGbcQUr1uX1ojuat9jZodMN87JcbXMTcA", "0000000000000000000000000000000000000000000000000000000000000001", address.Testnet, true},
+}
+
+func TestVectors(t *testing.T) {
+	for _, v := range vectors {
+		w, err := Decode(v.wif)
+		if err != nil {
+			t.Errorf("%s: %v", v.wif, err)
+			continue
+		}
+		if got := hex.EncodeToString(w.PrivateKey[:]); got != v.key {
+			t.Errorf("%s: key %s, want %s", v.wif, got, v.key)
+		}
+		if w.Network != v.net || w.Compressed != v.compressed {
+			t.Errorf("%s: %s compressed %v, want %s compressed %v", v.wif, w.Network, w.Compressed, v.net, v.compressed)
+		}
+		if got := w.String(); got != v.wif {
+			t.Errorf("%s: String gives %s", v.wif, got)
+		}
+
+		key, _ := hex.DecodeString(v.key)
+		n, err := New(key, v.net, v.compressed)
+		if err != nil || n.String() != v.wif {
+			t.Errorf("%s: New gives %v, %v", v.wif, n, err)
+		}
+	}
+}
+
+// Signet and regtest keys are encoded like testnet keys.
+func TestTestNetworks(t *testing.T) {
+	key := bytes.Repeat([]byte{0x11}, 32)
+	for _, net := range []address.Network{address.Signet, address.Regtest} {
+		w, _ := New(key, net, true)
+		got, err := Decode(w.String())
+		if err != nil || got.Network != address.Testnet || !strings.HasPrefix(w.String(), "c") {
+			t.Errorf("%s: %s decodes as %v, %v", net, w, got, err)
+		}
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	key := bytes.Repeat([]byte{0x11}, 32)
+	order, _ := hex.DecodeString("fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141")
+	for _, v := range []struct {
+		name    string
+		version byte
+		payload []byte
+	}{
+		{"31 bytes", 0x80, key[:31]},
+		{"34 bytes", 0x80, append(append([]byte(nil), key...), 0x01, 0x01)},
+		{"compression flag 0x02", 0x80, append(append([]byte(nil), key...), 0x02)},
+		{"compression flag 0x00", 0xef, append(append([]byte(nil), key...), 0x00)},
+		{"zero key", 0x80, make([]byte, 32)},
+		{"key equal to the group order", 0x80, order},
+		{"address version", 0x00, key},
+	} {
+		s := base58check.EncodeVersion([]byte{v.version}, v.payload)
+		if w, err := Decode(s); err == nil {
+			t.Errorf("%s: decoded as %x", v.name, w.PrivateKey)
+		}
+	}
+
+	// The last character of a valid WIF changed.
+	if _, err := Decode("5HueCGU8rMjxEXxiPuD5BDku4MkFqeZyd4dZ1jvhTVqvbTLvyTK"); err == nil {
+		t.Error("bad checksum accepted")
+	}
+	if _, err := New(make([]byte, 32), address.Mainnet, true); err == nil {
+		t.Error("New accepted a zero key")
+	}
+	if _, err := New(key[:31], address.Mainnet, true); err == nil {
+		t.Error("New accepted a 31-byte key")
+	}
+}